@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate adds the assignee/SLA columns)
+	config.ConnectDB()
+	config.InitModeration()
+
+	log.Println("🔄 Backfilling SLA deadlines on existing reports...")
+
+	var reports []models.ItemReport
+	if err := config.DB.Where("due_at IS NULL").Find(&reports).Error; err != nil {
+		log.Fatalf("❌ Failed to fetch reports: %v", err)
+	}
+
+	updated := 0
+	for _, report := range reports {
+		dueAt := report.CreatedAt.Add(config.ReportSLA(report.Reason))
+		if err := config.DB.Model(&report).Update("due_at", dueAt).Error; err != nil {
+			log.Printf("⚠️  Failed to update report %d: %v", report.ID, err)
+			continue
+		}
+		updated++
+	}
+
+	fmt.Printf("✨ Migration completed! Backfilled %d of %d reports.\n", updated, len(reports))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate creates core_searchterm)
+	config.ConnectDB()
+
+	log.Println("🔄 Building search index for existing posts...")
+
+	var items []models.LostItem
+	if err := config.DB.Find(&items).Error; err != nil {
+		log.Fatalf("❌ Failed to fetch items: %v", err)
+	}
+
+	indexed := 0
+	for i := range items {
+		if err := utils.IndexItem(config.DB, &items[i]); err != nil {
+			log.Printf("⚠️  Failed to index item %d: %v", items[i].ID, err)
+			continue
+		}
+		indexed++
+	}
+
+	fmt.Printf("✨ Migration completed! Indexed %d of %d posts.\n", indexed, len(items))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+	"temuin/storage"
+	"temuin/utils"
+
+	"github.com/joho/godotenv"
+)
+
+// hashedImage is a fingerprinted photo with the owner of its post
+type hashedImage struct {
+	ID     int64
+	ItemID int64
+	UserID int64
+	PHash  uint64 `gorm:"column:phash"`
+}
+
+func main() {
+	all := flag.Bool("all", false, "recompute photos that already have a perceptual hash")
+	match := flag.Bool("match", true, "record near-duplicates across users for /admin/duplicates")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate adds phash and core_image_match)
+	config.ConnectDB()
+	config.InitImages()
+	config.InitStorage()
+	storage.Init()
+
+	hashPhotos(context.Background(), *all)
+	if *match {
+		matchPhotos()
+	}
+}
+
+// hashPhotos computes the perceptual hash of photos stored before it existed
+func hashPhotos(ctx context.Context, all bool) {
+	log.Println("🔄 Fingerprinting stored photos...")
+
+	query := config.DB.Model(&models.LostItemImage{})
+	if !all {
+		query = query.Where("phash = 0")
+	}
+	var ids []int64
+	if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+		log.Fatalf("❌ Failed to list photos: %v", err)
+	}
+
+	hashed, featureless, failed := 0, 0, 0
+	for _, id := range ids {
+		// One row at a time, legacy rows carry their bytes
+		var image models.LostItemImage
+		if err := config.DB.Select("id", "item_id", "blob_key", "image_data").Take(&image, id).Error; err != nil {
+			log.Printf("⚠️  Failed to read photo %d: %v", id, err)
+			failed++
+			continue
+		}
+		data := image.ImageData
+		if image.BlobKey != "" {
+			obj, err := storage.Default.Get(ctx, image.BlobKey+"/full.jpg")
+			if err != nil {
+				log.Printf("⚠️  Failed to load photo %d: %v", image.ID, err)
+				failed++
+				continue
+			}
+			data = obj.Data
+		}
+
+		hash, err := utils.PerceptualHashData(data)
+		if err != nil {
+			log.Printf("⚠️  Photo %d of item %d: %v", image.ID, image.ItemID, err)
+			failed++
+			continue
+		}
+		if hash == 0 {
+			// Too featureless to compare, left at 0
+			featureless++
+			continue
+		}
+		if err := config.DB.Model(&image).Update("phash", hash).Error; err != nil {
+			log.Printf("⚠️  Failed to update photo %d: %v", image.ID, err)
+			failed++
+			continue
+		}
+		hashed++
+	}
+
+	fmt.Printf("✨ Fingerprinted %d of %d photos (%d featureless, %d failed)\n", hashed, len(ids), featureless, failed)
+}
+
+// matchPhotos records every cross-user pair within DUPLICATE_PHOTO_BITS. The
+// newer photo of a pair is the suspect, as on upload. No reports are opened:
+// old posts show up on the duplicates page instead.
+func matchPhotos() {
+	log.Println("🔄 Comparing fingerprints...")
+
+	var images []hashedImage
+	if err := config.DB.Table("core_lostitem_photo AS p").
+		Select("p.id, p.item_id, i.user_id, p.phash").
+		Joins("JOIN core_lostitem AS i ON i.id = p.item_id").
+		Where("p.phash <> 0").
+		Order("p.id").
+		Scan(&images).Error; err != nil {
+		log.Fatalf("❌ Failed to list fingerprints: %v", err)
+	}
+
+	recorded := 0
+	for i, newer := range images {
+		for _, older := range images[:i] {
+			if older.UserID == newer.UserID {
+				continue
+			}
+			distance := utils.HammingDistance(newer.PHash, older.PHash)
+			if distance > config.DuplicatePhotoBits {
+				continue
+			}
+
+			var exists int64
+			config.DB.Model(&models.ImageMatch{}).
+				Where("image_id = ? AND match_image_id = ?", newer.ID, older.ID).
+				Count(&exists)
+			if exists > 0 {
+				continue
+			}
+			if err := config.DB.Create(&models.ImageMatch{
+				ImageID:      newer.ID,
+				MatchImageID: older.ID,
+				ItemID:       newer.ItemID,
+				MatchItemID:  older.ItemID,
+				Distance:     distance,
+			}).Error; err != nil {
+				log.Printf("⚠️  Failed to record photos %d and %d: %v", newer.ID, older.ID, err)
+				continue
+			}
+			recorded++
+		}
+	}
+
+	fmt.Printf("✨ Recorded %d new matches among %d photos\n", recorded, len(images))
+}
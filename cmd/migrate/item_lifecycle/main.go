@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate adds the state column and history table)
+	config.ConnectDB()
+
+	log.Println("🔄 Backfilling item lifecycle states...")
+
+	// FOUND was the only terminal status before the lifecycle existed
+	returned := config.DB.Model(&models.LostItem{}).Where("status = ?", "FOUND").
+		Updates(map[string]interface{}{"state": utils.ItemReturned, "status": utils.ItemStatus(utils.ItemReturned)})
+	if returned.Error != nil {
+		log.Fatalf("❌ Failed to backfill returned items: %v", returned.Error)
+	}
+
+	acceptedMeetups := config.DB.Model(&models.Meetup{}).Select("item_id").Where("status = ?", "accepted")
+	pending := config.DB.Model(&models.LostItem{}).
+		Where("status = ? AND finder_id IS NOT NULL AND id IN (?)", "LOST", acceptedMeetups).
+		Update("state", utils.ItemHandoverPending)
+	if pending.Error != nil {
+		log.Fatalf("❌ Failed to backfill handover states: %v", pending.Error)
+	}
+
+	selected := config.DB.Model(&models.LostItem{}).
+		Where("status = ? AND finder_id IS NOT NULL AND state = ?", "LOST", utils.ItemOpen).
+		Update("state", utils.ItemFinderSelected)
+	if selected.Error != nil {
+		log.Fatalf("❌ Failed to backfill finder states: %v", selected.Error)
+	}
+
+	searches := config.DB.Model(&models.SavedSearch{}).Where("status = ?", "FOUND").Update("status", "RETURNED")
+	if searches.Error != nil {
+		log.Fatalf("❌ Failed to update saved searches: %v", searches.Error)
+	}
+
+	fmt.Printf("✨ Migration completed! %d returned, %d awaiting handover, %d with a finder selected, %d saved searches updated.\n",
+		returned.RowsAffected, pending.RowsAffected, selected.RowsAffected, searches.RowsAffected)
+}
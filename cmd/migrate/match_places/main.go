@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only print the matches")
+	minScore := flag.Float64("min-score", 0.75, "share of a place's words that must appear in the location (0-1)")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate adds core_place and place_id)
+	config.ConnectDB()
+
+	log.Println("🔄 Matching free-text locations to places...")
+
+	var items []models.LostItem
+	if err := config.DB.Where("place_id IS NULL AND location IS NOT NULL AND location <> ''").Find(&items).Error; err != nil {
+		log.Fatalf("❌ Failed to fetch items: %v", err)
+	}
+
+	matcher, err := utils.NewPlaceMatcher(config.DB)
+	if err != nil {
+		log.Fatalf("❌ Failed to load places: %v", err)
+	}
+
+	matched := 0
+	for _, item := range items {
+		place, score := matcher.Match(item.Location, *minScore)
+		if place == nil {
+			log.Printf("   item %d: %q — no match (best %.2f)", item.ID, item.Location, score)
+			continue
+		}
+
+		log.Printf("   item %d: %q → %s (%.2f)", item.ID, item.Location, place.FullName, score)
+		if !*dryRun {
+			if err := config.DB.Model(&item).Update("place_id", place.ID).Error; err != nil {
+				log.Printf("⚠️  Failed to update item %d: %v", item.ID, err)
+				continue
+			}
+		}
+		matched++
+	}
+
+	if *dryRun {
+		fmt.Printf("✨ Dry run: %d of %d posts would be matched.\n", matched, len(items))
+		return
+	}
+	fmt.Printf("✨ Migration completed! Matched %d of %d posts.\n", matched, len(items))
+}
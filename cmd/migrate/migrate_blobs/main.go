@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/storage"
+
+	"github.com/joho/godotenv"
+)
+
+// legacyProfilesDir is where avatars were written before blob storage
+const legacyProfilesDir = "static/images/profiles"
+
+func main() {
+	from := flag.String("from", "", "source: db, fs, s3, or legacy for bytes still in core_lostitem_photo and core_reportevidence and avatars in "+legacyProfilesDir)
+	to := flag.String("to", "", "destination: db, fs or s3")
+	deleteSource := flag.Bool("delete-source", false, "remove each blob from the source once copied")
+	flag.Parse()
+
+	if *from == "" || *to == "" || *from == *to {
+		log.Fatal("❌ Usage: migrate_blobs -from <db|fs|s3|legacy> -to <db|fs|s3> [-delete-source]")
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate adds core_blob and blob_key)
+	config.ConnectDB()
+	config.InitStorage()
+
+	dst, err := storage.New(*to)
+	if err != nil {
+		log.Fatalf("❌ Failed to open %s storage: %v", *to, err)
+	}
+
+	ctx := context.Background()
+	if *from == "legacy" {
+		migrateLegacyPhotos(ctx, dst, *deleteSource)
+		migrateLegacyEvidence(ctx, dst, *deleteSource)
+		migrateLegacyAvatars(ctx, dst, *deleteSource)
+		return
+	}
+
+	src, err := storage.New(*from)
+	if err != nil {
+		log.Fatalf("❌ Failed to open %s storage: %v", *from, err)
+	}
+	copyBlobs(ctx, src, dst, *deleteSource)
+	fmt.Printf("   Set STORAGE_BACKEND=%s before restarting the app.\n", *to)
+}
+
+// copyBlobs copies every blob the database points at from src to dst
+func copyBlobs(ctx context.Context, src, dst storage.Store, deleteSource bool) {
+	log.Println("🔄 Copying blobs...")
+
+	var keys []string
+	var photoKeys []string
+	if err := config.DB.Model(&models.LostItemImage{}).Where("blob_key <> ''").Pluck("blob_key", &photoKeys).Error; err != nil {
+		log.Fatalf("❌ Failed to list photos: %v", err)
+	}
+	for _, base := range photoKeys {
+		for _, size := range []string{"full", "detail", "thumb"} {
+			keys = append(keys, base+"/"+size+".jpg")
+		}
+	}
+	var avatarKeys []string
+	if err := config.DB.Model(&models.User{}).Where("profile_picture LIKE ?", "avatars/%").Pluck("profile_picture", &avatarKeys).Error; err != nil {
+		log.Fatalf("❌ Failed to list avatars: %v", err)
+	}
+	keys = append(keys, avatarKeys...)
+	var evidenceKeys []string
+	if err := config.DB.Model(&models.ReportEvidence{}).Where("blob_key <> ''").Pluck("blob_key", &evidenceKeys).Error; err != nil {
+		log.Fatalf("❌ Failed to list report evidence: %v", err)
+	}
+	keys = append(keys, evidenceKeys...)
+
+	copied, missing, failed := 0, 0, 0
+	for _, key := range keys {
+		obj, err := src.Get(ctx, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			missing++
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️  Failed to read %s: %v", key, err)
+			failed++
+			continue
+		}
+		if err := dst.Put(ctx, key, obj.Data, obj.ContentType); err != nil {
+			log.Printf("⚠️  Failed to write %s: %v", key, err)
+			failed++
+			continue
+		}
+		if deleteSource {
+			if err := src.Delete(ctx, key); err != nil {
+				log.Printf("⚠️  Copied %s but failed to remove it from the source: %v", key, err)
+			}
+		}
+		copied++
+	}
+
+	fmt.Printf("✨ Copied %d blobs (%d missing in the source, %d failed)\n", copied, missing, failed)
+}
+
+// migrateLegacyPhotos moves photo bytes out of core_lostitem_photo rows.
+// Run process_item_images first so the rows have thumbnails.
+func migrateLegacyPhotos(ctx context.Context, dst storage.Store, clearRows bool) {
+	log.Println("🔄 Moving photos out of the database rows...")
+
+	var ids []int64
+	if err := config.DB.Model(&models.LostItemImage{}).
+		Where("blob_key = '' AND image_data IS NOT NULL").
+		Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		log.Fatalf("❌ Failed to list photos: %v", err)
+	}
+
+	moved, failed := 0, 0
+	for _, id := range ids {
+		// One row at a time, the blobs can be large
+		var image models.LostItemImage
+		if err := config.DB.Take(&image, id).Error; err != nil {
+			log.Printf("⚠️  Failed to read photo %d: %v", id, err)
+			failed++
+			continue
+		}
+
+		base := storage.NewKey(fmt.Sprintf("items/%d", image.ItemID))
+		sizes := map[string][]byte{"full": image.ImageData, "detail": image.DetailData, "thumb": image.ThumbData}
+		ok := true
+		for size, data := range sizes {
+			if len(data) == 0 {
+				// Served from the full photo instead
+				continue
+			}
+			if err := dst.Put(ctx, base+"/"+size+".jpg", data, image.ContentType); err != nil {
+				log.Printf("⚠️  Failed to store photo %d (%s): %v", image.ID, size, err)
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			failed++
+			continue
+		}
+
+		updates := map[string]interface{}{"blob_key": base}
+		if clearRows {
+			updates["image_data"] = nil
+			updates["detail_data"] = nil
+			updates["thumb_data"] = nil
+		}
+		if err := config.DB.Model(&image).Updates(updates).Error; err != nil {
+			log.Printf("⚠️  Failed to update photo %d: %v", image.ID, err)
+			failed++
+			continue
+		}
+		moved++
+	}
+
+	fmt.Printf("✨ Moved %d of %d photos (%d failed)\n", moved, len(ids), failed)
+}
+
+// migrateLegacyEvidence moves report evidence bytes out of
+// core_reportevidence rows
+func migrateLegacyEvidence(ctx context.Context, dst storage.Store, clearRows bool) {
+	log.Println("🔄 Moving report evidence out of the database rows...")
+
+	var ids []int64
+	if err := config.DB.Model(&models.ReportEvidence{}).
+		Where("blob_key = '' AND image_data IS NOT NULL").
+		Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		log.Fatalf("❌ Failed to list report evidence: %v", err)
+	}
+
+	moved, failed := 0, 0
+	for _, id := range ids {
+		var evidence models.ReportEvidence
+		if err := config.DB.Take(&evidence, id).Error; err != nil {
+			log.Printf("⚠️  Failed to read evidence %d: %v", id, err)
+			failed++
+			continue
+		}
+		var report models.ItemReport
+		if err := config.DB.Select("id", "item_id").Take(&report, evidence.ReportID).Error; err != nil {
+			log.Printf("⚠️  Failed to read report of evidence %d: %v", id, err)
+			failed++
+			continue
+		}
+
+		key := storage.NewKey(fmt.Sprintf("evidence/%d", report.ItemID))
+		if err := dst.Put(ctx, key, evidence.ImageData, evidence.ContentType); err != nil {
+			log.Printf("⚠️  Failed to store evidence %d: %v", evidence.ID, err)
+			failed++
+			continue
+		}
+
+		updates := map[string]interface{}{"blob_key": key}
+		if clearRows {
+			updates["image_data"] = nil
+		}
+		if err := config.DB.Model(&evidence).Updates(updates).Error; err != nil {
+			log.Printf("⚠️  Failed to update evidence %d: %v", evidence.ID, err)
+			failed++
+			continue
+		}
+		moved++
+	}
+
+	fmt.Printf("✨ Moved %d of %d evidence images (%d failed)\n", moved, len(ids), failed)
+}
+
+// migrateLegacyAvatars moves avatars from local disk into storage
+func migrateLegacyAvatars(ctx context.Context, dst storage.Store, deleteFiles bool) {
+	log.Println("🔄 Moving avatars off local disk...")
+
+	var users []models.User
+	if err := config.DB.Where("profile_picture IS NOT NULL AND profile_picture <> '' AND profile_picture NOT LIKE ?", "%/%").
+		Find(&users).Error; err != nil {
+		log.Fatalf("❌ Failed to list users: %v", err)
+	}
+
+	moved, failed := 0, 0
+	for _, user := range users {
+		path := filepath.Join(legacyProfilesDir, filepath.Base(user.ProfilePicture))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("⚠️  Avatar of user %d: %v", user.ID, err)
+			failed++
+			continue
+		}
+
+		key := fmt.Sprintf("avatars/%d/%s", user.ID, strings.ReplaceAll(user.ProfilePicture, "/", "_"))
+		if err := dst.Put(ctx, key, data, http.DetectContentType(data)); err != nil {
+			log.Printf("⚠️  Failed to store avatar of user %d: %v", user.ID, err)
+			failed++
+			continue
+		}
+		if err := config.DB.Model(&user).Update("profile_picture", key).Error; err != nil {
+			log.Printf("⚠️  Failed to update user %d: %v", user.ID, err)
+			failed++
+			continue
+		}
+		if deleteFiles {
+			os.Remove(path)
+		}
+		moved++
+	}
+
+	fmt.Printf("✨ Moved %d of %d avatars (%d failed)\n", moved, len(users), failed)
+}
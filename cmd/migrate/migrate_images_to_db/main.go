@@ -91,7 +91,7 @@ func main() {
 
 		// Check if it already exists in DB
 		var existing models.LostItemImage
-		if err := config.DB.First(&existing, item.ID).Error; err == nil {
+		if err := config.DB.Where("item_id = ?", item.ID).Take(&existing).Error; err == nil {
 			// Already exists
 			// Skip or update? Skip strictly for now to avoid overwriting
 			// fmt.Printf("Image for item %d already in DB, skipping.\n", item.ID)
@@ -102,6 +102,8 @@ func main() {
 		// Save to DB
 		imgEntry := models.LostItemImage{
 			ItemID:      item.ID,
+			Position:    1,
+			IsPrimary:   true,
 			ImageData:   data,
 			ContentType: contentType,
 		}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	all := flag.Bool("all", false, "reprocess photos that already have thumbnails")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate adds the thumbnail columns)
+	config.ConnectDB()
+	config.InitImages()
+
+	log.Println("🔄 Re-encoding stored photos and generating thumbnails...")
+
+	// Photos in blob storage were processed on upload
+	query := config.DB.Model(&models.LostItemImage{}).Where("blob_key = ''")
+	if !*all {
+		query = query.Where("thumb_data IS NULL OR LENGTH(thumb_data) = 0")
+	}
+	var ids []int64
+	if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+		log.Fatalf("❌ Failed to list photos: %v", err)
+	}
+
+	processed, failed := 0, 0
+	for _, id := range ids {
+		// One row at a time, the blobs can be large
+		var image models.LostItemImage
+		if err := config.DB.Select("id", "item_id", "is_primary", "image_data").Take(&image, id).Error; err != nil {
+			log.Printf("⚠️  Failed to read photo %d: %v", id, err)
+			failed++
+			continue
+		}
+
+		result, err := utils.ProcessImage(image.ImageData, config.ItemImageMaxDimension)
+		if err != nil {
+			// Kept as is; it is still served at full size
+			log.Printf("⚠️  Photo %d of item %d: %v", image.ID, image.ItemID, err)
+			failed++
+			continue
+		}
+
+		if err := config.DB.Model(&image).Updates(map[string]interface{}{
+			"image_data":   result.Data,
+			"detail_data":  result.Detail,
+			"thumb_data":   result.Thumb,
+			"content_type": result.ContentType,
+			"content_hash": result.Hash,
+			"phash":        result.PHash,
+			"width":        result.Width,
+			"height":       result.Height,
+		}).Error; err != nil {
+			log.Printf("⚠️  Failed to update photo %d: %v", image.ID, err)
+			failed++
+			continue
+		}
+		// The post's Image field versions its primary photo's URL
+		if image.IsPrimary {
+			config.DB.Model(&models.LostItem{}).Where("id = ?", image.ItemID).Update("image", result.Hash)
+		}
+		processed++
+	}
+
+	fmt.Printf("✨ Processed %d of %d photos (%d failed)\n", processed, len(ids), failed)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+
+	"github.com/joho/godotenv"
+)
+
+// legacyTable held one photo per post, keyed by item_id
+const legacyTable = "core_lostitem_image"
+
+type legacyImage struct {
+	ItemID      int64
+	ImageData   []byte
+	ContentType string
+}
+
+func main() {
+	drop := flag.Bool("drop", false, "drop the old table once every photo is copied")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	// Connect to database (AutoMigrate creates core_lostitem_photo)
+	config.ConnectDB()
+
+	if !config.DB.Migrator().HasTable(legacyTable) {
+		log.Println("✨ No old image table, nothing to migrate")
+		return
+	}
+
+	log.Println("🔄 Copying single photos into the gallery table...")
+
+	var itemIDs []int64
+	if err := config.DB.Table(legacyTable).Order("item_id").Pluck("item_id", &itemIDs).Error; err != nil {
+		log.Fatalf("❌ Failed to list old images: %v", err)
+	}
+
+	copied, skipped, failed := 0, 0, 0
+	for _, itemID := range itemIDs {
+		// Posts that already have gallery photos were migrated or edited since
+		var existing int64
+		config.DB.Model(&models.LostItemImage{}).Where("item_id = ?", itemID).Count(&existing)
+		if existing > 0 {
+			skipped++
+			continue
+		}
+
+		// One row at a time, the blobs can be large
+		var old legacyImage
+		if err := config.DB.Table(legacyTable).Where("item_id = ?", itemID).Take(&old).Error; err != nil {
+			log.Printf("⚠️  Failed to read image of item %d: %v", itemID, err)
+			failed++
+			continue
+		}
+
+		image := models.LostItemImage{
+			ItemID:      old.ItemID,
+			Position:    1,
+			IsPrimary:   true,
+			ImageData:   old.ImageData,
+			ContentType: old.ContentType,
+		}
+		if err := config.DB.Create(&image).Error; err != nil {
+			log.Printf("⚠️  Failed to copy image of item %d: %v", itemID, err)
+			failed++
+			continue
+		}
+		copied++
+	}
+
+	if *drop {
+		if failed > 0 {
+			log.Printf("⚠️  Keeping %s, %d images failed to copy", legacyTable, failed)
+		} else if err := config.DB.Migrator().DropTable(legacyTable); err != nil {
+			log.Printf("⚠️  Failed to drop %s: %v", legacyTable, err)
+		} else {
+			log.Printf("   dropped %s", legacyTable)
+		}
+	}
+
+	fmt.Printf("✨ Copied %d images (%d skipped, %d failed)\n", copied, skipped, failed)
+}
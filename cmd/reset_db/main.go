@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"temuin/config"
 	"temuin/models"
+	"temuin/utils"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -21,12 +22,38 @@ func main() {
 
 	// Drop all tables
 	// Order matters for Foreign Keys
+	dropTable(db, &models.DirectMessage{})
+	dropTable(db, &models.Conversation{})
+	dropTable(db, &models.MeetupNoShow{})
+	dropTable(db, &models.MeetupSlot{})
+	dropTable(db, &models.Meetup{})
+	dropTable(db, &models.HandoverAttempt{})
+	dropTable(db, &models.HandoverCode{})
 	dropTable(db, &models.ItemClaim{})
 	dropTable(db, &models.CoinTransaction{})
 	dropTable(db, &models.Comment{})
+	dropTable(db, &models.ReportEvidence{})
 	dropTable(db, &models.ItemReport{})
+	dropTable(db, &models.ModerationRule{})
 	dropTable(db, &models.Notification{})
+	dropTable(db, &models.SearchTerm{})
+	dropTable(db, &models.SavedSearch{})
+	dropTable(db, &models.FoundReport{})
+	dropTable(db, &models.TagMessage{})
+	dropTable(db, &models.BelongingTag{})
+	dropTable(db, &models.IdentifierConflict{})
+	dropTable(db, &models.Identifier{})
+	dropTable(db, &models.NotificationPreference{})
+	dropTable(db, &models.EmailDelivery{})
+	dropTable(db, &models.WebhookDelivery{})
+	dropTable(db, &models.WebhookSubscription{})
+	dropTable(db, &models.ItemHistory{})
 	dropTable(db, &models.LostItem{})
+	dropTable(db, &models.ClaimAttribute{})
+	dropTable(db, &models.ItemAttribute{})
+	dropTable(db, &models.AttributeField{})
+	dropTable(db, &models.PlaceAlias{})
+	dropTable(db, &models.Place{})
 	dropTable(db, &models.SubCategory{})
 	dropTable(db, &models.Category{})
 	dropTable(db, &models.User{})
@@ -34,6 +61,11 @@ func main() {
 	dropTable(db, &models.TopUpTransaction{})
 	dropTable(db, &models.WithdrawalRequest{})
 	dropTable(db, &models.LostItemImage{}) // Drop image table
+	dropTable(db, "core_lostitem_image")   // single-photo table before galleries
+	dropTable(db, &models.ImageMatch{})
+	dropTable(db, &models.Blob{})
+	dropTable(db, &models.BanAppeal{})
+	dropTable(db, &models.ModerationAction{})
 
 	log.Println("✅ All tables dropped.")
 
@@ -43,15 +75,45 @@ func main() {
 		&models.User{},
 		&models.Category{},
 		&models.SubCategory{},
+		&models.AttributeField{},
+		&models.Place{},
+		&models.PlaceAlias{},
 		&models.LostItem{},
+		&models.ItemHistory{},
 		&models.LostItemImage{}, // Migrate image table
+		&models.ImageMatch{},
+		&models.Blob{},
 		&models.Comment{},
 		&models.CoinTransaction{},
 		&models.ItemClaim{},
+		&models.HandoverCode{},
+		&models.HandoverAttempt{},
+		&models.Meetup{},
+		&models.MeetupSlot{},
+		&models.MeetupNoShow{},
+		&models.ItemAttribute{},
+		&models.ClaimAttribute{},
 		&models.ItemReport{},
+		&models.ReportEvidence{},
+		&models.ModerationRule{},
+		&models.Conversation{},
+		&models.DirectMessage{},
 		&models.Notification{},
+		&models.SearchTerm{},
+		&models.SavedSearch{},
+		&models.Identifier{},
+		&models.IdentifierConflict{},
+		&models.FoundReport{},
+		&models.BelongingTag{},
+		&models.TagMessage{},
+		&models.NotificationPreference{},
+		&models.EmailDelivery{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
 		&models.TopUpTransaction{},
 		&models.WithdrawalRequest{},
+		&models.ModerationAction{},
+		&models.BanAppeal{},
 	)
 	if err != nil {
 		log.Fatalf("❌ Migration failed: %v", err)
@@ -112,9 +174,9 @@ func seedCategories(db *gorm.DB) {
 
 			// 3. Seed Items (5 per subcategory)
 			for i := 1; i <= 5; i++ {
-				// Status only LOST or FOUND. FOUND means completed.
-				statuses := []string{"LOST", "LOST", "LOST", "LOST", "FOUND"}
-				status := statuses[rand.Intn(len(statuses))]
+				// Mostly open posts, some returned (completed)
+				states := []string{utils.ItemOpen, utils.ItemOpen, utils.ItemOpen, utils.ItemOpen, utils.ItemReturned}
+				state := states[rand.Intn(len(states))]
 				location := locations[rand.Intn(len(locations))]
 
 				// Add some detail to location to match filter query logic test
@@ -125,21 +187,23 @@ func seedCategories(db *gorm.DB) {
 					Description:   fmt.Sprintf("Barang %s di sekitar %s. Mohon info jika menemukan.", subName, location),
 					Location:      location,
 					BountyCoins:   (rand.Intn(10) + 1) * 10,
-					Status:        status,
+					Status:        utils.ItemStatus(state),
+					State:         state,
 					UserID:        user.ID,
 					CategoryID:    cat.ID,
 					SubCategoryID: &sub.ID,
 					Image:         "",
 				}
 
-				// Logic for FOUND (Completed)
-				if status == "FOUND" {
+				// Logic for returned (Completed)
+				if state == utils.ItemReturned {
 					item.FinderID = &user.ID
 					item.FinderConfirmed = true
 					item.OwnerConfirmed = true
 				} else {
 					// Randomly assign a "Finder" who found it but not yet confirmed by owner (Still LOST status)
 					if rand.Intn(4) == 0 {
+						item.State = utils.ItemFinderSelected
 						item.FinderID = &user.ID
 						item.FinderConfirmed = true
 					}
@@ -38,16 +38,46 @@ func ConnectDB() {
 		&models.User{},
 		&models.Category{},
 		&models.SubCategory{},
+		&models.AttributeField{},
+		&models.Place{},
+		&models.PlaceAlias{},
 		&models.LostItem{},
+		&models.ItemHistory{},
 		&models.LostItemImage{},
+		&models.ImageMatch{},
+		&models.Blob{},
 		&models.Comment{},
 		&models.CoinTransaction{},
 		&models.ItemClaim{},
+		&models.HandoverCode{},
+		&models.HandoverAttempt{},
+		&models.Meetup{},
+		&models.MeetupSlot{},
+		&models.MeetupNoShow{},
+		&models.ItemAttribute{},
+		&models.ClaimAttribute{},
 		&models.ItemReport{},
+		&models.ReportEvidence{},
+		&models.ModerationRule{},
+		&models.Conversation{},
+		&models.DirectMessage{},
 		&models.Notification{},
+		&models.SearchTerm{},
+		&models.SavedSearch{},
+		&models.Identifier{},
+		&models.IdentifierConflict{},
+		&models.FoundReport{},
+		&models.BelongingTag{},
+		&models.TagMessage{},
+		&models.NotificationPreference{},
+		&models.EmailDelivery{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
 		&models.TopUpTransaction{},
 		&models.WithdrawalRequest{},
 		&models.SiteVisit{},
+		&models.ModerationAction{},
+		&models.BanAppeal{},
 	)
 
 	if err != nil {
@@ -0,0 +1,17 @@
+package config
+
+var (
+	HandoverMaxFailures   int // wrong entries before a handover code is revoked and the owner gets a new one
+	MeetupDurationMinutes int // length of an agreed meetup in calendar exports
+	MeetupReminderMinutes int // how long before an agreed meetup both sides are reminded
+	MeetupPollSeconds     int // how often the reminder worker looks for upcoming meetups
+	NoShowWarning         int // no-show reports after which a user's reputation is flagged
+)
+
+func InitHandover() {
+	HandoverMaxFailures = envInt("HANDOVER_MAX_FAILURES", 5)
+	MeetupDurationMinutes = envInt("MEETUP_DURATION_MINUTES", 30)
+	MeetupReminderMinutes = envInt("MEETUP_REMINDER_MINUTES", 120)
+	MeetupPollSeconds = envInt("MEETUP_POLL_SECONDS", 60)
+	NoShowWarning = envInt("NO_SHOW_WARNING", 2)
+}
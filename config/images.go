@@ -0,0 +1,17 @@
+package config
+
+var (
+	ItemMaxImages         int // photos allowed on one post
+	ItemImageMaxSizeMB    int // size limit per uploaded photo
+	ItemImageMaxDimension int // long edge of a stored photo, in pixels
+	ImageCacheMB          int // memory kept for recently served photos and avatars
+	DuplicatePhotoBits    int // perceptual hash bits two photos may differ in and still count as the same picture
+)
+
+func InitImages() {
+	ItemMaxImages = envInt("ITEM_MAX_IMAGES", 5)
+	ItemImageMaxSizeMB = envInt("ITEM_IMAGE_MAX_SIZE_MB", 10)
+	ItemImageMaxDimension = envInt("ITEM_IMAGE_MAX_DIMENSION", 1600)
+	ImageCacheMB = envInt("IMAGE_CACHE_MB", 64)
+	DuplicatePhotoBits = envInt("DUPLICATE_PHOTO_BITS", 6)
+}
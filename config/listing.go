@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+var (
+	ListingPerPage int // items per page on browse pages
+	ItemExpiryDays int // open posts expire this many days after they are created
+)
+
+func InitListing() {
+	ListingPerPage = envInt("LISTING_PER_PAGE", 24)
+	ItemExpiryDays = envInt("ITEM_EXPIRY_DAYS", 90)
+}
+
+// ItemExpiry is how long a post stays open before it expires
+func ItemExpiry() time.Duration {
+	return time.Duration(ItemExpiryDays) * 24 * time.Hour
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	MailDriver      string // "smtp" sends for real, "log" writes to MailSinkDir for development
+	MailFrom        string
+	SMTPHost        string
+	SMTPPort        string
+	SMTPUsername    string
+	SMTPPassword    string
+	MailSinkDir     string
+	MailMaxAttempts int // sends before a delivery is marked failed
+	MailPollSeconds int // how often the worker looks for queued emails
+	DigestHour      int // local hour at which daily digests go out
+	AppBaseURL      string
+)
+
+func InitMail() {
+	MailDriver = strings.ToLower(os.Getenv("MAIL_DRIVER"))
+	if MailDriver == "" {
+		MailDriver = "log"
+	}
+	MailFrom = envString("MAIL_FROM", "TemuIN <no-reply@temuin.local>")
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = envString("SMTP_PORT", "587")
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	MailSinkDir = envString("MAIL_SINK_DIR", "tmp/mail")
+	MailMaxAttempts = envInt("MAIL_MAX_ATTEMPTS", 5)
+	MailPollSeconds = envInt("MAIL_POLL_SECONDS", 15)
+	DigestHour = envHour("MAIL_DIGEST_HOUR", 7)
+	AppBaseURL = strings.TrimRight(envString("APP_BASE_URL", "http://localhost:8080"), "/")
+
+	if MailDriver == "smtp" && SMTPHost == "" {
+		log.Println("Warning: MAIL_DRIVER=smtp but SMTP_HOST is not set. Falling back to the log mailer.")
+		MailDriver = "log"
+	}
+}
+
+// envString reads a string from the environment, falling back to def
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envHour reads an hour of the day (0-23) from the environment, falling back
+// to def. Unlike envInt it accepts 0, which is midnight.
+func envHour(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > 23 {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, v, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	StrikeSuspendThreshold int // strikes needed before an automatic suspension
+	StrikeBanThreshold     int // strikes needed before an automatic permanent ban
+	SuspensionDays         int // length of an automatic suspension
+	ReportSLAHours         int // time allowed to handle a report
+	ReportUrgentSLAHours   int // time allowed to handle a fraud report
+	EvidenceMaxFiles       int // images a reporter may attach to one report
+	EvidenceMaxSizeMB      int // size limit per evidence image
+)
+
+func InitModeration() {
+	StrikeSuspendThreshold = envInt("STRIKE_SUSPEND_THRESHOLD", 3)
+	StrikeBanThreshold = envInt("STRIKE_BAN_THRESHOLD", 5)
+	SuspensionDays = envInt("SUSPENSION_DAYS", 7)
+	ReportSLAHours = envInt("REPORT_SLA_HOURS", 24)
+	ReportUrgentSLAHours = envInt("REPORT_URGENT_SLA_HOURS", 6)
+	EvidenceMaxFiles = envInt("EVIDENCE_MAX_FILES", 3)
+	EvidenceMaxSizeMB = envInt("EVIDENCE_MAX_SIZE_MB", 5)
+
+	if StrikeBanThreshold <= StrikeSuspendThreshold {
+		log.Println("Warning: STRIKE_BAN_THRESHOLD should be greater than STRIKE_SUSPEND_THRESHOLD")
+	}
+}
+
+// ReportSLA returns how long moderators have to handle a report with the given reason
+func ReportSLA(reason string) time.Duration {
+	if reason == "fraud" {
+		return time.Duration(ReportUrgentSLAHours) * time.Hour
+	}
+	return time.Duration(ReportSLAHours) * time.Hour
+}
+
+// envInt reads a positive integer from the environment, falling back to def
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, v, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,29 @@
+package config
+
+import "log"
+
+const devIdentifierPepper = "temuin-dev-identifier-pepper"
+
+var (
+	IdentifierPepper      string // HMAC key for registered serial numbers and IMEIs; changing it orphans every stored hash
+	FoundReportsPerDay    int    // found-item reports one user may file per day, so the registry cannot be probed
+	MaxIdentifiersPerUser int    // identifiers one user may register
+	ConflictsPerDay       int    // conflicting registrations per user and day that notify the admins
+	MaxTagsPerUser        int    // QR belonging tags one user may create
+	TagMessagesPerHour    int    // anonymous messages one sender may leave on tags per hour
+	TagMessagesPerDay     int    // anonymous messages one tag accepts per day
+)
+
+func InitRegistry() {
+	IdentifierPepper = envString("IDENTIFIER_PEPPER", devIdentifierPepper)
+	FoundReportsPerDay = envInt("FOUND_REPORTS_PER_DAY", 10)
+	MaxIdentifiersPerUser = envInt("MAX_IDENTIFIERS_PER_USER", 50)
+	ConflictsPerDay = envInt("REGISTRY_CONFLICTS_PER_DAY", 3)
+	MaxTagsPerUser = envInt("MAX_TAGS_PER_USER", 50)
+	TagMessagesPerHour = envInt("TAG_MESSAGES_PER_HOUR", 5)
+	TagMessagesPerDay = envInt("TAG_MESSAGES_PER_DAY", 20)
+
+	if IdentifierPepper == devIdentifierPepper {
+		log.Println("Warning: IDENTIFIER_PEPPER is not set. Registered identifiers are hashed with a development key.")
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+import "strings"
+
+// TrustedProxies are the reverse proxies allowed to set X-Forwarded-For.
+// Without any, the client IP is the remote address, so rate limits keyed
+// on it cannot be dodged by sending the header.
+var TrustedProxies []string
+
+func InitServer() {
+	for _, proxy := range strings.Split(envString("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			TrustedProxies = append(TrustedProxies, proxy)
+		}
+	}
+}
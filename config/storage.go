@@ -0,0 +1,34 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+var (
+	StorageBackend string // "db" keeps blobs in MySQL, "fs" under StorageDir, "s3" in an S3-compatible bucket
+	StorageDir     string
+	S3Endpoint     string // host[:port], e.g. s3.amazonaws.com or localhost:9000 for MinIO
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UseSSL       bool
+)
+
+func InitStorage() {
+	StorageBackend = strings.ToLower(envString("STORAGE_BACKEND", "db"))
+	StorageDir = envString("STORAGE_DIR", "data/blobs")
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	S3Region = envString("S3_REGION", "us-east-1")
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+	S3SecretKey = os.Getenv("S3_SECRET_KEY")
+	S3UseSSL = envString("S3_USE_SSL", "true") != "false"
+
+	if StorageBackend == "s3" && (S3Endpoint == "" || S3Bucket == "") {
+		log.Println("Warning: STORAGE_BACKEND=s3 but S3_ENDPOINT or S3_BUCKET is not set. Falling back to database storage.")
+		StorageBackend = "db"
+	}
+}
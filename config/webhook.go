@@ -0,0 +1,13 @@
+package config
+
+var (
+	WebhookMaxAttempts    int // deliveries are marked failed after this many attempts
+	WebhookTimeoutSeconds int // per-request timeout when calling a subscriber
+	WebhookPollSeconds    int // how often the worker looks for due deliveries
+)
+
+func InitWebhooks() {
+	WebhookMaxAttempts = envInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	WebhookTimeoutSeconds = envInt("WEBHOOK_TIMEOUT_SECONDS", 10)
+	WebhookPollSeconds = envInt("WEBHOOK_POLL_SECONDS", 10)
+}
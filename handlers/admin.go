@@ -10,11 +10,13 @@ import (
 
 	"github.com/flosch/pongo2/v6"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // AdminDeleteItem allows admin to delete any post
 func AdminDeleteItem(c *gin.Context) {
 	itemID := c.Param("pk")
+	admin := c.MustGet("user").(*models.User)
 
 	var item models.LostItem
 	if err := config.DB.First(&item, itemID).Error; err != nil {
@@ -25,81 +27,71 @@ func AdminDeleteItem(c *gin.Context) {
 	// Admin can delete any post - no ownership check needed
 	// Use transaction to ensure full cleanup (Manual Cascade)
 	tx := config.DB.Begin()
+	blobs := newBlobWrites(c)
 
-	// REFUND LOGIC: If item has bounty, refund to owner
-	if item.BountyCoins > 0 {
-		var owner models.User
-		if err := tx.First(&owner, item.UserID).Error; err == nil {
-			owner.CoinBalance += item.BountyCoins
-			if err := tx.Save(&owner).Error; err != nil {
-				tx.Rollback()
-				c.String(http.StatusInternalServerError, "Failed to refund coins to owner")
-				return
-			}
-
-			// Record Transaction
-			trans := models.CoinTransaction{
-				UserID:          owner.ID,
-				Amount:          item.BountyCoins,
-				TransactionType: "admin_delete_refund",
-			}
-			if err := tx.Create(&trans).Error; err != nil {
-				tx.Rollback()
-				c.String(http.StatusInternalServerError, "Failed to create refund transaction")
-				return
-			}
-		}
-	}
-
-	// 1. Delete Image
-	if err := tx.Where("item_id = ?", item.ID).Delete(&models.LostItemImage{}).Error; err != nil {
+	if err := adminRemoveItem(tx, blobs, &item, admin.ID); err != nil {
 		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete item image")
+		c.String(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	// 2. Delete Comments
-	if err := tx.Where("item_id = ?", item.ID).Delete(&models.Comment{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete comments")
-		return
-	}
+	tx.Commit()
+	blobs.commit()
 
-	// 3. Delete Claims
-	if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemClaim{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete claims")
+	c.Redirect(http.StatusFound, "/dashboard")
+}
+
+// adminRemoveItem removes a post for an admin, see removeItem. Must run
+// inside a transaction.
+func adminRemoveItem(tx *gorm.DB, blobs *blobWrites, item *models.LostItem, adminID int64) error {
+	return removeItem(tx, blobs, item, &adminID, "Dihapus admin", "admin_delete_refund")
+}
+
+// ModerationRequest is the optional body for ban/suspend/unban actions
+type ModerationRequest struct {
+	Reason string `json:"reason" form:"reason"`
+	Days   int    `json:"days" form:"days"`
+}
+
+// BanUser allows admin to ban a user account
+func BanUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	var targetUser models.User
+	if err := config.DB.First(&targetUser, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	// 4. Delete Reports
-	if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemReport{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete reports")
+	// Prevent admin from banning themselves
+	currentUser := c.MustGet("user").(*models.User)
+	if targetUser.ID == currentUser.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot ban yourself"})
 		return
 	}
 
-	// 5. Delete Notifications linked to this item
-	if err := tx.Where("related_item_id = ?", item.ID).Delete(&models.Notification{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete notifications")
-		return
+	// Body is optional, older clients send none
+	var req ModerationRequest
+	c.ShouldBind(&req)
+	if req.Reason == "" {
+		req.Reason = "Pelanggaran ketentuan platform"
 	}
 
-	// 6. Finally, Delete the Item
-	if err := tx.Delete(&item).Error; err != nil {
+	// Set user as banned and record the action
+	tx := config.DB.Begin()
+	if _, err := utils.BanUser(tx, &targetUser, req.Reason, &currentUser.ID, nil); err != nil {
 		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete item")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
 		return
 	}
-
 	tx.Commit()
 
-	c.Redirect(http.StatusFound, "/dashboard")
+	// Return success - can be JSON or redirect depending on frontend
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "User banned successfully"})
 }
 
-// BanUser allows admin to ban a user account
-func BanUser(c *gin.Context) {
+// SuspendUser allows admin to suspend a user account for a number of days
+func SuspendUser(c *gin.Context) {
 	userID := c.Param("id")
 
 	var targetUser models.User
@@ -108,22 +100,50 @@ func BanUser(c *gin.Context) {
 		return
 	}
 
-	// Prevent admin from banning themselves
 	currentUser := c.MustGet("user").(*models.User)
 	if targetUser.ID == currentUser.ID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot ban yourself"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot suspend yourself"})
 		return
 	}
 
-	// Set user as banned
-	targetUser.IsBanned = true
-	if err := config.DB.Save(&targetUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+	if targetUser.IsBanned {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is already banned"})
 		return
 	}
 
-	// Return success - can be JSON or redirect depending on frontend
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "User banned successfully"})
+	var req ModerationRequest
+	c.ShouldBind(&req)
+	if req.Days <= 0 {
+		req.Days = config.SuspensionDays
+	}
+	if req.Reason == "" {
+		req.Reason = "Pelanggaran ketentuan platform"
+	}
+
+	until := time.Now().AddDate(0, 0, req.Days)
+
+	tx := config.DB.Begin()
+	if _, err := utils.SuspendUser(tx, &targetUser, until, req.Reason, &currentUser.ID, nil); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+
+	notification := models.Notification{
+		UserID:  targetUser.ID,
+		Type:    "warning",
+		Title:   "Akun Ditangguhkan",
+		Message: utils.RestrictionMessage(&targetUser),
+	}
+	if err := utils.Notify(tx, &notification); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		return
+	}
+	tx.Commit()
+	utils.NotificationHub.Publish(notification.UserID)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "User suspended until " + until.Format("02 Jan 2006 15:04")})
 }
 
 // UnbanUser allows admin to unban a user account
@@ -136,12 +156,19 @@ func UnbanUser(c *gin.Context) {
 		return
 	}
 
-	// Set user as unbanned
-	targetUser.IsBanned = false
-	if err := config.DB.Save(&targetUser).Error; err != nil {
+	currentUser := c.MustGet("user").(*models.User)
+
+	var req ModerationRequest
+	c.ShouldBind(&req)
+
+	// Set user as unbanned (also lifts any running suspension)
+	tx := config.DB.Begin()
+	if _, err := utils.UnbanUser(tx, &targetUser, req.Reason, &currentUser.ID); err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unban user"})
 		return
 	}
+	tx.Commit()
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "User unbanned successfully"})
 }
@@ -152,12 +179,27 @@ func AdminDashboard(c *gin.Context) {
 	var totalPosts int64
 	var totalUsers int64
 	var bannedUsersCount int64
+	var suspendedUsersCount int64
+	var pendingAppeals int64
 	var totalVisitors int64
 
 	config.DB.Model(&models.LostItem{}).Count(&totalPosts)
 	config.DB.Model(&models.User{}).Count(&totalUsers)
 	config.DB.Model(&models.User{}).Where("is_banned = ?", true).Count(&bannedUsersCount)
+	config.DB.Model(&models.User{}).Where("is_banned = ? AND suspended_until > ?", false, time.Now()).Count(&suspendedUsersCount)
 	config.DB.Model(&models.SiteVisit{}).Count(&totalVisitors)
+	config.DB.Model(&models.BanAppeal{}).Where("status = ?", "pending").Count(&pendingAppeals)
+
+	var heldItems, heldComments int64
+	config.DB.Model(&models.LostItem{}).Where("is_held = ?", true).Count(&heldItems)
+	config.DB.Model(&models.Comment{}).Where("is_held = ?", true).Count(&heldComments)
+
+	var failedWebhooks int64
+	config.DB.Model(&models.WebhookDelivery{}).Where("status = ?", "failed").Count(&failedWebhooks)
+	var duplicatePhotos int64
+	config.DB.Model(&models.ImageMatch{}).Where("dismissed_at IS NULL").Count(&duplicatePhotos)
+	var registryConflicts int64
+	config.DB.Model(&models.IdentifierConflict{}).Where("status = ?", "open").Count(&registryConflicts)
 
 	// Fetch recent posts
 	var recentPosts []models.LostItem
@@ -167,6 +209,10 @@ func AdminDashboard(c *gin.Context) {
 	var bannedUsers []models.User
 	config.DB.Where("is_banned = ?", true).Find(&bannedUsers)
 
+	// Fetch users inside an active suspension window
+	var suspendedUsers []models.User
+	config.DB.Where("is_banned = ? AND suspended_until > ?", false, time.Now()).Order("suspended_until ASC").Find(&suspendedUsers)
+
 	// Fetch all users with subscription status
 	var allUsers []models.User
 	config.DB.Order("date_joined DESC").Find(&allUsers)
@@ -200,6 +246,13 @@ func AdminDashboard(c *gin.Context) {
 	ctx["total_visitors"] = totalVisitors
 	ctx["recent_posts"] = recentPosts
 	ctx["banned_users"] = bannedUsers
+	ctx["suspended_users_count"] = suspendedUsersCount
+	ctx["suspended_users"] = suspendedUsers
+	ctx["pending_appeals"] = pendingAppeals
+	ctx["held_content"] = heldItems + heldComments
+	ctx["failed_webhooks"] = failedWebhooks
+	ctx["duplicate_photos"] = duplicatePhotos
+	ctx["registry_conflicts"] = registryConflicts
 	ctx["all_users"] = usersWithSubscription
 
 	tpl, err := pongo2.FromFile("templates/admin_dashboard.html")
@@ -271,13 +324,14 @@ func AdminApproveWithdrawal(c *gin.Context) {
 		Title:   "Withdrawal Approved",
 		Message: "Your withdrawal request for " + utils.FormatRupiah(wr.Amount) + " has been approved and is being processed.",
 	}
-	if err := tx.Create(&notification).Error; err != nil {
+	if err := utils.Notify(tx, &notification); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
 		return
 	}
 
 	tx.Commit()
+	utils.NotificationHub.Publish(notification.UserID)
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
@@ -343,13 +397,14 @@ func AdminRejectWithdrawal(c *gin.Context) {
 		Title:   "Withdrawal Rejected",
 		Message: "Your withdrawal request for " + utils.FormatRupiah(wr.Amount) + " has been rejected. The coins have been refunded to your balance.",
 	}
-	if err := tx.Create(&notification).Error; err != nil {
+	if err := utils.Notify(tx, &notification); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
 		return
 	}
 
 	tx.Commit()
+	utils.NotificationHub.Publish(notification.UserID)
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"unicode/utf8"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const maxAppealLength = 2000
+
+// appealUser loads the restricted user stored in the session by Login/AuthRequired
+func appealUser(c *gin.Context) (*models.User, bool) {
+	session := sessions.Default(c)
+	userID := session.Get("appeal_user_id")
+	if userID == nil {
+		return nil, false
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		session.Delete("appeal_user_id")
+		session.Save()
+		return nil, false
+	}
+
+	// Sanction already lifted (expired or unbanned), nothing left to appeal
+	utils.LiftExpiredSuspension(config.DB, &user)
+	if !utils.IsRestricted(&user) {
+		session.Delete("appeal_user_id")
+		session.Save()
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// AppealPage shows the appeal form, or the status of an appeal already filed
+func AppealPage(c *gin.Context) {
+	user, ok := appealUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+
+	sanction, err := utils.ActiveSanction(config.DB, user)
+	if err != nil {
+		c.String(http.StatusNotFound, "No sanction found for this account")
+		return
+	}
+
+	var appeal models.BanAppeal
+	hasAppeal := config.DB.Where("moderation_action_id = ?", sanction.ID).First(&appeal).Error == nil
+
+	utils.RenderTemplate(c, "templates/core/appeal.html", map[string]interface{}{
+		"appeal_user":         user,
+		"restriction_message": utils.RestrictionMessage(user),
+		"sanction":            sanction,
+		"appeal":              appeal,
+		"has_appeal":          hasAppeal,
+		"error":               c.Query("error"),
+		"max_length":          maxAppealLength,
+	})
+}
+
+// SubmitAppeal files a single appeal against the user's active sanction
+func SubmitAppeal(c *gin.Context) {
+	user, ok := appealUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+
+	statement := strings.TrimSpace(c.PostForm("statement"))
+	if valid, _ := utils.ValidateNotEmpty(statement, "Pernyataan"); !valid {
+		c.Redirect(http.StatusFound, "/appeal?error=empty")
+		return
+	}
+	if utf8.RuneCountInString(statement) > maxAppealLength {
+		c.Redirect(http.StatusFound, "/appeal?error=too_long")
+		return
+	}
+
+	sanction, err := utils.ActiveSanction(config.DB, user)
+	if err != nil {
+		c.String(http.StatusNotFound, "No sanction found for this account")
+		return
+	}
+
+	// Only one appeal per sanction
+	var count int64
+	config.DB.Model(&models.BanAppeal{}).Where("moderation_action_id = ?", sanction.ID).Count(&count)
+	if count > 0 {
+		c.Redirect(http.StatusFound, "/appeal?error=duplicate")
+		return
+	}
+
+	appeal := models.BanAppeal{
+		UserID:             user.ID,
+		ModerationActionID: sanction.ID,
+		Statement:          statement,
+		Status:             "pending",
+	}
+	if err := config.DB.Create(&appeal).Error; err != nil {
+		c.Redirect(http.StatusFound, "/appeal?error=failed")
+		return
+	}
+
+	// Notify all admins
+	var admins []models.User
+	config.DB.Where("is_superuser = ?", true).Find(&admins)
+	for _, admin := range admins {
+		notification := models.Notification{
+			UserID:       admin.ID,
+			Type:         "appeal",
+			Title:        "Banding Baru dari " + user.Username,
+			Message:      fmt.Sprintf("User %s mengajukan banding atas sanksi '%s'.", user.Username, sanction.Reason),
+			ReferenceURL: fmt.Sprintf("/admin/appeals?highlight=%d", appeal.ID),
+		}
+		if utils.Notify(config.DB, &notification) == nil {
+			utils.NotificationHub.Publish(admin.ID)
+		}
+	}
+
+	c.Redirect(http.StatusFound, "/appeal")
+}
+
+// AdminAppealList displays the appeal queue for admin
+func AdminAppealList(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+
+	query := config.DB.Preload("User").Preload("Admin").
+		Preload("ModerationAction").Preload("ModerationAction.Admin").
+		Preload("ModerationAction.Report").Preload("ModerationAction.Report.Item")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var appeals []models.BanAppeal
+	query.Order("created_at ASC").Find(&appeals)
+
+	var pendingAppeals int64
+	config.DB.Model(&models.BanAppeal{}).Where("status = ?", "pending").Count(&pendingAppeals)
+
+	ctx := map[string]interface{}{
+		"appeals":         appeals,
+		"status":          status,
+		"pending_appeals": pendingAppeals,
+	}
+	if hid, err := strconv.ParseInt(c.Query("highlight"), 10, 64); err == nil {
+		ctx["highlight_id"] = hid
+	}
+
+	utils.RenderTemplate(c, "templates/admin_appeals.html", ctx)
+}
+
+// AppealDecisionRequest is the optional body for approve/deny
+type AppealDecisionRequest struct {
+	Note string `json:"note" form:"note"`
+}
+
+// ApproveAppeal lifts the appealed sanction and notifies the user
+func ApproveAppeal(c *gin.Context) {
+	appealID := c.Param("id")
+	admin := c.MustGet("user").(*models.User)
+
+	var appeal models.BanAppeal
+	if err := config.DB.Preload("User").Preload("ModerationAction").First(&appeal, appealID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appeal not found"})
+		return
+	}
+
+	if appeal.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Appeal is not pending"})
+		return
+	}
+
+	var req AppealDecisionRequest
+	c.ShouldBind(&req)
+
+	tx := config.DB.Begin()
+
+	now := utils.GetCurrentTime()
+	appeal.Status = "approved"
+	appeal.AdminID = &admin.ID
+	appeal.AdminNote = req.Note
+	appeal.DecidedAt = &now
+	if err := tx.Save(&appeal).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appeal"})
+		return
+	}
+
+	// Lift only the appealed sanction; the entry points back at this appeal
+	action, err := utils.LiftSanction(tx, &appeal.User, &appeal.ModerationAction, fmt.Sprintf("Banding #%d disetujui", appeal.ID), &admin.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift sanction"})
+		return
+	}
+	if err := tx.Model(action).Update("appeal_id", appeal.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link audit trail"})
+		return
+	}
+
+	message := "Banding Anda telah disetujui. Akun Anda sudah aktif kembali."
+	if utils.IsRestricted(&appeal.User) {
+		message = "Banding Anda telah disetujui dan sanksi tersebut dicabut. " + utils.RestrictionMessage(&appeal.User)
+	}
+	if req.Note != "" {
+		message += " Catatan admin: " + req.Note
+	}
+	notification := models.Notification{
+		UserID:  appeal.UserID,
+		Type:    "appeal",
+		Title:   "Banding Disetujui",
+		Message: message,
+	}
+	if err := utils.Notify(tx, &notification); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		return
+	}
+
+	tx.Commit()
+	utils.NotificationHub.Publish(notification.UserID)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// DenyAppeal rejects the appeal, keeping the sanction in place
+func DenyAppeal(c *gin.Context) {
+	appealID := c.Param("id")
+	admin := c.MustGet("user").(*models.User)
+
+	var appeal models.BanAppeal
+	if err := config.DB.First(&appeal, appealID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appeal not found"})
+		return
+	}
+
+	if appeal.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Appeal is not pending"})
+		return
+	}
+
+	var req AppealDecisionRequest
+	c.ShouldBind(&req)
+
+	tx := config.DB.Begin()
+
+	now := utils.GetCurrentTime()
+	appeal.Status = "denied"
+	appeal.AdminID = &admin.ID
+	appeal.AdminNote = req.Note
+	appeal.DecidedAt = &now
+	if err := tx.Save(&appeal).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appeal"})
+		return
+	}
+
+	message := "Banding Anda telah ditolak. Sanksi pada akun Anda tetap berlaku."
+	if req.Note != "" {
+		message += " Catatan admin: " + req.Note
+	}
+	notification := models.Notification{
+		UserID:  appeal.UserID,
+		Type:    "appeal",
+		Title:   "Banding Ditolak",
+		Message: message,
+	}
+	if err := utils.Notify(tx, &notification); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		return
+	}
+
+	tx.Commit()
+	utils.NotificationHub.Publish(notification.UserID)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
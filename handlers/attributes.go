@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// attributeInputPrefix names the form inputs of attribute fields: attr_<field id>
+const attributeInputPrefix = "attr_"
+
+// AttributeFieldRow is a schema field in the admin list with its usage
+type AttributeFieldRow struct {
+	models.AttributeField
+	KindLabel  string
+	OptionList string
+	ValueCount int64
+}
+
+// AttributeSubCategoryRow is a subcategory in the admin picker with its field count
+type AttributeSubCategoryRow struct {
+	ID         int64
+	Name       string
+	FieldCount int64
+}
+
+// AttributeCategoryGroup is a category in the admin picker
+type AttributeCategoryGroup struct {
+	Name          string
+	SubCategories []AttributeSubCategoryRow
+}
+
+// AdminAttributeList shows the attribute schema of one subcategory, picked
+// from the list of all subcategories
+func AdminAttributeList(c *gin.Context) {
+	var categories []models.Category
+	config.DB.Preload("SubCategories", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	}).Order("name").Find(&categories)
+
+	var counts []struct {
+		SubCategoryID int64
+		Count         int64
+	}
+	config.DB.Model(&models.AttributeField{}).
+		Select("subcategory_id, COUNT(*) AS count").
+		Group("subcategory_id").
+		Scan(&counts)
+	fieldCounts := make(map[int64]int64, len(counts))
+	for _, row := range counts {
+		fieldCounts[row.SubCategoryID] = row.Count
+	}
+
+	groups := make([]AttributeCategoryGroup, len(categories))
+	for i, category := range categories {
+		groups[i].Name = category.Name
+		for _, sub := range category.SubCategories {
+			groups[i].SubCategories = append(groups[i].SubCategories, AttributeSubCategoryRow{
+				ID:         sub.ID,
+				Name:       sub.Name,
+				FieldCount: fieldCounts[sub.ID],
+			})
+		}
+	}
+
+	data := map[string]interface{}{
+		"categories": groups,
+	}
+
+	if id := parseOptionalID(c.Query("subcategory")); id != nil {
+		var sub models.SubCategory
+		if err := config.DB.Preload("Category").First(&sub, *id).Error; err == nil {
+			var valueCounts []struct {
+				FieldID int64
+				Count   int64
+			}
+			config.DB.Model(&models.ItemAttribute{}).
+				Select("core_itemattribute.field_id, COUNT(*) AS count").
+				Joins("JOIN core_attributefield ON core_attributefield.id = core_itemattribute.field_id").
+				Where("core_attributefield.subcategory_id = ?", sub.ID).
+				Group("core_itemattribute.field_id").
+				Scan(&valueCounts)
+			usage := make(map[int64]int64, len(valueCounts))
+			for _, row := range valueCounts {
+				usage[row.FieldID] = row.Count
+			}
+
+			fields := utils.SubCategoryFields(config.DB, sub.ID)
+			rows := make([]AttributeFieldRow, len(fields))
+			for i, field := range fields {
+				rows[i] = AttributeFieldRow{
+					AttributeField: field,
+					KindLabel:      utils.AttributeKindLabels[field.Kind],
+					OptionList:     strings.Join(utils.AttributeOptions(&field), ", "),
+					ValueCount:     usage[field.ID],
+				}
+			}
+			data["subcategory"] = sub
+			data["fields"] = rows
+		}
+	}
+
+	kinds := make([]map[string]string, len(utils.AttributeKinds))
+	for i, kind := range utils.AttributeKinds {
+		kinds[i] = map[string]string{"value": kind, "label": utils.AttributeKindLabels[kind]}
+	}
+	data["kinds"] = kinds
+
+	utils.RenderTemplate(c, "templates/admin_attributes.html", data)
+}
+
+// AttributeFieldRequest is the body for creating or updating a schema field
+type AttributeFieldRequest struct {
+	SubCategoryID int64  `json:"subcategory_id" form:"subcategory_id"`
+	Name          string `json:"name" form:"name"`
+	Kind          string `json:"kind" form:"kind"`
+	Options       string `json:"options" form:"options"`
+	IsPrivate     bool   `json:"is_private" form:"is_private"`
+	IsRequired    bool   `json:"is_required" form:"is_required"`
+	Position      int    `json:"position" form:"position"`
+}
+
+// validate checks the request and returns an error message for the admin
+func (req *AttributeFieldRequest) validate() string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "Nama atribut wajib diisi (maks. 100 karakter)"
+	}
+	if _, ok := utils.AttributeKindLabels[req.Kind]; !ok {
+		return "Jenis atribut tidak dikenal"
+	}
+
+	// Choices may be typed one per line or comma-separated
+	var options []string
+	seen := make(map[string]bool)
+	for _, line := range strings.FieldsFunc(req.Options, func(r rune) bool { return r == '\n' || r == ',' }) {
+		option := strings.TrimSpace(line)
+		if option == "" || seen[strings.ToLower(option)] {
+			continue
+		}
+		if len(option) > 100 {
+			return "Pilihan terlalu panjang: " + option
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+	if req.Kind == "enum" && len(options) < 2 {
+		return "Atribut pilihan membutuhkan minimal 2 pilihan"
+	}
+	if req.Kind != "enum" {
+		options = nil
+	}
+	req.Options = strings.Join(options, "\n")
+	return ""
+}
+
+// CreateAttributeField adds a field to a subcategory's schema
+func CreateAttributeField(c *gin.Context) {
+	var req AttributeFieldRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	var sub models.SubCategory
+	if err := config.DB.First(&sub, req.SubCategoryID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-kategori tidak ditemukan"})
+		return
+	}
+
+	field := models.AttributeField{
+		SubCategoryID: sub.ID,
+		Name:          req.Name,
+		Kind:          req.Kind,
+		Options:       req.Options,
+		IsPrivate:     req.IsPrivate,
+		IsRequired:    req.IsRequired,
+		Position:      req.Position,
+	}
+	if err := config.DB.Create(&field).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attribute"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "id": field.ID})
+}
+
+// UpdateAttributeField edits a schema field. The kind is fixed once posts
+// have values for the field, since they were validated against it.
+func UpdateAttributeField(c *gin.Context) {
+	var field models.AttributeField
+	if err := config.DB.First(&field, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
+		return
+	}
+
+	var req AttributeFieldRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if req.Kind != field.Kind {
+		var used int64
+		config.DB.Model(&models.ItemAttribute{}).Where("field_id = ?", field.ID).Count(&used)
+		if used > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis tidak bisa diubah karena sudah ada postingan yang mengisinya"})
+			return
+		}
+	}
+
+	wasPrivate := field.IsPrivate
+	if err := config.DB.Model(&field).Updates(map[string]interface{}{
+		"name":        req.Name,
+		"kind":        req.Kind,
+		"options":     req.Options,
+		"is_private":  req.IsPrivate,
+		"is_required": req.IsRequired,
+		"position":    req.Position,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attribute"})
+		return
+	}
+	if req.IsPrivate != wasPrivate {
+		reindexSubCategory(field.SubCategoryID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// reindexSubCategory rebuilds the search terms of a subcategory's posts after
+// a field stops or starts being searchable
+func reindexSubCategory(subCategoryID int64) {
+	var items []models.LostItem
+	config.DB.Where("subcategory_id = ?", subCategoryID).Find(&items)
+	for i := range items {
+		if err := utils.IndexItem(config.DB, &items[i]); err != nil {
+			log.Printf("[search] index item %d: %v", items[i].ID, err)
+		}
+	}
+}
+
+// DeleteAttributeField removes a field with the values posts and claims gave it
+func DeleteAttributeField(c *gin.Context) {
+	var field models.AttributeField
+	if err := config.DB.First(&field, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attribute not found"})
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Where("field_id = ?", field.ID).Delete(&models.ItemAttribute{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete values"})
+		return
+	}
+	if err := tx.Where("field_id = ?", field.ID).Delete(&models.ClaimAttribute{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete claim values"})
+		return
+	}
+	if err := tx.Delete(&field).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attribute"})
+		return
+	}
+	tx.Commit()
+
+	// Values of public fields were part of the search index
+	if !field.IsPrivate {
+		reindexSubCategory(field.SubCategoryID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// attributeFieldJSON is a schema field as the form script renders it
+type attributeFieldJSON struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`
+	Options    []string `json:"options"`
+	IsPrivate  bool     `json:"is_private"`
+	IsRequired bool     `json:"is_required"`
+}
+
+// attributeSchemasJSON encodes every subcategory's fields, keyed by
+// subcategory ID, for static/js/attribute_fields.js
+func attributeSchemasJSON() string {
+	var fields []models.AttributeField
+	config.DB.Order("position, id").Find(&fields)
+
+	schemas := make(map[string][]attributeFieldJSON)
+	for _, field := range fields {
+		key := strconv.FormatInt(field.SubCategoryID, 10)
+		schemas[key] = append(schemas[key], attributeFieldJSON{
+			ID:         field.ID,
+			Name:       field.Name,
+			Kind:       field.Kind,
+			Options:    utils.AttributeOptions(&field),
+			IsPrivate:  field.IsPrivate,
+			IsRequired: field.IsRequired,
+		})
+	}
+	// json.Marshal escapes <, > and &, so this is safe inside <script>
+	out, _ := json.Marshal(schemas)
+	return string(out)
+}
+
+// attributeValuesJSON encodes field values keyed by field ID for the form
+// script
+func attributeValuesJSON(values map[int64]string) string {
+	byKey := make(map[string]string, len(values))
+	for id, value := range values {
+		byKey[strconv.FormatInt(id, 10)] = value
+	}
+	out, _ := json.Marshal(byKey)
+	return string(out)
+}
+
+// submittedAttributes echoes the attr_ inputs of a failed submit back to the
+// form, as typed
+func submittedAttributes(c *gin.Context) map[int64]string {
+	values := make(map[int64]string)
+	for key, posted := range c.Request.PostForm {
+		if !strings.HasPrefix(key, attributeInputPrefix) || len(posted) == 0 {
+			continue
+		}
+		if id, err := strconv.ParseInt(strings.TrimPrefix(key, attributeInputPrefix), 10, 64); err == nil {
+			values[id] = posted[0]
+		}
+	}
+	return values
+}
+
+// storedAttributes loads a post's values keyed by field ID
+func storedAttributes(db *gorm.DB, itemID int64) map[int64]string {
+	var rows []models.ItemAttribute
+	db.Where("item_id = ?", itemID).Find(&rows)
+	values := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		values[row.FieldID] = row.Value
+	}
+	return values
+}
+
+// readAttributes reads the attr_<id> inputs of a subcategory's fields and
+// returns the normalized, non-empty values. Returns a user-facing message
+// when a value is invalid or a required one is missing.
+func readAttributes(c *gin.Context, fields []models.AttributeField) (map[int64]string, string) {
+	values := make(map[int64]string)
+	for i := range fields {
+		field := &fields[i]
+		value, msg := utils.NormalizeAttribute(field, c.PostForm(attributeInputPrefix+strconv.FormatInt(field.ID, 10)))
+		if msg != "" {
+			return nil, msg
+		}
+		if value != "" {
+			values[field.ID] = value
+		}
+	}
+	return values, ""
+}
+
+// publicAttributeTexts lists the public text values, which others will read
+// and so go through the content rules. Private values (IMEI, serials) are
+// never shown and would trip the phone number rules.
+func publicAttributeTexts(fields []models.AttributeField, values map[int64]string) []string {
+	var texts []string
+	for _, field := range fields {
+		if field.Kind == "text" && !field.IsPrivate && values[field.ID] != "" {
+			texts = append(texts, values[field.ID])
+		}
+	}
+	return texts
+}
+
+// saveItemAttributes replaces a post's attribute values. Values of another
+// subcategory's fields go when the post moves.
+func saveItemAttributes(tx *gorm.DB, itemID int64, values map[int64]string) error {
+	if err := tx.Where("item_id = ?", itemID).Delete(&models.ItemAttribute{}).Error; err != nil {
+		return err
+	}
+	for fieldID, value := range values {
+		if err := tx.Create(&models.ItemAttribute{ItemID: itemID, FieldID: fieldID, Value: value}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteItemAttributes removes the attribute values of a post and of the
+// claims on it. Call it before the claims are deleted.
+func deleteItemAttributes(tx *gorm.DB, itemID int64) error {
+	if err := tx.Where("claim_id IN (?)", tx.Model(&models.ItemClaim{}).Select("id").Where("item_id = ?", itemID)).
+		Delete(&models.ClaimAttribute{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("item_id = ?", itemID).Delete(&models.ItemAttribute{}).Error
+}
+
+// saveClaimAttributes stores what a claimant described about the item. All
+// fields are optional for claims and values that do not fit are skipped.
+func saveClaimAttributes(c *gin.Context, db *gorm.DB, item *models.LostItem, claimID int64) error {
+	if item.SubCategoryID == nil {
+		return nil
+	}
+	for _, field := range utils.SubCategoryFields(db, *item.SubCategoryID) {
+		field.IsRequired = false
+		value, msg := utils.NormalizeAttribute(&field, c.PostForm(attributeInputPrefix+strconv.FormatInt(field.ID, 10)))
+		if msg != "" || value == "" {
+			continue
+		}
+		if err := db.Create(&models.ClaimAttribute{ClaimID: claimID, FieldID: field.ID, Value: value}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimView is a claim on the owner's post with how its description compares
+type ClaimView struct {
+	models.ItemClaim
+	Signals    []utils.AttributeSignal
+	Matched    int
+	Reputation utils.Reputation
+}
+
+// ClaimField is a field a claimant may fill in, with its choices split
+type ClaimField struct {
+	models.AttributeField
+	Choices []string
+}
+
+// claimFields lists the fields of a subcategory for the claim form
+func claimFields(db *gorm.DB, subCategoryID int64) []ClaimField {
+	fields := utils.SubCategoryFields(db, subCategoryID)
+	claimFields := make([]ClaimField, len(fields))
+	for i := range fields {
+		claimFields[i] = ClaimField{AttributeField: fields[i], Choices: utils.AttributeOptions(&fields[i])}
+	}
+	return claimFields
+}
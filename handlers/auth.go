@@ -19,6 +19,14 @@ import (
 )
 
 func LoginPage(c *gin.Context) {
+	// Banned or suspended users are sent to the appeal form instead of the landing page
+	errParam := c.Query("error")
+	if errParam == "banned" || errParam == "suspended" {
+		if sessions.Default(c).Get("appeal_user_id") != nil {
+			c.Redirect(http.StatusFound, "/appeal")
+			return
+		}
+	}
 	c.Redirect(http.StatusFound, "/")
 }
 
@@ -91,14 +99,22 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Check if user is banned
-	if user.IsBanned {
-		errMsg := "Akun Anda telah diblokir karena melanggar ketentuan platform (penipuan, jual beli, atau pelanggaran lainnya). Silakan hubungi admin jika ada pertanyaan."
+	// Check if user is banned or suspended
+	utils.LiftExpiredSuspension(config.DB, &user)
+	if utils.IsRestricted(&user) {
+		errMsg := utils.RestrictionMessage(&user)
+
+		// Password was verified, so allow this browser to file an appeal
+		session := sessions.Default(c)
+		session.Set("appeal_user_id", user.ID)
+		session.Save()
+
 		if isJSON {
-			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": errMsg})
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": errMsg, "appeal_url": "/appeal"})
 			return
 		}
 		ctx["banned_error"] = errMsg
+		ctx["appeal_url"] = "/appeal"
 		ctx["username"] = username
 		tpl := pongo2.Must(pongo2.FromFile("templates/core/login.html"))
 		out, _ := tpl.Execute(ctx)
@@ -109,6 +125,7 @@ func Login(c *gin.Context) {
 	// Login successful
 	session := sessions.Default(c)
 	session.Set("user_id", user.ID)
+	session.Delete("appeal_user_id")
 	session.Save()
 
 	if isJSON {
@@ -391,9 +408,21 @@ func Profile(c *gin.Context) {
 
 	// set ctx (sesuaikan nama kunci dengan template)
 	ctx["user"] = user
+	ctx["avatar_version"] = profilePictureVersion(user.ProfilePicture)
 	ctx["items"] = items
 	ctx["found_items"] = foundItems
+	ctx["reputation"] = utils.UserReputation(config.DB, user.ID)
 	ctx["transactions"] = allTransactions
+	ctx["notification_preferences"] = notificationPreferenceRows(user.ID)
+	ctx["notification_saved"] = c.Query("saved") == "notifications"
+
+	var savedSearches []models.SavedSearch
+	config.DB.Preload("Category").Preload("SubCategory").
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Find(&savedSearches)
+	ctx["saved_searches"] = savedSearches
+	ctx["search_saved"] = c.Query("saved") == "search"
 
 	tpl := pongo2.Must(pongo2.FromFile("templates/core/profile.html"))
 	out, _ := tpl.Execute(ctx)
@@ -500,15 +529,24 @@ func GoogleCallback(c *gin.Context) {
 	}
 	// If user exists, just login (no need to update anything)
 
-	// Check if user is banned
-	if user.IsBanned {
-		c.Redirect(http.StatusFound, "/login?error=banned")
+	// Check if user is banned or suspended
+	utils.LiftExpiredSuspension(config.DB, &user)
+	if utils.IsRestricted(&user) {
+		session.Set("appeal_user_id", user.ID)
+		session.Delete("oauth_state")
+		session.Save()
+		if user.IsBanned {
+			c.Redirect(http.StatusFound, "/login?error=banned")
+		} else {
+			c.Redirect(http.StatusFound, "/login?error=suspended")
+		}
 		return
 	}
 
 	// Set session
 	session.Set("user_id", user.ID)
 	session.Delete("oauth_state")
+	session.Delete("appeal_user_id")
 	session.Save()
 
 	c.Redirect(http.StatusFound, "/dashboard")
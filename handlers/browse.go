@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
+	"strconv"
 	"temuin/config"
 	"temuin/models"
 	"temuin/utils"
@@ -10,6 +12,40 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// categoryHighlights returns up to 3 highlighted posts matching the page
+// scope, and whether more exist
+func categoryHighlights(column string, id int64) ([]models.LostItem, bool) {
+	var pinnedItems []models.LostItem
+	config.DB.Preload("User").Scopes(utils.VisibleContent).
+		Where("is_highlighted = ? AND "+column+" = ?", true, id).
+		Order("highlight_expiry DESC").
+		Limit(3).
+		Find(&pinnedItems)
+
+	var total int64
+	config.DB.Model(&models.LostItem{}).Scopes(utils.VisibleContent).
+		Where("is_highlighted = ? AND "+column+" = ?", true, id).
+		Count(&total)
+	return pinnedItems, total > 3
+}
+
+// renderBrowse lists the remaining posts through the shared listing service
+// and renders them below the highlights
+func renderBrowse(c *gin.Context, ctx pongo2.Context, filter utils.ListingFilter) {
+	filter.ExcludeHighlighted = true
+	filter.CountFacets = true
+	listItems(c, ctx, filter)
+
+	ctx["q"] = filter.Query
+	ctx["status"] = filter.Status
+	ctx["location"] = filter.Location
+	setPlaceFilter(ctx, c)
+
+	tpl := pongo2.Must(pongo2.FromFile("templates/core/home.html"))
+	out, _ := tpl.Execute(ctx)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
+}
+
 func CategoryPage(c *gin.Context) {
 	id := c.Param("pk")
 	ctx := utils.GetGlobalContext(c)
@@ -23,36 +59,18 @@ func CategoryPage(c *gin.Context) {
 		return
 	}
 
-	// items
-	var items []models.LostItem
-	var pinnedItems []models.LostItem
-	var normalItems []models.LostItem
-
-	config.DB.Preload("User").Where("category_id = ?", id).Find(&items)
-
-	for _, item := range items {
-		if item.IsHighlighted {
-			pinnedItems = append(pinnedItems, item)
-		} else {
-			normalItems = append(normalItems, item)
-		}
-	}
-
-	// Limit pinned items to 3, set flag if more exist
-	hasMoreHighlights := len(pinnedItems) > 3
-	if hasMoreHighlights {
-		pinnedItems = pinnedItems[:3]
-	}
+	pinnedItems, hasMoreHighlights := categoryHighlights("category_id", category.ID)
 
 	ctx["active_category"] = category
-	ctx["items"] = normalItems
 	ctx["pinned_items"] = pinnedItems
 	ctx["has_more_highlights"] = hasMoreHighlights
 	ctx["header_title"] = category.Name
 
-	tpl := pongo2.Must(pongo2.FromFile("templates/core/home.html"))
-	out, _ := tpl.Execute(ctx)
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
+	// The subcategory facet narrows the category page
+	ctx["subcategory_param"] = c.Query("subcategory")
+	filter := utils.ParseListingFilter(c)
+	filter.CategoryID = &category.ID
+	renderBrowse(c, ctx, filter)
 }
 
 func SubCategoryPage(c *gin.Context) {
@@ -72,38 +90,18 @@ func SubCategoryPage(c *gin.Context) {
 	var category models.Category
 	config.DB.Preload("SubCategories").First(&category, sub.CategoryID)
 
-	// items
-	var items []models.LostItem
-	var pinnedItems []models.LostItem
-	var normalItems []models.LostItem
-
-	// Fetch all for this subcat
-	config.DB.Preload("User").Where("subcategory_id = ?", id).Find(&items)
-
-	for _, item := range items {
-		if item.IsHighlighted {
-			pinnedItems = append(pinnedItems, item)
-		} else {
-			normalItems = append(normalItems, item)
-		}
-	}
-
-	// Limit pinned items to 3, set flag if more exist
-	hasMoreHighlights := len(pinnedItems) > 3
-	if hasMoreHighlights {
-		pinnedItems = pinnedItems[:3]
-	}
+	pinnedItems, hasMoreHighlights := categoryHighlights("subcategory_id", sub.ID)
 
 	ctx["active_category"] = category
 	ctx["active_subcategory"] = sub
-	ctx["items"] = normalItems
 	ctx["pinned_items"] = pinnedItems
 	ctx["has_more_highlights"] = hasMoreHighlights
 	ctx["header_title"] = sub.Name
 
-	tpl := pongo2.Must(pongo2.FromFile("templates/core/home.html"))
-	out, _ := tpl.Execute(ctx)
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
+	filter := utils.ParseListingFilter(c)
+	filter.SubCategoryID = &sub.ID
+	filter.PinAttributeFields(utils.SubCategoryFields(config.DB, sub.ID))
+	renderBrowse(c, ctx, filter)
 }
 
 // AllHighlightsPage shows all highlighted items globally
@@ -114,7 +112,7 @@ func AllHighlightsPage(c *gin.Context) {
 	utils.ExpireHighlights(config.DB)
 
 	var highlightedItems []models.LostItem
-	config.DB.Preload("User").Preload("Category").Preload("SubCategory").
+	config.DB.Preload("User").Preload("Category").Preload("SubCategory").Scopes(utils.VisibleContent).
 		Where("is_highlighted = ?", true).
 		Order("highlight_expiry DESC").
 		Find(&highlightedItems)
@@ -146,7 +144,7 @@ func CategoryHighlightsPage(c *gin.Context) {
 	}
 
 	var highlightedItems []models.LostItem
-	config.DB.Preload("User").Preload("Category").Preload("SubCategory").
+	config.DB.Preload("User").Preload("Category").Preload("SubCategory").Scopes(utils.VisibleContent).
 		Where("is_highlighted = ? AND category_id = ?", true, categoryID).
 		Order("highlight_expiry DESC").
 		Find(&highlightedItems)
@@ -162,3 +160,38 @@ func CategoryHighlightsPage(c *gin.Context) {
 	out, _ := tpl.Execute(ctx)
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
 }
+
+// MapPage shows posts with a map pin on a clustered map
+func MapPage(c *gin.Context) {
+	ctx := utils.GetGlobalContext(c)
+	ctx["header_title"] = "Peta Barang"
+	ctx["status"] = c.Query("status")
+
+	tpl := pongo2.Must(pongo2.FromFile("templates/core/map.html"))
+	out, _ := tpl.Execute(ctx)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
+}
+
+// ItemPins returns clustered, approximate pins for the visible map area.
+// Takes bbox=south,west,north,east and zoom plus the usual listing filters.
+func ItemPins(c *gin.Context) {
+	bounds, ok := utils.ParseMapBounds(c.Query("bbox"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bbox"})
+		return
+	}
+	zoom, _ := strconv.Atoi(c.DefaultQuery("zoom", "12"))
+
+	filter := utils.ParseListingFilter(c)
+	if id, err := strconv.ParseInt(c.Query("category"), 10, 64); err == nil && id > 0 {
+		filter.CategoryID = &id
+	}
+
+	pins, err := utils.ClusterPins(config.DB, filter, bounds, zoom)
+	if err != nil {
+		log.Println("map pins failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pins"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pins": pins})
+}
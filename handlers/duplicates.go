@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// duplicateMatchLimit caps the matches kept for one new photo; a stock
+	// photo reused everywhere only needs a few to be noticed
+	duplicateMatchLimit = 10
+	// duplicatePageLimit caps the matches grouped on the admin page
+	duplicatePageLimit = 1000
+)
+
+// flagDuplicatePhotos compares new photos of a post against the photos on
+// other users' posts. Near-duplicates are recorded for the duplicates page
+// and the post is reported to moderators, since scammers reuse the same
+// picture across fake posts. Photos of the owner's own posts never count.
+func flagDuplicatePhotos(db *gorm.DB, item *models.LostItem, images []models.LostItemImage) error {
+	var matches []models.ImageMatch
+	for _, image := range images {
+		if image.PHash == 0 {
+			continue
+		}
+		// MySQL compares the hashes itself, without an index, so every
+		// fingerprinted photo is scanned once per new photo
+		var candidates []struct {
+			ID       int64
+			ItemID   int64
+			Distance int
+		}
+		if err := db.Table("core_lostitem_photo AS p").
+			Select("p.id, p.item_id, BIT_COUNT(p.phash ^ ?) AS distance", image.PHash).
+			Joins("JOIN core_lostitem AS i ON i.id = p.item_id").
+			Where("p.phash <> 0 AND i.user_id <> ?", item.UserID).
+			Where("BIT_COUNT(p.phash ^ ?) <= ?", image.PHash, config.DuplicatePhotoBits).
+			Order("distance, p.id").
+			Limit(duplicateMatchLimit).
+			Scan(&candidates).Error; err != nil {
+			return err
+		}
+		for _, candidate := range candidates {
+			match := models.ImageMatch{
+				ImageID:      image.ID,
+				MatchImageID: candidate.ID,
+				ItemID:       item.ID,
+				MatchItemID:  candidate.ItemID,
+				Distance:     candidate.Distance,
+			}
+			if err := db.Create(&match).Error; err != nil {
+				return err
+			}
+			matches = append(matches, match)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	seen := make(map[int64]bool)
+	var matchedIDs []int64
+	for _, match := range matches {
+		if !seen[match.MatchItemID] {
+			seen[match.MatchItemID] = true
+			matchedIDs = append(matchedIDs, match.MatchItemID)
+		}
+	}
+	var matchedItems []models.LostItem
+	db.Select("id", "title").Where("id IN ?", matchedIDs).Order("id").Find(&matchedItems)
+	titles := make([]string, len(matchedItems))
+	for i, matched := range matchedItems {
+		titles[i] = fmt.Sprintf("'%s' (#%d)", matched.Title, matched.ID)
+	}
+
+	dueAt := time.Now().Add(config.ReportSLA("fraud"))
+	report := models.ItemReport{
+		ItemID:      item.ID,
+		Reason:      "fraud",
+		Description: "Ditandai otomatis: foto mirip dengan foto pada postingan pengguna lain " + strings.Join(titles, ", ") + ".",
+		Status:      "pending",
+		DueAt:       &dueAt,
+	}
+	if err := db.Create(&report).Error; err != nil {
+		return err
+	}
+	if err := utils.QueueWebhook(db, "report.created", reportWebhookData(&report)); err != nil {
+		return err
+	}
+	admins, err := notifyAdmins(db, "Foto Duplikat Terdeteksi",
+		fmt.Sprintf("Foto pada postingan '%s' mirip dengan foto di %d postingan pengguna lain.", item.Title, len(matchedIDs)),
+		fmt.Sprintf("/admin/duplicates?highlight=%d", item.ID), &item.ID, &report.ID)
+	if err != nil {
+		return err
+	}
+	// db is never a transaction here, so the notifications are already saved
+	utils.NotificationHub.Publish(admins...)
+	return nil
+}
+
+// DuplicatePhoto is one photo in a duplicate cluster
+type DuplicatePhoto struct {
+	Image models.LostItemImage
+	Item  models.LostItem
+}
+
+// DuplicateCluster is a group of photos linked by near-duplicate matches
+type DuplicateCluster struct {
+	Photos      []DuplicatePhoto
+	MatchIDs    []int64
+	UserCount   int
+	MinDistance int
+	LatestAt    time.Time
+	Dismissed   bool
+	Highlighted bool
+}
+
+// AdminDuplicateList groups near-duplicate photos into clusters: photos
+// linked by any chain of matches belong together
+func AdminDuplicateList(c *gin.Context) {
+	showAll := c.Query("show") == "all"
+	query := config.DB.Order("created_at DESC, id DESC").Limit(duplicatePageLimit)
+	if !showAll {
+		query = query.Where("dismissed_at IS NULL")
+	}
+	var matches []models.ImageMatch
+	query.Find(&matches)
+
+	// Union-find over photo IDs
+	parent := make(map[int64]int64)
+	var find func(id int64) int64
+	find = func(id int64) int64 {
+		if _, ok := parent[id]; !ok {
+			parent[id] = id
+		}
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	for _, match := range matches {
+		parent[find(match.ImageID)] = find(match.MatchImageID)
+	}
+
+	imageIDs := make([]int64, 0, len(parent))
+	for id := range parent {
+		imageIDs = append(imageIDs, id)
+	}
+	var images []models.LostItemImage
+	if len(imageIDs) > 0 {
+		config.DB.Select("id", "item_id", "position", "content_hash", "width", "height", "created_at").
+			Where("id IN ?", imageIDs).
+			Find(&images)
+	}
+	itemIDs := make([]int64, 0, len(images))
+	for _, image := range images {
+		itemIDs = append(itemIDs, image.ItemID)
+	}
+	items := make(map[int64]models.LostItem)
+	if len(itemIDs) > 0 {
+		var rows []models.LostItem
+		config.DB.Preload("User").Where("id IN ?", itemIDs).Find(&rows)
+		for _, item := range rows {
+			items[item.ID] = item
+		}
+	}
+
+	var highlight int64
+	if id := parseOptionalID(c.Query("highlight")); id != nil {
+		highlight = *id
+	}
+
+	byRoot := make(map[int64]*DuplicateCluster)
+	for _, image := range images {
+		item, ok := items[image.ItemID]
+		if !ok {
+			continue
+		}
+		root := find(image.ID)
+		cluster := byRoot[root]
+		if cluster == nil {
+			cluster = &DuplicateCluster{MinDistance: 64, Dismissed: true}
+			byRoot[root] = cluster
+		}
+		cluster.Photos = append(cluster.Photos, DuplicatePhoto{Image: image, Item: item})
+		if item.ID == highlight {
+			cluster.Highlighted = true
+		}
+	}
+	for _, match := range matches {
+		cluster := byRoot[find(match.ImageID)]
+		if cluster == nil {
+			continue
+		}
+		cluster.MatchIDs = append(cluster.MatchIDs, match.ID)
+		cluster.MinDistance = min(cluster.MinDistance, match.Distance)
+		if match.CreatedAt.After(cluster.LatestAt) {
+			cluster.LatestAt = match.CreatedAt
+		}
+		if match.DismissedAt == nil {
+			cluster.Dismissed = false
+		}
+	}
+
+	clusters := make([]*DuplicateCluster, 0, len(byRoot))
+	for _, cluster := range byRoot {
+		users := make(map[int64]bool)
+		for _, photo := range cluster.Photos {
+			users[photo.Item.UserID] = true
+		}
+		cluster.UserCount = len(users)
+		// Oldest photo first: it is most likely the original
+		sort.Slice(cluster.Photos, func(i, j int) bool {
+			return cluster.Photos[i].Image.CreatedAt.Before(cluster.Photos[j].Image.CreatedAt)
+		})
+		if len(cluster.Photos) > 1 {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].LatestAt.After(clusters[j].LatestAt)
+	})
+
+	utils.RenderTemplate(c, "templates/admin_duplicates.html", map[string]interface{}{
+		"clusters": clusters,
+		"show_all": showAll,
+		"max_bits": config.DuplicatePhotoBits,
+	})
+}
+
+// DismissDuplicates marks the matches of a cluster as not a problem, such as
+// a shared campus photo or two honest posts about the same item
+func DismissDuplicates(c *gin.Context) {
+	var req struct {
+		MatchIDs []int64 `json:"match_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.MatchIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	currentUser := c.MustGet("user").(*models.User)
+	now := time.Now()
+	if err := config.DB.Model(&models.ImageMatch{}).
+		Where("id IN ? AND dismissed_at IS NULL", req.MatchIDs).
+		Updates(map[string]interface{}{
+			"dismissed_at":    now,
+			"dismissed_by_id": currentUser.ID,
+		}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss matches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
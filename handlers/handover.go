@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const handoverQRSize = 320
+
+// Sides of a return, see models.HandoverCode.ShownBy
+const (
+	handoverOwner  = "owner"
+	handoverFinder = "finder"
+)
+
+// handoverSide is the side the user takes in the item's return, or "" when
+// they are neither the owner nor the selected finder
+func handoverSide(item *models.LostItem, userID int64) string {
+	switch {
+	case item.UserID == userID:
+		return handoverOwner
+	case item.FinderID != nil && *item.FinderID == userID:
+		return handoverFinder
+	}
+	return ""
+}
+
+// otherHandoverSide is the side that enters the code shown by side
+func otherHandoverSide(side string) string {
+	if side == handoverOwner {
+		return handoverFinder
+	}
+	return handoverOwner
+}
+
+// handoverHolder is the user who shows the side's code
+func handoverHolder(item *models.LostItem, side string) int64 {
+	if side == handoverFinder {
+		return *item.FinderID
+	}
+	return item.UserID
+}
+
+// newHandoverCode returns a random six-digit code
+func newHandoverCode() string {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%06d", n.Int64())
+}
+
+// activeHandoverCode is the unused code side shows to the item's current
+// finder or owner, or nil
+func activeHandoverCode(db *gorm.DB, item *models.LostItem, side string) *models.HandoverCode {
+	if item.FinderID == nil {
+		return nil
+	}
+	var code models.HandoverCode
+	err := db.Where("item_id = ? AND finder_id = ? AND shown_by = ? AND used_at IS NULL AND revoked_at IS NULL", item.ID, *item.FinderID, side).
+		Order("id DESC").First(&code).Error
+	if err != nil {
+		return nil
+	}
+	return &code
+}
+
+// issueHandoverCode returns the active code of side, creating one when it has
+// none for this finder yet or the last one was revoked
+func issueHandoverCode(db *gorm.DB, item *models.LostItem, side string) (*models.HandoverCode, error) {
+	if code := activeHandoverCode(db, item, side); code != nil {
+		return code, nil
+	}
+	code := models.HandoverCode{ItemID: item.ID, FinderID: *item.FinderID, ShownBy: side, Code: newHandoverCode()}
+	if err := db.Create(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// revokeHandoverCodes invalidates the item's unused codes, such as when the
+// owner picks another finder
+func revokeHandoverCodes(tx *gorm.DB, itemID int64) error {
+	return tx.Model(&models.HandoverCode{}).
+		Where("item_id = ? AND used_at IS NULL AND revoked_at IS NULL", itemID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// deleteHandover removes an item's handover codes and attempt log. Must run
+// inside the delete transaction.
+func deleteHandover(tx *gorm.DB, itemID int64) error {
+	if err := tx.Where("item_id = ?", itemID).Delete(&models.HandoverAttempt{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("item_id = ?", itemID).Delete(&models.HandoverCode{}).Error
+}
+
+// handoverFailures counts the wrong entries against the item's codes
+func handoverFailures(db *gorm.DB, itemID int64) int64 {
+	var count int64
+	db.Model(&models.HandoverAttempt{}).Where("item_id = ? AND success = ?", itemID, false).Count(&count)
+	return count
+}
+
+// normalizeHandoverCode drops the spaces and dashes people type between digits
+func normalizeHandoverCode(raw string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+}
+
+// HandoverQRCode serves the user's own handover code as a QR code. Scanning
+// it opens the post with the code filled in for the other side.
+func HandoverQRCode(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var item models.LostItem
+	if err := config.DB.First(&item, c.Param("pk")).Error; err != nil {
+		c.String(http.StatusNotFound, "Item not found")
+		return
+	}
+	side := handoverSide(&item, user.ID)
+	if side == "" {
+		c.String(http.StatusForbidden, "Not authorized")
+		return
+	}
+	code := activeHandoverCode(config.DB, &item, side)
+	if code == nil || item.Status != "LOST" {
+		c.String(http.StatusNotFound, "No active handover code")
+		return
+	}
+
+	png, err := utils.QRCodePNG(utils.HandoverURL(item.ID, code.Code), handoverQRSize)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render QR code")
+		return
+	}
+	// The code is a secret; never let a shared cache keep it
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// ConfirmReturn completes a return. Each side holds a one-time code: the
+// owner shows theirs once the item is back in their hands, the finder shows
+// theirs as they hand it over. The other side enters it (or scans its QR)
+// here, which marks the post returned and releases the bounty. Every entry is
+// logged and too many wrong ones revoke the code.
+func ConfirmReturn(c *gin.Context) {
+	itemID := c.Param("pk")
+	user := c.MustGet("user").(*models.User)
+
+	var item models.LostItem
+	if err := config.DB.First(&item, itemID).Error; err != nil {
+		c.String(http.StatusNotFound, "Item not found")
+		return
+	}
+	side := handoverSide(&item, user.ID)
+	if side == "" || item.FinderID == nil {
+		c.String(http.StatusForbidden, "Not authorized")
+		return
+	}
+	if item.Status != "LOST" {
+		c.Redirect(http.StatusFound, "/item/"+itemID)
+		return
+	}
+
+	entered := normalizeHandoverCode(c.PostForm("code"))
+	method := "code"
+	if c.PostForm("method") == "qr" {
+		method = "qr"
+	}
+	attempt := models.HandoverAttempt{
+		ItemID:  item.ID,
+		UserID:  user.ID,
+		Entered: entered,
+		Method:  method,
+		IPHash:  utils.HashIdentifier("ip:" + c.ClientIP()),
+	}
+	if len(attempt.Entered) > 20 {
+		attempt.Entered = attempt.Entered[:20]
+	}
+
+	// The user enters the code the other side shows
+	code := activeHandoverCode(config.DB, &item, otherHandoverSide(side))
+	if code == nil {
+		config.DB.Create(&attempt)
+		c.Redirect(http.StatusFound, "/item/"+itemID+"?handover=nocode#handover")
+		return
+	}
+	attempt.CodeID = &code.ID
+
+	if subtle.ConstantTimeCompare([]byte(entered), []byte(code.Code)) != 1 {
+		log.Printf("[handover] wrong code for item %d by user %d (%d/%d)", item.ID, user.ID, code.Failures+1, config.HandoverMaxFailures)
+		if err := recordHandoverFailure(&item, code, &attempt); err != nil {
+			log.Printf("[handover] record failure for item %d: %v", item.ID, err)
+		}
+		result := "wrong"
+		if code.RevokedAt != nil {
+			result = "revoked"
+			utils.NotificationHub.Publish(handoverHolder(&item, code.ShownBy))
+		}
+		c.Redirect(http.StatusFound, "/item/"+itemID+"?handover="+result+"#handover")
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := completeHandover(tx, &item, code, &attempt); err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to complete the return")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to complete the return")
+		return
+	}
+	utils.NotificationHub.Publish(handoverHolder(&item, code.ShownBy))
+	utils.QueueWebhook(config.DB, "item.returned", itemWebhookData(&item))
+
+	c.Redirect(http.StatusFound, "/item/"+itemID)
+}
+
+// recordHandoverFailure logs a wrong entry and revokes the code once the
+// other side has used up their attempts, telling its holder a new code is
+// waiting
+func recordHandoverFailure(item *models.LostItem, code *models.HandoverCode, attempt *models.HandoverAttempt) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		code.Failures++
+		updates := map[string]interface{}{"failures": code.Failures}
+		if code.Failures >= config.HandoverMaxFailures {
+			now := time.Now()
+			code.RevokedAt = &now
+			updates["revoked_at"] = now
+		}
+		if err := tx.Model(code).Updates(updates).Error; err != nil {
+			return err
+		}
+		if code.RevokedAt == nil {
+			return nil
+		}
+		return utils.Notify(tx, &models.Notification{
+			UserID:        handoverHolder(item, code.ShownBy),
+			Type:          "handover",
+			Title:         "Kode serah terima diblokir",
+			Message:       fmt.Sprintf("Kode serah terima '%s' salah dimasukkan %d kali dan sudah diganti. Buka postingan untuk melihat kode baru.", item.Title, code.Failures),
+			ReferenceURL:  fmt.Sprintf("/item/%d#handover", item.ID),
+			RelatedItemID: &item.ID,
+		})
+	})
+}
+
+// completeHandover marks the code used, the post returned and pays the bounty
+// to the finder, then tells the code's holder. Must run inside a transaction.
+func completeHandover(tx *gorm.DB, item *models.LostItem, code *models.HandoverCode, attempt *models.HandoverAttempt) error {
+	now := time.Now()
+	attempt.Success = true
+	if err := tx.Create(attempt).Error; err != nil {
+		return err
+	}
+	// Claim the code first so a double submit cannot pay the bounty twice
+	result := tx.Model(&models.HandoverCode{}).Where("id = ? AND used_at IS NULL", code.ID).Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errors.New("handover code already used")
+	}
+
+	if err := utils.TransitionItem(tx, item, utils.ItemReturned, &attempt.UserID, "Kode serah terima dimasukkan"); err != nil {
+		return err
+	}
+	item.OwnerConfirmed = true
+	item.FinderConfirmed = true
+	item.IsHighlighted = false
+	if err := tx.Model(item).Updates(map[string]interface{}{
+		"owner_confirmed":  true,
+		"finder_confirmed": true,
+		"is_highlighted":   false,
+	}).Error; err != nil {
+		return err
+	}
+
+	// Transfer Bounty
+	if item.BountyCoins > 0 {
+		var finder models.User
+		if err := tx.First(&finder, *item.FinderID).Error; err != nil {
+			return err
+		}
+		finder.CoinBalance += item.BountyCoins
+		if err := tx.Save(&finder).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.CoinTransaction{
+			UserID:          finder.ID,
+			Amount:          item.BountyCoins,
+			TransactionType: "bounty_reward",
+		}).Error; err != nil {
+			return err
+		}
+		// The bounty left the owner's balance when it was posted; record it
+		// leaving escrow and clear it so no later edit or removal refunds it
+		if err := tx.Create(&models.CoinTransaction{
+			UserID:          item.UserID,
+			Amount:          -item.BountyCoins,
+			TransactionType: "bounty_paid",
+		}).Error; err != nil {
+			return err
+		}
+		item.BountyCoins = 0
+		if err := tx.Model(item).Update("bounty_coins", 0).Error; err != nil {
+			return err
+		}
+	}
+
+	enteredBy := "Penemu"
+	if code.ShownBy == handoverFinder {
+		enteredBy = "Pemilik"
+	}
+	return utils.Notify(tx, &models.Notification{
+		UserID:        handoverHolder(item, code.ShownBy),
+		Type:          "handover",
+		Title:         "Serah terima selesai",
+		Message:       fmt.Sprintf("%s memasukkan kode serah terima untuk '%s'. Postingan ditandai sudah dikembalikan.", enteredBy, item.Title),
+		ReferenceURL:  fmt.Sprintf("/item/%d", item.ID),
+		RelatedItemID: &item.ID,
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"temuin/config"
 	"temuin/models"
@@ -10,32 +11,51 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// landingPageSize is how many posts the landing page shows
+const landingPageSize = 9
+
+// listItems fills items and listing with the posts matching the filter, all
+// through the shared listing service. Keyword searches sorted by relevance
+// are ranked through the search index and paged with a cursor; other sorts
+// page by number.
+func listItems(c *gin.Context, ctx pongo2.Context, filter utils.ListingFilter) {
+	if filter.Sort == "relevance" {
+		results, err := utils.SearchItems(config.DB, utils.SearchParams{Filter: filter, Cursor: c.Query("cursor")})
+		if err == nil {
+			ctx["items"] = results.Hits
+			ctx["listing"] = results.Listing
+			ctx["search_mode"] = true
+			ctx["next_cursor"] = results.NextCursor
+			ctx["cursor"] = c.Query("cursor")
+			return
+		}
+		log.Println("search failed:", err)
+		filter.Sort = "newest"
+	}
+
+	listing, err := utils.ListItems(config.DB, filter)
+	if err != nil {
+		log.Println("listing failed:", err)
+	}
+	ctx["items"] = listing.Items
+	ctx["listing"] = listing
+}
+
 func Home(c *gin.Context) {
 	// Fetch items
 
 	// Expire old highlights using utility function
 	utils.ExpireHighlights(config.DB)
 
-	var allItems []models.LostItem
-	query := config.DB.Preload("User").Order("created_at desc")
-
-	// Filtering
-	if q := c.Query("q"); q != "" {
-		// Search title OR description
-		query = query.Where("(title LIKE ? OR description LIKE ?)", "%"+q+"%", "%"+q+"%")
-	}
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
-	}
-	if loc := c.Query("location"); loc != "" {
-		query = query.Where("location LIKE ?", "%"+loc+"%")
-	}
+	ctx := utils.GetGlobalContext(c)
 
-	query.Find(&allItems)
+	filter := utils.ParseListingFilter(c)
+	filter.CountFacets = true
+	listItems(c, ctx, filter)
 
 	// Fetch highlighted items (max 3)
 	var highlightedItems []models.LostItem
-	config.DB.Preload("User").
+	config.DB.Preload("User").Scopes(utils.VisibleContent).
 		Where("is_highlighted = ?", true).
 		Order("highlight_expiry DESC").
 		Limit(3).
@@ -43,18 +63,17 @@ func Home(c *gin.Context) {
 
 	// Check if there are more highlights
 	var totalHighlights int64
-	config.DB.Model(&models.LostItem{}).
+	config.DB.Model(&models.LostItem{}).Scopes(utils.VisibleContent).
 		Where("is_highlighted = ?", true).
 		Count(&totalHighlights)
 	hasMoreHighlights := totalHighlights > 3
 
-	ctx := utils.GetGlobalContext(c)
-	ctx["items"] = allItems
 	ctx["pinned_items"] = highlightedItems
 	ctx["has_more_highlights"] = hasMoreHighlights
 	ctx["q"] = c.Query("q")
 	ctx["status"] = c.Query("status")
 	ctx["location"] = c.Query("location")
+	setPlaceFilter(ctx, c)
 
 	// User check for UI
 	if u, exists := c.Get("user"); exists {
@@ -87,30 +106,13 @@ func LandingPage(c *gin.Context) {
 
 	ctx := utils.GetGlobalContext(c)
 
-	// Fetch items from database with filtering
-	var items []models.LostItem
-	query := config.DB.Preload("User").Order("created_at desc")
-
-	// Apply filters (same logic as Home handler)
-	if q := c.Query("q"); q != "" {
-		// Search in title OR description
-		query = query.Where("(title LIKE ? OR description LIKE ?)", "%"+q+"%", "%"+q+"%")
-	}
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
-	}
-	if loc := c.Query("location"); loc != "" {
-		query = query.Where("location LIKE ?", "%"+loc+"%")
-	}
-
-	// Limit items for landing page (9 items)
-	query.Limit(9).Find(&items)
-
-	// DEBUG: Log the number of items fetched
-	println("DEBUG LandingPage: Fetched", len(items), "items from database")
+	// Landing page shows one short page; keyword searches are ranked and paginated
+	filter := utils.ParseListingFilter(c)
+	filter.PerPage = landingPageSize
+	filter.Page = 1
+	listItems(c, ctx, filter)
 
 	// Pass data to template
-	ctx["items"] = items
 	ctx["q"] = c.Query("q")
 	ctx["status"] = c.Query("status")
 	ctx["location"] = c.Query("location")
@@ -0,0 +1,544 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/storage"
+	"temuin/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// imageUpload is a processed photo from the form, not yet stored
+type imageUpload struct {
+	*utils.ProcessedImage
+}
+
+// DataURI embeds the photo in a re-rendered form so it survives a failed submit
+func (u imageUpload) DataURI() string {
+	return "data:" + u.ContentType + ";base64," + base64.StdEncoding.EncodeToString(u.Data)
+}
+
+// processUpload runs raw bytes through the image pipeline. Returns a
+// user-facing message on failure.
+func processUpload(name string, data []byte) (imageUpload, string) {
+	processed, err := utils.ProcessImage(data, config.ItemImageMaxDimension)
+	switch {
+	case errors.Is(err, utils.ErrImageFormat):
+		return imageUpload{}, fmt.Sprintf("Format %s tidak didukung (JPG, PNG, GIF, atau WEBP)", name)
+	case errors.Is(err, utils.ErrImageTooLarge):
+		return imageUpload{}, fmt.Sprintf("Resolusi %s terlalu besar", name)
+	case err != nil:
+		return imageUpload{}, "Gagal memproses foto"
+	}
+	return imageUpload{processed}, ""
+}
+
+// readImageFile reads and processes one uploaded photo
+func readImageFile(fh *multipart.FileHeader) (imageUpload, string) {
+	maxSize := int64(config.ItemImageMaxSizeMB) << 20
+	tooBig := fmt.Sprintf("Ukuran foto %s melebihi %d MB", fh.Filename, config.ItemImageMaxSizeMB)
+	if fh.Size > maxSize {
+		return imageUpload{}, tooBig
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		return imageUpload{}, "Gagal membaca foto"
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return imageUpload{}, "Gagal membaca foto"
+	}
+	if int64(len(data)) > maxSize {
+		return imageUpload{}, tooBig
+	}
+
+	// Trust the file contents, not the client-supplied name or header
+	return processUpload(fh.Filename, data)
+}
+
+// readImageUploads reads the files in a multipart field, in the order they
+// were picked
+func readImageUploads(c *gin.Context, field string) ([]imageUpload, string) {
+	form, err := c.MultipartForm()
+	if err != nil || form.File[field] == nil {
+		return nil, ""
+	}
+	var uploads []imageUpload
+	for _, fh := range form.File[field] {
+		upload, msg := readImageFile(fh)
+		if msg != "" {
+			return nil, msg
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, ""
+}
+
+// readPreviousImages decodes the photos a failed submit sent back as data
+// URIs. They come from the client, so they go through the pipeline again.
+func readPreviousImages(values []string) []imageUpload {
+	maxSize := config.ItemImageMaxSizeMB << 20
+	var uploads []imageUpload
+	for _, value := range values {
+		header, encoded, ok := strings.Cut(value, ",")
+		if !ok || !strings.HasPrefix(header, "data:") || base64.StdEncoding.DecodedLen(len(encoded)) > maxSize {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		if upload, msg := processUpload("foto", data); msg == "" {
+			uploads = append(uploads, upload)
+		}
+	}
+	return uploads
+}
+
+func tooManyImagesMessage() string {
+	return fmt.Sprintf("Maksimal %d foto per postingan", config.ItemMaxImages)
+}
+
+// itemImageSizes are the blobs stored for every photo, under its BlobKey
+var itemImageSizes = []string{"full", "detail", "thumb"}
+
+func itemImageBlobKey(base, size string) string {
+	return base + "/" + size + ".jpg"
+}
+
+// blobWrites tracks the blobs a request writes and replaces. Blobs are
+// written before the rows pointing at them, so a rollback leaves strays to
+// remove; replaced blobs are only removed once the rows have moved on.
+type blobWrites struct {
+	ctx     context.Context
+	created []string
+	stale   []string
+}
+
+func newBlobWrites(c *gin.Context) *blobWrites {
+	return &blobWrites{ctx: c.Request.Context()}
+}
+
+// putItemImage stores every size of a photo and returns its BlobKey
+func (w *blobWrites) putItemImage(itemID int64, upload imageUpload) (string, error) {
+	base := storage.NewKey("items/" + strconv.FormatInt(itemID, 10))
+	sizes := map[string][]byte{"full": upload.Data, "detail": upload.Detail, "thumb": upload.Thumb}
+	for _, size := range itemImageSizes {
+		key := itemImageBlobKey(base, size)
+		if err := storage.Default.Put(w.ctx, key, sizes[size], upload.ContentType); err != nil {
+			return "", err
+		}
+		w.created = append(w.created, key)
+	}
+	return base, nil
+}
+
+// putEvidence stores a report evidence image and returns its BlobKey.
+// Evidence is kept as uploaded, admins need the original.
+func (w *blobWrites) putEvidence(itemID int64, data []byte, contentType string) (string, error) {
+	key := storage.NewKey("evidence/" + strconv.FormatInt(itemID, 10))
+	if err := storage.Default.Put(w.ctx, key, data, contentType); err != nil {
+		return "", err
+	}
+	w.created = append(w.created, key)
+	return key, nil
+}
+
+// discard marks the blobs of a photo for removal on commit
+func (w *blobWrites) discard(image models.LostItemImage) {
+	w.stale = append(w.stale, itemBlobKeys(image)...)
+}
+
+// discardKeys marks stored blobs for removal on commit
+func (w *blobWrites) discardKeys(keys ...string) {
+	w.stale = append(w.stale, keys...)
+}
+
+func (w *blobWrites) rollback() {
+	storage.DeleteAll(w.ctx, storage.Default, w.created)
+}
+
+func (w *blobWrites) commit() {
+	storage.DeleteAll(w.ctx, storage.Default, w.stale)
+}
+
+// itemBlobKeys lists the stored sizes of a photo (none for legacy photos
+// whose bytes are still in the row)
+func itemBlobKeys(image models.LostItemImage) []string {
+	if image.BlobKey == "" {
+		return nil
+	}
+	keys := make([]string, len(itemImageSizes))
+	for i, size := range itemImageSizes {
+		keys[i] = itemImageBlobKey(image.BlobKey, size)
+	}
+	return keys
+}
+
+// createItemImages stores new photos after the existing ones and returns
+// their rows. The first photo of a post without a primary becomes its primary.
+func createItemImages(tx *gorm.DB, blobs *blobWrites, itemID int64, uploads []imageUpload, position int, hasPrimary bool) ([]models.LostItemImage, error) {
+	var created []models.LostItemImage
+	for _, upload := range uploads {
+		base, err := blobs.putItemImage(itemID, upload)
+		if err != nil {
+			return nil, err
+		}
+		position++
+		image := models.LostItemImage{
+			ItemID:      itemID,
+			Position:    position,
+			IsPrimary:   !hasPrimary,
+			BlobKey:     base,
+			ContentType: upload.ContentType,
+			ContentHash: upload.Hash,
+			PHash:       upload.PHash,
+			Width:       upload.Width,
+			Height:      upload.Height,
+		}
+		if err := tx.Create(&image).Error; err != nil {
+			return nil, err
+		}
+		created = append(created, image)
+		hasPrimary = true
+	}
+	return created, nil
+}
+
+// deleteImageMatches forgets the duplicate matches of a photo that is gone
+// or whose picture was replaced
+func deleteImageMatches(tx *gorm.DB, imageID int64) error {
+	return tx.Where("image_id = ? OR match_image_id = ?", imageID, imageID).Delete(&models.ImageMatch{}).Error
+}
+
+// itemImages lists a post's photos in gallery order, without their bytes
+func itemImages(db *gorm.DB, itemID int64) []models.LostItemImage {
+	var images []models.LostItemImage
+	db.Select("id", "item_id", "position", "is_primary", "blob_key", "content_type", "content_hash", "phash", "width", "height", "created_at").
+		Where("item_id = ?", itemID).
+		Order("position, id").
+		Find(&images)
+	return images
+}
+
+// imageEdit is what the edit form asks to change about a post's photos
+type imageEdit struct {
+	Deletes   map[int64]bool
+	Replaces  map[int64]imageUpload
+	Positions map[int64]int
+	PrimaryID int64
+	Added     []imageUpload
+}
+
+// readImageEdit reads the per-photo controls of the edit form: delete_image,
+// replace_<id>, position_<id> and primary_image, plus new files in "images"
+func readImageEdit(c *gin.Context, existing []models.LostItemImage) (*imageEdit, string) {
+	edit := &imageEdit{
+		Deletes:   make(map[int64]bool),
+		Replaces:  make(map[int64]imageUpload),
+		Positions: make(map[int64]int),
+	}
+
+	owned := make(map[int64]bool, len(existing))
+	for _, image := range existing {
+		owned[image.ID] = true
+	}
+	for _, value := range c.PostFormArray("delete_image") {
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil && owned[id] {
+			edit.Deletes[id] = true
+		}
+	}
+	if id, err := strconv.ParseInt(c.PostForm("primary_image"), 10, 64); err == nil && owned[id] {
+		edit.PrimaryID = id
+	}
+
+	for _, image := range existing {
+		key := strconv.FormatInt(image.ID, 10)
+		if position, err := strconv.Atoi(c.PostForm("position_" + key)); err == nil {
+			edit.Positions[image.ID] = position
+		}
+		if edit.Deletes[image.ID] {
+			continue
+		}
+		if fh, err := c.FormFile("replace_" + key); err == nil {
+			upload, msg := readImageFile(fh)
+			if msg != "" {
+				return nil, msg
+			}
+			edit.Replaces[image.ID] = upload
+		}
+	}
+
+	added, msg := readImageUploads(c, "images")
+	if msg != "" {
+		return nil, msg
+	}
+	edit.Added = added
+
+	if len(existing)-len(edit.Deletes)+len(edit.Added) > config.ItemMaxImages {
+		return nil, tooManyImagesMessage()
+	}
+	return edit, ""
+}
+
+// apply stores the edit and renumbers the gallery. Returns the photos whose
+// picture is new: replacements and additions.
+func (e *imageEdit) apply(tx *gorm.DB, blobs *blobWrites, itemID int64, existing []models.LostItemImage) ([]models.LostItemImage, error) {
+	var kept, changed []models.LostItemImage
+	for _, image := range existing {
+		if e.Deletes[image.ID] {
+			if err := tx.Delete(&models.LostItemImage{}, image.ID).Error; err != nil {
+				return nil, err
+			}
+			if err := deleteImageMatches(tx, image.ID); err != nil {
+				return nil, err
+			}
+			blobs.discard(image)
+			continue
+		}
+		if upload, ok := e.Replaces[image.ID]; ok {
+			base, err := blobs.putItemImage(itemID, upload)
+			if err != nil {
+				return nil, err
+			}
+			if err := tx.Model(&models.LostItemImage{}).Where("id = ?", image.ID).Updates(map[string]interface{}{
+				"blob_key":     base,
+				"image_data":   nil,
+				"detail_data":  nil,
+				"thumb_data":   nil,
+				"content_type": upload.ContentType,
+				"content_hash": upload.Hash,
+				"phash":        upload.PHash,
+				"width":        upload.Width,
+				"height":       upload.Height,
+			}).Error; err != nil {
+				return nil, err
+			}
+			if err := deleteImageMatches(tx, image.ID); err != nil {
+				return nil, err
+			}
+			blobs.discard(image)
+			image.BlobKey, image.ContentHash, image.PHash = base, upload.Hash, upload.PHash
+			changed = append(changed, image)
+		}
+		kept = append(kept, image)
+	}
+
+	// Photos without a requested position keep their place
+	sort.SliceStable(kept, func(i, j int) bool {
+		return e.position(kept[i]) < e.position(kept[j])
+	})
+
+	// Keep the current primary unless another was picked or it was deleted
+	primaryID := e.PrimaryID
+	if primaryID == 0 || e.Deletes[primaryID] {
+		primaryID = 0
+		for _, image := range kept {
+			if image.IsPrimary {
+				primaryID = image.ID
+			}
+		}
+	}
+	if primaryID == 0 && len(kept) > 0 {
+		primaryID = kept[0].ID
+	}
+
+	for i, image := range kept {
+		if err := tx.Model(&models.LostItemImage{}).Where("id = ?", image.ID).Updates(map[string]interface{}{
+			"position":   i + 1,
+			"is_primary": image.ID == primaryID,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	added, err := createItemImages(tx, blobs, itemID, e.Added, len(kept), primaryID != 0)
+	if err != nil {
+		return nil, err
+	}
+	return append(changed, added...), nil
+}
+
+func (e *imageEdit) position(image models.LostItemImage) int {
+	if position, ok := e.Positions[image.ID]; ok {
+		return position
+	}
+	return image.Position
+}
+
+// primaryImageVersion is what LostItem.Image holds: the content hash of the
+// primary photo, or "" when the post has none. Legacy photos without a hash
+// still mark the post as having a photo.
+func primaryImageVersion(db *gorm.DB, itemID int64) string {
+	var image models.LostItemImage
+	if err := db.Select("id", "content_hash").
+		Where("item_id = ?", itemID).
+		Order("is_primary DESC, position, id").
+		Take(&image).Error; err != nil {
+		return ""
+	}
+	if image.ContentHash == "" {
+		return "stored"
+	}
+	return image.ContentHash
+}
+
+// imageSizes maps the ?size= parameter to the legacy column holding that
+// size. Cards ask for "thumb", the post page for "detail"; no size is the
+// full photo.
+var imageSizes = map[string]string{
+	"thumb":  "thumb_data",
+	"detail": "detail_data",
+}
+
+const (
+	// Versioned URLs (?v=<content hash>) never change content
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	// Unversioned URLs may point at another photo later, so browsers revalidate
+	revalidateCacheControl = "public, no-cache"
+)
+
+// writeImage sends an image with its ETag, or 304 when the browser already
+// has it
+func writeImage(c *gin.Context, image *utils.CachedImage, cacheControl string) {
+	c.Header("Cache-Control", cacheControl)
+	c.Header("ETag", image.ETag)
+	if etagMatches(c.GetHeader("If-None-Match"), image.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, image.ContentType, image.Data)
+}
+
+// etagMatches checks an If-None-Match header against an ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveItemImage sends one size of the photo query finds. route names the
+// URL for the cache ("12:p" for the primary of post 12, "12:3" for its third
+// photo). Photos stored before thumbnails existed fall back to the full image.
+func serveItemImage(c *gin.Context, route string, query *gorm.DB) {
+	size := c.Query("size")
+	if _, ok := imageSizes[size]; !ok {
+		size = "full"
+	}
+
+	// The row is always looked up first, so a deleted, replaced or
+	// moderated photo is never served from the cache
+	var image models.LostItemImage
+	if err := query.Select("id", "blob_key", "content_type", "content_hash").Take(&image).Error; err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	// A versioned URL of the current photo always means the same bytes
+	version := c.Query("v")
+	versionKey := "photo:" + route + ":" + size + ":" + version
+	if version != "" && version == image.ContentHash {
+		if cached, ok := utils.ImageCache.Get(versionKey); ok {
+			writeImage(c, cached, immutableCacheControl)
+			return
+		}
+	}
+
+	var served *utils.CachedImage
+	if image.BlobKey != "" {
+		served = loadBlobImage(c, itemImageBlobKey(image.BlobKey, size))
+		if served == nil && size != "full" {
+			served = loadBlobImage(c, itemImageBlobKey(image.BlobKey, "full"))
+		}
+	} else {
+		served = loadLegacyImage(image, size)
+	}
+	if served == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	if version != "" && version == image.ContentHash {
+		utils.ImageCache.Add(versionKey, served)
+		writeImage(c, served, immutableCacheControl)
+		return
+	}
+	writeImage(c, served, revalidateCacheControl)
+}
+
+// loadBlobImage reads a stored blob through the cache. Storage keys are
+// never reused, so cached blobs never go stale.
+func loadBlobImage(c *gin.Context, key string) *utils.CachedImage {
+	if cached, ok := utils.ImageCache.Get("blob:" + key); ok {
+		return cached
+	}
+	obj, err := storage.Default.Get(c.Request.Context(), key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("[images] load %s: %v", key, err)
+		}
+		return nil
+	}
+	image := &utils.CachedImage{Data: obj.Data, ContentType: obj.ContentType, ETag: `"` + utils.ContentHash(obj.Data) + `"`}
+	utils.ImageCache.Add("blob:"+key, image)
+	return image
+}
+
+// loadLegacyImage reads a photo whose bytes are still in its row. These can
+// be rewritten in place by cmd/migrate, so they are not cached.
+func loadLegacyImage(image models.LostItemImage, size string) *utils.CachedImage {
+	column := "image_data"
+	if size != "full" {
+		column = imageSizes[size]
+	}
+	var row models.LostItemImage
+	if err := config.DB.Select(column+" AS image_data").Take(&row, image.ID).Error; err != nil {
+		return nil
+	}
+	if len(row.ImageData) == 0 && column != "image_data" {
+		if err := config.DB.Select("image_data").Take(&row, image.ID).Error; err != nil {
+			return nil
+		}
+	}
+	return &utils.CachedImage{Data: row.ImageData, ContentType: image.ContentType, ETag: `"` + utils.ContentHash(row.ImageData) + `"`}
+}
+
+// GetItemImage serves the primary photo of a post
+func GetItemImage(c *gin.Context) {
+	serveItemImage(c, c.Param("pk")+":p", config.DB.Model(&models.LostItemImage{}).
+		Where("item_id = ?", c.Param("pk")).
+		Order("is_primary DESC, position, id"))
+}
+
+// GetItemImageAt serves the nth photo of a post's gallery, counting from 1
+func GetItemImageAt(c *gin.Context) {
+	n, err := strconv.Atoi(c.Param("n"))
+	if err != nil || n < 1 {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	serveItemImage(c, c.Param("pk")+":"+strconv.Itoa(n), config.DB.Model(&models.LostItemImage{}).
+		Where("item_id = ?", c.Param("pk")).
+		Order("position, id").
+		Offset(n-1))
+}
@@ -1,10 +1,9 @@
 package handlers
 
 import (
-	"encoding/base64"
-	"io/ioutil"
+	"errors"
+	"log"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"temuin/config"
@@ -14,6 +13,7 @@ import (
 
 	"github.com/flosch/pongo2/v6"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func ReportItemPage(c *gin.Context) {
@@ -23,6 +23,9 @@ func ReportItemPage(c *gin.Context) {
 	var categories []models.SubCategory
 	config.DB.Find(&categories)
 	ctx["subcategories"] = categories
+	ctx["max_images"] = config.ItemMaxImages
+	ctx["attribute_schemas"] = attributeSchemasJSON()
+	ctx["attribute_values"] = "{}"
 
 	tpl, err := pongo2.FromFile("templates/core/report_item.html")
 	if err != nil {
@@ -40,9 +43,9 @@ func ReportItemPage(c *gin.Context) {
 func ReportItem(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 
-	// Check if user is banned
-	if user.IsBanned {
-		c.String(http.StatusForbidden, "Your account has been banned. You cannot create posts.")
+	// Check if user is banned or suspended
+	if utils.IsRestricted(user) {
+		c.String(http.StatusForbidden, "Your account has been banned or suspended. You cannot create posts.")
 		return
 	}
 
@@ -54,92 +57,96 @@ func ReportItem(c *gin.Context) {
 
 	bounty, _ := strconv.Atoi(bountyStr)
 	subCatID, _ := strconv.ParseInt(subCatIDStr, 10, 64)
-
-	// Handle Image Upload
-	fileHeader, err := c.FormFile("image")
-	var imageBytes []byte
-	var imageContentType string
-	var imagePathForTemplate string // Data URI or existing path
-
-	if err == nil {
-		// New file uploaded
-		file, _ := fileHeader.Open()
-		defer file.Close()
-		imageBytes, _ = ioutil.ReadAll(file)
-
-		// Determine content type
-		ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-		if ext == ".png" {
-			imageContentType = "image/png"
-		} else if ext == ".webp" {
-			imageContentType = "image/webp"
-		} else if ext == ".gif" {
-			imageContentType = "image/gif"
-		} else {
-			imageContentType = "image/jpeg"
+	latitude, longitude, coordErr := utils.ParseCoordinates(c.PostForm("latitude"), c.PostForm("longitude"))
+	place, placeOK := resolvePlace(c.PostForm("place_id"))
+	if place != nil && strings.TrimSpace(location) == "" {
+		location = place.FullName
+	}
+
+	// Photos: new uploads, or the ones kept from a failed submit
+	images, imageErr := readImageUploads(c, "images")
+	if imageErr == "" && len(images) == 0 {
+		images = readPreviousImages(c.PostFormArray("previous_images"))
+	}
+	if imageErr == "" && len(images) > config.ItemMaxImages {
+		imageErr = tooManyImagesMessage()
+	}
+	attributeFields := utils.SubCategoryFields(config.DB, subCatID)
+	attributes, attributeErr := readAttributes(c, attributeFields)
+
+	// Re-render the form with an error, keeping what the user entered
+	renderError := func(message string) {
+		var categories []models.SubCategory
+		config.DB.Find(&categories)
+
+		ctx := utils.GetGlobalContext(c)
+		ctx["subcategories"] = categories
+		ctx["error"] = message
+		ctx["title"] = title
+		ctx["description"] = desc
+		ctx["location"] = location
+		ctx["bounty_coins"] = bountyStr
+		ctx["latitude"] = c.PostForm("latitude")
+		ctx["longitude"] = c.PostForm("longitude")
+		if place != nil {
+			ctx["place_id"] = place.ID
+			ctx["place_name"] = place.FullName
 		}
-
-		// Create Data URI for preview
-		encoded := base64.StdEncoding.EncodeToString(imageBytes)
-		imagePathForTemplate = "data:" + imageContentType + ";base64," + encoded
-	} else {
-		// Fallback to previous image if exists (Data URI or DB path?)
-		// If it comes from "previous_image" hidden field, it's likely a Data URI.
-		imagePathForTemplate = c.PostForm("previous_image")
-		
-		// If we have a previous image string that is a Data URI, decode it to bytes
-		if strings.HasPrefix(imagePathForTemplate, "data:") {
-			parts := strings.Split(imagePathForTemplate, ",")
-			if len(parts) == 2 {
-				// extract content type
-				mimeParts := strings.Split(parts[0], ";")
-				if len(mimeParts) > 0 {
-					imageContentType = strings.TrimPrefix(mimeParts[0], "data:")
-				}
-				
-				decoded, err := base64.StdEncoding.DecodeString(parts[1])
-				if err == nil {
-					imageBytes = decoded
-				}
-			}
+		// Persist dropdowns
+		ctx["selected_category"] = c.PostForm("category")
+		ctx["selected_subcategory"] = subCatIDStr
+		// Persist photos (as data URIs)
+		previews := make([]string, len(images))
+		for i, image := range images {
+			previews[i] = image.DataURI()
 		}
-	}
-
-	// Logic: Check coin balance
-	if bounty > 0 {
-		if user.CoinBalance < bounty {
-			// Re-render template with error
-			var categories []models.SubCategory
-			config.DB.Find(&categories)
-
-			ctx := utils.GetGlobalContext(c)
-			ctx["subcategories"] = categories
-			ctx["error"] = "Saldo Coins Tidak Cukup!"
-			ctx["title"] = title
-			ctx["description"] = desc
-			ctx["location"] = location
-			ctx["bounty_coins"] = bountyStr
-			// Persist dropdowns
-			ctx["selected_category"] = c.PostForm("category")
-			ctx["selected_subcategory"] = subCatIDStr
-			// Persist image (pass Data URI)
-			ctx["image_path"] = imagePathForTemplate
-
-			tpl, err := pongo2.FromFile("templates/core/report_item.html")
-			if err != nil {
-				c.String(http.StatusInternalServerError, "Template Error: "+err.Error())
-				return
-			}
-			out, err := tpl.Execute(ctx)
-			if err != nil {
-				c.String(http.StatusInternalServerError, "Render Error: "+err.Error())
-				return
-			}
-			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
+		ctx["image_previews"] = previews
+		ctx["max_images"] = config.ItemMaxImages
+		ctx["attribute_schemas"] = attributeSchemasJSON()
+		ctx["attribute_values"] = attributeValuesJSON(submittedAttributes(c))
+
+		tpl, err := pongo2.FromFile("templates/core/report_item.html")
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Template Error: "+err.Error())
 			return
 		}
-		user.CoinBalance -= bounty
-		config.DB.Save(user)
+		out, err := tpl.Execute(ctx)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Render Error: "+err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
+	}
+
+	if imageErr != "" {
+		images = nil
+		renderError(imageErr)
+		return
+	}
+	if coordErr != nil {
+		renderError("Titik lokasi di peta tidak valid")
+		return
+	}
+	if !placeOK {
+		renderError("Tempat yang dipilih tidak ditemukan")
+		return
+	}
+	if attributeErr != "" {
+		renderError(attributeErr)
+		return
+	}
+
+	// Content rules run before any coins are taken
+	moderation := utils.CheckContent(config.DB, "items", append([]string{title, desc, location}, publicAttributeTexts(attributeFields, attributes)...)...)
+	if moderation.Blocked() {
+		renderError(blockedContentMessage(moderation))
+		return
+	}
+
+	// Logic: Check coin balance (deducted together with the post below)
+	if bounty > 0 && user.CoinBalance < bounty {
+		renderError("Saldo Coins Tidak Cukup!")
+		return
 	}
 
 	// Fetch Category ID from SubCategory
@@ -149,10 +156,10 @@ func ReportItem(c *gin.Context) {
 		catID = subCat.CategoryID
 	}
 
-	// Determine generic status for 'Image' field (just a flag now)
+	// 'Image' versions the primary photo's URL (and flags that there is one)
 	imageFlag := ""
-	if len(imageBytes) > 0 {
-		imageFlag = "stored"
+	if len(images) > 0 {
+		imageFlag = images[0].Hash
 	}
 
 	item := models.LostItem{
@@ -163,21 +170,79 @@ func ReportItem(c *gin.Context) {
 		UserID:        user.ID,
 		Image:         imageFlag, // Just a flag, or we can leave it empty and check LostItemImage table. But 'imageFlag' is useful for quick checks.
 		Status:        "LOST",
+		State:         utils.ItemOpen,
 		SubCategoryID: &subCatID,
 		CategoryID:    catID,
+		Latitude:      latitude,
+		Longitude:     longitude,
+		PlaceID:       placeIDOf(place),
 	}
 
-	config.DB.Create(&item)
-	
-	// Create Image Record
-	if len(imageBytes) > 0 {
-		imgRec := models.LostItemImage{
-			ItemID:      item.ID,
-			ImageData:   imageBytes,
-			ContentType: imageContentType,
+	// The post, its bounty and its moderation hold are saved together, so a
+	// post meant to be held is never public
+	postTx := config.DB.Begin()
+	if bounty > 0 {
+		user.CoinBalance -= bounty
+		if err := postTx.Save(user).Error; err != nil {
+			postTx.Rollback()
+			renderError("Gagal menyimpan postingan")
+			return
 		}
-		config.DB.Create(&imgRec)
 	}
+	if err := postTx.Create(&item).Error; err != nil {
+		postTx.Rollback()
+		renderError("Gagal menyimpan postingan")
+		return
+	}
+	// Attributes and search terms too, so the index always matches the post
+	if err := saveItemAttributes(postTx, item.ID, attributes); err != nil {
+		postTx.Rollback()
+		log.Printf("[attributes] save new item: %v", err)
+		renderError("Gagal menyimpan postingan")
+		return
+	}
+	if err := utils.IndexItem(postTx, &item); err != nil {
+		postTx.Rollback()
+		log.Printf("[search] index new item: %v", err)
+		renderError("Gagal menyimpan postingan")
+		return
+	}
+	admins, err := applyModeration(postTx, moderation, &item, nil)
+	if err != nil {
+		postTx.Rollback()
+		log.Printf("[moderation] apply rules to new item: %v", err)
+		renderError("Gagal menyimpan postingan")
+		return
+	}
+	if err := postTx.Commit().Error; err != nil {
+		renderError("Gagal menyimpan postingan")
+		return
+	}
+	utils.NotificationHub.Publish(admins...)
+
+	// Create Image Records, the first photo is the primary
+	blobs := newBlobWrites(c)
+	tx := config.DB.Begin()
+	storedImages, err := createItemImages(tx, blobs, item.ID, images, 0, false)
+	if err != nil {
+		tx.Rollback()
+		blobs.rollback()
+		log.Printf("[images] store photos of item %d: %v", item.ID, err)
+		config.DB.Model(&item).Update("image", "")
+	} else {
+		tx.Commit()
+	}
+	if err := flagDuplicatePhotos(config.DB, &item, storedImages); err != nil {
+		log.Printf("[images] compare photos of item %d: %v", item.ID, err)
+	}
+
+	// Held posts stay private, so send the owner to the post to see its status
+	if moderation.Held() {
+		c.Redirect(http.StatusFound, "/item/"+strconv.FormatInt(item.ID, 10))
+		return
+	}
+	utils.QueueWebhook(config.DB, "item.created", itemWebhookData(&item))
+	go notifySavedSearches(item)
 
 	c.Redirect(http.StatusFound, "/dashboard")
 }
@@ -191,9 +256,58 @@ func ItemDetail(c *gin.Context) {
 		return
 	}
 
+	var viewer *models.User
+	if u, exists := c.Get("user"); exists {
+		viewer = u.(*models.User)
+	}
+	isAdmin := viewer != nil && viewer.IsSuperuser
+
+	// Held posts are only visible to their owner and admins until reviewed
+	if item.IsHeld && !isAdmin && (viewer == nil || viewer.ID != item.UserID) {
+		c.String(http.StatusNotFound, "Item not found")
+		return
+	}
+
+	// Held comments are only visible to their author and admins
+	comments := make([]models.Comment, 0, len(item.Comments))
+	for _, comment := range item.Comments {
+		if !comment.IsHeld || isAdmin || (viewer != nil && viewer.ID == comment.UserID) {
+			comments = append(comments, comment)
+		}
+	}
+
+	// Only the owner and the selected finder see the exact pin; everyone else
+	// gets the surrounding area
+	var mapPin map[string]interface{}
+	if item.Latitude != nil && item.Longitude != nil {
+		exact := utils.CanSeeExactLocation(&item, viewer)
+		lat, lng := *item.Latitude, *item.Longitude
+		if !exact {
+			lat, lng = utils.ApproximateCoordinate(lat), utils.ApproximateCoordinate(lng)
+			item.Latitude, item.Longitude = nil, nil
+		}
+		mapPin = map[string]interface{}{"lat": lat, "lng": lng, "exact": exact}
+	}
+
+	// Private attributes are for the owner (and admins) only
+	isOwner := viewer != nil && viewer.ID == item.UserID
+
 	ctx := utils.GetGlobalContext(c)
 	ctx["item"] = item
-	ctx["comments"] = item.Comments
+	ctx["images"] = itemImages(config.DB, item.ID)
+	ctx["attributes"] = utils.ItemAttributeValues(config.DB, item.ID, isOwner || isAdmin)
+	ctx["comments"] = comments
+	ctx["map_pin"] = mapPin
+	ctx["evidence_max_files"] = config.EvidenceMaxFiles
+	ctx["evidence_max_size_mb"] = config.EvidenceMaxSizeMB
+
+	// Lifecycle timeline; who moved the post and why is for the two sides and admins
+	var history []models.ItemHistory
+	config.DB.Preload("Actor").Where("item_id = ?", item.ID).Order("created_at ASC, id ASC").Find(&history)
+	ctx["history"] = history
+	ctx["history_details"] = isOwner || isAdmin || (viewer != nil && item.FinderID != nil && *item.FinderID == viewer.ID)
+	ctx["state_labels"] = utils.ItemStateLabels
+	ctx["lifecycle_error"] = c.Query("lifecycle")
 
 	// User for template logic
 	if u, exists := c.Get("user"); exists {
@@ -203,11 +317,51 @@ func ItemDetail(c *gin.Context) {
 		ctx["is_owner"] = (item.UserID == user.ID)
 		ctx["is_finder"] = (item.FinderID != nil && *item.FinderID == user.ID)
 
-		// Logic: If Owner, fetch claims
+		// Handover: each side holds a code and enters the other side's
+		if side := handoverSide(&item, user.ID); side != "" && item.FinderID != nil && item.Status == "LOST" {
+			if code, err := issueHandoverCode(config.DB, &item, side); err == nil {
+				ctx["handover_code"] = code
+			} else {
+				log.Printf("[handover] issue code for item %d: %v", item.ID, err)
+			}
+			ctx["handover_failures"] = handoverFailures(config.DB, item.ID)
+			ctx["handover_prefill"] = c.Query("code")
+			ctx["handover_error"] = c.Query("handover")
+			if code := activeHandoverCode(config.DB, &item, otherHandoverSide(side)); code != nil {
+				ctx["handover_attempts_left"] = config.HandoverMaxFailures - code.Failures
+			}
+		}
+
+		// Meetups with the other side of the handover and how they behaved before
+		if counterpart := meetupCounterpart(&item, user.ID); counterpart != 0 {
+			ctx["meetups"] = itemMeetups(config.DB, &item, user.ID)
+			ctx["meetup_status_labels"] = meetupStatusLabels
+			ctx["meetup_error"] = c.Query("meetup")
+			ctx["meetup_places"] = meetupPlaceSuggestions(config.DB)
+			ctx["meetup_slots"] = make([]struct{}, maxMeetupSlots)
+			ctx["meetup_min"] = time.Now().Format(meetupInputLayout)
+			ctx["counterpart_reputation"] = utils.UserReputation(config.DB, counterpart)
+		}
+
+		// Logic: If Owner, fetch claims with how each description compares
 		if item.UserID == user.ID {
 			var claims []models.ItemClaim
 			config.DB.Preload("User").Where("item_id = ?", item.ID).Find(&claims)
-			ctx["claims"] = claims
+			claimantIDs := make([]int64, len(claims))
+			for i, claim := range claims {
+				claimantIDs[i] = claim.UserID
+			}
+			reputations := utils.Reputations(config.DB, claimantIDs)
+			views := make([]ClaimView, len(claims))
+			for i, claim := range claims {
+				views[i].ItemClaim = claim
+				views[i].Signals, views[i].Matched = utils.ClaimSignals(config.DB, item.ID, claim.ID)
+				views[i].Reputation = reputations[claim.UserID]
+			}
+			ctx["claims"] = views
+		} else if item.SubCategoryID != nil {
+			// Claimants may describe the item to back up their claim
+			ctx["claim_fields"] = claimFields(config.DB, *item.SubCategoryID)
 		}
 
 		// Logic: Check if current user has claimed
@@ -234,21 +388,51 @@ func PostComment(c *gin.Context) {
 	content := c.PostForm("content")
 	user := c.MustGet("user").(*models.User)
 
-	// Check if user is banned
-	if user.IsBanned {
-		c.String(http.StatusForbidden, "Your account has been banned. You cannot comment.")
+	// Check if user is banned or suspended
+	if utils.IsRestricted(user) {
+		c.String(http.StatusForbidden, "Your account has been banned or suspended. You cannot comment.")
 		return
 	}
 
 	iid, _ := strconv.ParseInt(itemID, 10, 64)
 
+	var item models.LostItem
+	if err := config.DB.First(&item, iid).Error; err != nil {
+		c.String(http.StatusNotFound, "Item not found")
+		return
+	}
+
+	moderation := utils.CheckContent(config.DB, "comments", content)
+	if moderation.Blocked() {
+		c.String(http.StatusBadRequest, blockedContentMessage(moderation))
+		return
+	}
+
 	comment := models.Comment{
 		Content: content,
 		UserID:  user.ID,
 		ItemID:  iid,
 	}
 
-	config.DB.Create(&comment)
+	// Created and held together, so a comment meant to be held is never public
+	tx := config.DB.Begin()
+	if err := tx.Create(&comment).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to post comment")
+		return
+	}
+	admins, err := applyModeration(tx, moderation, &item, &comment)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("[moderation] apply rules to comment on item %d: %v", item.ID, err)
+		c.String(http.StatusInternalServerError, "Failed to post comment")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to post comment")
+		return
+	}
+	utils.NotificationHub.Publish(admins...)
 	c.Redirect(http.StatusFound, "/item/"+itemID)
 }
 
@@ -274,7 +458,12 @@ func MarkAsFound(c *gin.Context) {
 				ItemID: item.ID,
 				UserID: user.ID,
 			}
-			config.DB.Create(&claim)
+			if config.DB.Create(&claim).Error == nil {
+				if err := saveClaimAttributes(c, config.DB, &item, claim.ID); err != nil {
+					log.Printf("[attributes] save claim %d: %v", claim.ID, err)
+				}
+				utils.QueueWebhook(config.DB, "claim.created", claimWebhookData(&claim))
+			}
 		}
 	}
 
@@ -299,58 +488,41 @@ func SelectFinder(c *gin.Context) {
 	}
 
 	uid, _ := strconv.ParseInt(targetUserID, 10, 64)
-	item.FinderID = &uid
-	item.FinderConfirmed = false // Reset confirmation to force mutual check
-	item.OwnerConfirmed = false
-	config.DB.Save(&item)
-
-	c.Redirect(http.StatusFound, "/item/"+itemID)
-}
-
-// ConfirmReturn handles mutual confirmation. Both Owner and Finder must confirm.
-func ConfirmReturn(c *gin.Context) {
-	itemID := c.Param("pk")
-	user := c.MustGet("user").(*models.User)
-
-	var item models.LostItem
-	if err := config.DB.Preload("Finder").First(&item, itemID).Error; err != nil {
-		c.String(http.StatusNotFound, "Item not found")
+	var claim models.ItemClaim
+	if err := config.DB.Preload("User").Where("item_id = ? AND user_id = ?", item.ID, uid).First(&claim).Error; err != nil {
+		c.String(http.StatusBadRequest, "Candidate has not claimed this item")
 		return
 	}
-
-	// Check authorization (Owner or Finder)
-	isOwner := (item.UserID == user.ID)
-	isFinder := (item.FinderID != nil && *item.FinderID == user.ID)
-
-	if !isOwner && !isFinder {
-		c.String(http.StatusForbidden, "Not authorized")
+	if item.FinderID != nil && *item.FinderID == uid {
+		c.Redirect(http.StatusFound, "/item/"+itemID)
 		return
 	}
 
-	// Update confirmation status
-	if isOwner {
-		item.OwnerConfirmed = true
-		// User Logic: "Harusnya penemu tidak perlu konfirmasi lagi ketika pemilik sudah konfirmasi"
-		// Meaning: Owner's confirmation is the Final Say (since they pay).
-		// So if Owner accepts, we auto-confirm the Finder side (assuming they gave it).
-		item.FinderConfirmed = true
-	} else if isFinder {
-		item.FinderConfirmed = true
-	}
-	config.DB.Save(&item)
-
-	// Check if BOTH confirmed (Now Owner creates this state immediately)
-	// So, if Owner is confirming now, AND FinderConfirmed is true -> DEAL DONE.
-	if item.FinderConfirmed && item.OwnerConfirmed && item.Status == "LOST" {
-		item.Status = "FOUND"      // Terminal state
-		item.IsHighlighted = false // Remove highlight
-		config.DB.Save(&item)
-
-		// Transfer Bounty
-		if item.BountyCoins > 0 && item.Finder != nil {
-			item.Finder.CoinBalance += item.BountyCoins
-			config.DB.Save(item.Finder)
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// A code shown to the previous finder must not complete the return,
+		// and meetups arranged with them are off
+		if err := revokeHandoverCodes(tx, item.ID); err != nil {
+			return err
+		}
+		if err := cancelMeetups(tx, item.ID); err != nil {
+			return err
 		}
+		if err := utils.TransitionItem(tx, &item, utils.ItemFinderSelected, &user.ID, "Penemu: "+claim.User.Username); err != nil {
+			return err
+		}
+		return tx.Model(&item).Updates(map[string]interface{}{
+			"finder_id":        uid,
+			"finder_confirmed": false, // Reset confirmation to force mutual check
+			"owner_confirmed":  false,
+		}).Error
+	})
+	if errors.Is(err, utils.ErrInvalidTransition) {
+		c.String(http.StatusConflict, "Finder can no longer be changed")
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to select finder")
+		return
 	}
 
 	c.Redirect(http.StatusFound, "/item/"+itemID)
@@ -399,7 +571,7 @@ func EditItemPage(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 
 	var item models.LostItem
-	if err := config.DB.Preload("User").First(&item, itemID).Error; err != nil {
+	if err := config.DB.Preload("User").Preload("Place").First(&item, itemID).Error; err != nil {
 		c.String(http.StatusNotFound, "Item not found")
 		return
 	}
@@ -417,6 +589,18 @@ func EditItemPage(c *gin.Context) {
 	ctx := utils.GetGlobalContext(c)
 	ctx["item"] = item
 	ctx["subcategories"] = subcategories
+	ctx["images"] = itemImages(config.DB, item.ID)
+	ctx["max_images"] = config.ItemMaxImages
+	ctx["attribute_schemas"] = attributeSchemasJSON()
+	ctx["attribute_values"] = attributeValuesJSON(storedAttributes(config.DB, item.ID))
+	if item.Latitude != nil && item.Longitude != nil {
+		ctx["latitude"] = strconv.FormatFloat(*item.Latitude, 'f', -1, 64)
+		ctx["longitude"] = strconv.FormatFloat(*item.Longitude, 'f', -1, 64)
+	}
+	if item.Place != nil {
+		ctx["place_id"] = item.Place.ID
+		ctx["place_name"] = item.Place.FullName
+	}
 
 	tpl, err := pongo2.FromFile("templates/core/edit_item.html")
 	if err != nil {
@@ -436,9 +620,9 @@ func EditItem(c *gin.Context) {
 	itemID := c.Param("pk")
 	user := c.MustGet("user").(*models.User)
 
-	// Check if user is banned
-	if user.IsBanned {
-		c.String(http.StatusForbidden, "Your account has been banned. You cannot edit posts.")
+	// Check if user is banned or suspended
+	if utils.IsRestricted(user) {
+		c.String(http.StatusForbidden, "Your account has been banned or suspended. You cannot edit posts.")
 		return
 	}
 
@@ -463,80 +647,87 @@ func EditItem(c *gin.Context) {
 
 	bounty, _ := strconv.Atoi(bountyStr)
 	subCatID, _ := strconv.ParseInt(subCatIDStr, 10, 64)
+	latitude, longitude, coordErr := utils.ParseCoordinates(c.PostForm("latitude"), c.PostForm("longitude"))
+	place, placeOK := resolvePlace(c.PostForm("place_id"))
+	if place != nil && strings.TrimSpace(location) == "" {
+		location = place.FullName
+	}
+	existingImages := itemImages(config.DB, item.ID)
+	imageChanges, imageErr := readImageEdit(c, existingImages)
+	attributeFields := utils.SubCategoryFields(config.DB, subCatID)
+	attributes, attributeErr := readAttributes(c, attributeFields)
+
+	// Re-render the form with an error
+	renderError := func(message string) {
+		var subcategories []models.SubCategory
+		config.DB.Find(&subcategories)
+
+		ctx := utils.GetGlobalContext(c)
+		ctx["item"] = item
+		ctx["subcategories"] = subcategories
+		ctx["error"] = message
+		ctx["images"] = existingImages
+		ctx["max_images"] = config.ItemMaxImages
+		ctx["attribute_schemas"] = attributeSchemasJSON()
+		ctx["attribute_values"] = attributeValuesJSON(submittedAttributes(c))
+		ctx["latitude"] = c.PostForm("latitude")
+		ctx["longitude"] = c.PostForm("longitude")
+		if place != nil {
+			ctx["place_id"] = place.ID
+			ctx["place_name"] = place.FullName
+		}
+
+		tpl, err := pongo2.FromFile("templates/core/edit_item.html")
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Template Error: "+err.Error())
+			return
+		}
+		out, err := tpl.Execute(ctx)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Render Error: "+err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
+	}
+
+	if imageErr != "" {
+		renderError(imageErr)
+		return
+	}
+	if coordErr != nil {
+		renderError("Titik lokasi di peta tidak valid")
+		return
+	}
+	if !placeOK {
+		renderError("Tempat yang dipilih tidak ditemukan")
+		return
+	}
+	if attributeErr != "" {
+		renderError(attributeErr)
+		return
+	}
+
+	// Content rules run before any coins are moved
+	moderation := utils.CheckContent(config.DB, "items", append([]string{title, desc, location}, publicAttributeTexts(attributeFields, attributes)...)...)
+	if moderation.Blocked() {
+		renderError(blockedContentMessage(moderation))
+		return
+	}
 
 	// Calculate bounty difference
 	oldBounty := item.BountyCoins
 	bountyDiff := bounty - oldBounty
 
-	// Validate and adjust coin balance if bounty changed
-	if bountyDiff > 0 {
-		// Bounty increased - check if user has sufficient balance
-		if user.CoinBalance < bountyDiff {
-			// Re-render template with error
-			var subcategories []models.SubCategory
-			config.DB.Find(&subcategories)
-
-			ctx := utils.GetGlobalContext(c)
-			ctx["item"] = item
-			ctx["subcategories"] = subcategories
-			ctx["error"] = "Saldo Coins Tidak Cukup! Anda memerlukan " + strconv.Itoa(bountyDiff) + " coins tambahan."
-
-			tpl, err := pongo2.FromFile("templates/core/edit_item.html")
-			if err != nil {
-				c.String(http.StatusInternalServerError, "Template Error: "+err.Error())
-				return
-			}
-			out, err := tpl.Execute(ctx)
-			if err != nil {
-				c.String(http.StatusInternalServerError, "Render Error: "+err.Error())
-				return
-			}
-			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
-			return
-		}
-		// Deduct the difference
-		user.CoinBalance -= bountyDiff
-		config.DB.Save(user)
-	} else if bountyDiff < 0 {
-		// Bounty decreased - refund the difference
-		user.CoinBalance += (-bountyDiff)
-		config.DB.Save(user)
-	}
-	// If bountyDiff == 0, no coin adjustment needed
-
-	// Handle Image Upload (if new image provided)
-	fileHeader, err := c.FormFile("image")
-	if err == nil {
-		file, _ := fileHeader.Open()
-		defer file.Close()
-		data, _ := ioutil.ReadAll(file)
-
-		ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-		contentType := "image/jpeg"
-		if ext == ".png" {
-			contentType = "image/png"
-		} else if ext == ".webp" {
-			contentType = "image/webp"
-		} else if ext == ".gif" {
-			contentType = "image/gif"
-		}
+	// The bounty is settled once the post is returned, closed or expired
+	if bountyDiff != 0 && !utils.ItemActive(item.State) {
+		renderError("Bounty hanya dapat diubah selama postingan masih aktif")
+		return
+	}
 
-		var imgRec models.LostItemImage
-		if err := config.DB.First(&imgRec, item.ID).Error; err == nil {
-			// Update
-			imgRec.ImageData = data
-			imgRec.ContentType = contentType
-			config.DB.Save(&imgRec)
-		} else {
-			// Create
-			imgRec = models.LostItemImage{
-				ItemID:      item.ID,
-				ImageData:   data,
-				ContentType: contentType,
-			}
-			config.DB.Create(&imgRec)
-		}
-		item.Image = "stored"
+	// Bounty increased - check if user has sufficient balance
+	if bountyDiff > 0 && user.CoinBalance < bountyDiff {
+		renderError("Saldo Coins Tidak Cukup! Anda memerlukan " + strconv.Itoa(bountyDiff) + " coins tambahan.")
+		return
 	}
 
 	// Fetch Category ID from SubCategory
@@ -546,6 +737,41 @@ func EditItem(c *gin.Context) {
 		catID = subCat.CategoryID
 	}
 
+	// Coins, photos, the edit and its moderation hold are saved together, so
+	// a failure leaves neither the balance nor the post half changed
+	blobs := newBlobWrites(c)
+	tx := config.DB.Begin()
+	fail := func(message string) {
+		tx.Rollback()
+		blobs.rollback()
+		c.String(http.StatusInternalServerError, message)
+	}
+
+	// Deduct an increase, refund a decrease
+	if bountyDiff != 0 {
+		user.CoinBalance -= bountyDiff
+		if err := tx.Model(user).Update("coin_balance", user.CoinBalance).Error; err != nil {
+			fail("Failed to update coin balance")
+			return
+		}
+		if err := tx.Create(&models.CoinTransaction{
+			UserID:          user.ID,
+			Amount:          -bountyDiff,
+			TransactionType: "bounty_change",
+		}).Error; err != nil {
+			fail("Failed to update coin balance")
+			return
+		}
+	}
+
+	// Apply photo deletes, replacements, order and new uploads
+	changedImages, err := imageChanges.apply(tx, blobs, item.ID, existingImages)
+	if err != nil {
+		fail("Failed to update photos")
+		return
+	}
+	item.Image = primaryImageVersion(tx, item.ID)
+
 	// Update item fields
 	item.Title = title
 	item.Description = desc
@@ -553,8 +779,63 @@ func EditItem(c *gin.Context) {
 	item.BountyCoins = bounty
 	item.SubCategoryID = &subCatID
 	item.CategoryID = catID
-
-	config.DB.Save(&item)
+	item.Latitude = latitude
+	item.Longitude = longitude
+	item.PlaceID = placeIDOf(place)
+
+	// Only the edited columns are written, so a handover, finder choice or
+	// expiry that ran meanwhile keeps its state. A bounty change also
+	// requires the bounty and state the coins were moved for.
+	update := tx.Model(&models.LostItem{}).Where("id = ?", item.ID)
+	if bountyDiff != 0 {
+		update = update.Where("bounty_coins = ? AND state IN ?", oldBounty, utils.ActiveItemStates)
+	}
+	result := update.Updates(map[string]interface{}{
+		"title":          item.Title,
+		"description":    item.Description,
+		"location":       item.Location,
+		"bounty_coins":   item.BountyCoins,
+		"subcategory_id": item.SubCategoryID,
+		"category_id":    item.CategoryID,
+		"latitude":       item.Latitude,
+		"longitude":      item.Longitude,
+		"place_id":       item.PlaceID,
+		"image":          item.Image,
+	})
+	if result.Error != nil {
+		fail("Failed to update item")
+		return
+	}
+	if bountyDiff != 0 && result.RowsAffected != 1 {
+		fail("Postingan berubah saat disunting, silakan coba lagi")
+		return
+	}
+	if err := saveItemAttributes(tx, item.ID, attributes); err != nil {
+		log.Printf("[attributes] save item %d: %v", item.ID, err)
+		fail("Failed to update item")
+		return
+	}
+	if err := utils.IndexItem(tx, &item); err != nil {
+		log.Printf("[search] index item %d: %v", item.ID, err)
+		fail("Failed to update item")
+		return
+	}
+	admins, err := applyModeration(tx, moderation, &item, nil)
+	if err != nil {
+		log.Printf("[moderation] apply rules to item %d: %v", item.ID, err)
+		fail("Failed to update item")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		blobs.rollback()
+		c.String(http.StatusInternalServerError, "Failed to update item")
+		return
+	}
+	blobs.commit()
+	utils.NotificationHub.Publish(admins...)
+	if err := flagDuplicatePhotos(config.DB, &item, changedImages); err != nil {
+		log.Printf("[images] compare photos of item %d: %v", item.ID, err)
+	}
 	c.Redirect(http.StatusFound, "/item/"+itemID)
 }
 
@@ -577,62 +858,97 @@ func DeleteItem(c *gin.Context) {
 
 	// Use transaction to ensure full cleanup (Manual Cascade)
 	tx := config.DB.Begin()
-
-	// 1. Delete Image
-	if err := tx.Where("item_id = ?", item.ID).Delete(&models.LostItemImage{}).Error; err != nil {
+	blobs := newBlobWrites(c)
+	if err := removeItem(tx, blobs, &item, &user.ID, "Dihapus pemilik", "delete_refund"); err != nil {
 		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete item image")
+		c.String(http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	tx.Commit()
+	blobs.commit()
+
+	c.Redirect(http.StatusFound, "/dashboard")
+}
+
+// removeItem refunds the bounty of a still active post to its owner and
+// deletes the post with everything that references it, keeping its history.
+// Returned posts already paid their bounty to the finder. Used by both the
+// owner's and the admins' delete; stored blobs go once the caller commits.
+// Must run inside a transaction.
+func removeItem(tx *gorm.DB, blobs *blobWrites, item *models.LostItem, actorID *int64, note, refundType string) error {
+	active := utils.ItemActive(item.State)
+	if err := utils.TransitionItem(tx, item, utils.ItemRemoved, actorID, note); err != nil {
+		return errors.New("Failed to record removal")
+	}
+
+	// REFUND LOGIC: If item has bounty, refund to owner
+	if active {
+		if err := utils.RefundBounty(tx, item, refundType); err != nil {
+			return errors.New("Failed to refund coins to owner")
+		}
+	}
+
+	// 1. Delete Image (stored blobs go once the caller commits)
+	for _, image := range itemImages(tx, item.ID) {
+		blobs.discard(image)
+	}
+	if err := tx.Where("item_id = ?", item.ID).Delete(&models.LostItemImage{}).Error; err != nil {
+		return errors.New("Failed to delete item image")
+	}
+	if err := tx.Where("item_id = ? OR match_item_id = ?", item.ID, item.ID).Delete(&models.ImageMatch{}).Error; err != nil {
+		return errors.New("Failed to delete photo matches")
+	}
+
 	// 2. Delete Comments
 	if err := tx.Where("item_id = ?", item.ID).Delete(&models.Comment{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete comments")
-		return
+		return errors.New("Failed to delete comments")
 	}
 
-	// 3. Delete Claims
+	// 3. Delete Claims (and the attributes of the post and its claims)
+	if err := deleteItemAttributes(tx, item.ID); err != nil {
+		return errors.New("Failed to delete attributes")
+	}
 	if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemClaim{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete claims")
-		return
+		return errors.New("Failed to delete claims")
 	}
-
-	// 4. Delete Reports
-	if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemReport{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete reports")
-		return
+	if err := unlinkFoundReports(tx, item.ID); err != nil {
+		return errors.New("Failed to unlink found reports")
+	}
+	if err := deleteHandover(tx, item.ID); err != nil {
+		return errors.New("Failed to delete handover codes")
+	}
+	if err := deleteMeetups(tx, item.ID); err != nil {
+		return errors.New("Failed to delete meetups")
 	}
 
-	// 5. Delete Notifications linked to this item
-	if err := tx.Where("related_item_id = ?", item.ID).Delete(&models.Notification{}).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete notifications")
-		return
+	// 3b. Delete private conversations about the item
+	if err := deleteConversations(tx, item.ID); err != nil {
+		return errors.New("Failed to delete conversations")
 	}
 
-	// 6. Finally, Delete the Item
-	if err := tx.Delete(&item).Error; err != nil {
-		tx.Rollback()
-		c.String(http.StatusInternalServerError, "Failed to delete item")
-		return
+	// 3c. Drop the post from the search index
+	if err := utils.RemoveItemIndex(tx, item.ID); err != nil {
+		return errors.New("Failed to delete search index")
 	}
 
-	tx.Commit()
+	// 4. Delete Notifications linked to this item
+	if err := tx.Where("related_item_id = ?", item.ID).Delete(&models.Notification{}).Error; err != nil {
+		return errors.New("Failed to delete notifications")
+	}
 
-	c.Redirect(http.StatusFound, "/dashboard")
-}
+	// 5. Delete Reports (the moderation audit trail keeps its rows)
+	if err := detachReports(tx, blobs, item.ID); err != nil {
+		return errors.New("Failed to detach reports")
+	}
+	if err := tx.Where("item_id = ?", item.ID).Delete(&models.ItemReport{}).Error; err != nil {
+		return errors.New("Failed to delete reports")
+	}
 
-// GetItemImage serves the image from database
-func GetItemImage(c *gin.Context) {
-	id := c.Param("pk")
-	var imgRec models.LostItemImage
-	if err := config.DB.First(&imgRec, id).Error; err != nil {
-		c.Status(http.StatusNotFound)
-		return
+	// 6. Finally, Delete the Item
+	if err := tx.Delete(item).Error; err != nil {
+		return errors.New("Failed to delete item")
 	}
 
-	c.Data(http.StatusOK, imgRec.ContentType, imgRec.ImageData)
+	return nil
 }
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxCloseReason = 255
+
+// loadOwnedItem fetches the post in the URL for its owner
+func loadOwnedItem(c *gin.Context, user *models.User) (*models.LostItem, bool) {
+	var item models.LostItem
+	if err := config.DB.First(&item, c.Param("pk")).Error; err != nil {
+		c.String(http.StatusNotFound, "Item not found")
+		return nil, false
+	}
+	if item.UserID != user.ID {
+		c.String(http.StatusForbidden, "Not authorized")
+		return nil, false
+	}
+	return &item, true
+}
+
+// CloseItem lets the owner give up on a post that was never returned. The
+// bounty goes back to the owner and any handover in progress is called off.
+func CloseItem(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	item, ok := loadOwnedItem(c, user)
+	if !ok {
+		return
+	}
+	reason := strings.TrimSpace(c.PostForm("reason"))
+	if utf8.RuneCountInString(reason) > maxCloseReason {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?lifecycle=reason#lifecycle", item.ID))
+		return
+	}
+	finderID := item.FinderID
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.TransitionItem(tx, item, utils.ItemClosedUnresolved, &user.ID, reason); err != nil {
+			return err
+		}
+		if err := utils.RefundBounty(tx, item, "closed_refund"); err != nil {
+			return err
+		}
+		if err := revokeHandoverCodes(tx, item.ID); err != nil {
+			return err
+		}
+		if err := cancelMeetups(tx, item.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(item).Updates(map[string]interface{}{
+			"finder_id":        nil,
+			"finder_confirmed": false,
+			"owner_confirmed":  false,
+			"is_highlighted":   false,
+		}).Error; err != nil {
+			return err
+		}
+		if finderID == nil {
+			return nil
+		}
+		return utils.Notify(tx, &models.Notification{
+			UserID:        *finderID,
+			Type:          "handover",
+			Title:         "Postingan ditutup",
+			Message:       fmt.Sprintf("Pemilik menutup postingan '%s' tanpa serah terima. Jadwal yang sudah disepakati dibatalkan.", item.Title),
+			ReferenceURL:  fmt.Sprintf("/item/%d", item.ID),
+			RelatedItemID: &item.ID,
+		})
+	})
+	if errors.Is(err, utils.ErrInvalidTransition) {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?lifecycle=state#lifecycle", item.ID))
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to close item")
+		return
+	}
+	if finderID != nil {
+		utils.NotificationHub.Publish(*finderID)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d#lifecycle", item.ID))
+}
+
+// ReopenItem puts a closed or expired post back up. Its bounty was refunded
+// when it closed, so it reopens without one.
+func ReopenItem(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	item, ok := loadOwnedItem(c, user)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return utils.TransitionItem(tx, item, utils.ItemOpen, &user.ID, "")
+	})
+	if errors.Is(err, utils.ErrInvalidTransition) {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?lifecycle=state#lifecycle", item.ID))
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to reopen item")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d#lifecycle", item.ID))
+}
@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxMeetupSlots    = 3
+	maxMeetupNote     = 500
+	meetupInputLayout = "2006-01-02T15:04" // <input type="datetime-local">
+	meetupMaxAhead    = 30 * 24 * time.Hour
+	// A no-show can be reported once the meetup is this far past its start,
+	// and only for a week
+	noShowGrace  = 30 * time.Minute
+	noShowWindow = 7 * 24 * time.Hour
+)
+
+var meetupStatusLabels = map[string]string{
+	"pending":   "Menunggu jawaban",
+	"accepted":  "Disepakati",
+	"countered": "Dibalas dengan usulan lain",
+	"declined":  "Ditolak",
+	"cancelled": "Dibatalkan",
+}
+
+// MeetupView is a meetup on the item page with what the viewer may do
+type MeetupView struct {
+	models.Meetup
+	IsRecipient     bool
+	CanReportNoShow bool
+	NoShowReported  bool
+}
+
+// meetupCounterpart is the other side of the handover for the owner or the
+// selected finder, or 0 when the user is neither
+func meetupCounterpart(item *models.LostItem, userID int64) int64 {
+	if item.FinderID == nil {
+		return 0
+	}
+	switch userID {
+	case item.UserID:
+		return *item.FinderID
+	case *item.FinderID:
+		return item.UserID
+	}
+	return 0
+}
+
+// itemMeetups lists the item's recent meetups for the item page, newest first
+func itemMeetups(db *gorm.DB, item *models.LostItem, viewerID int64) []MeetupView {
+	var meetups []models.Meetup
+	db.Preload("Proposer").
+		Preload("Slots", func(db *gorm.DB) *gorm.DB { return db.Order("starts_at ASC") }).
+		Preload("Slot").
+		Where("item_id = ?", item.ID).
+		Order("created_at DESC").Limit(5).Find(&meetups)
+
+	var reported []int64
+	db.Model(&models.MeetupNoShow{}).Where("reporter_id = ? AND meetup_id IN (?)", viewerID,
+		db.Model(&models.Meetup{}).Select("id").Where("item_id = ?", item.ID)).Pluck("meetup_id", &reported)
+	reportedSet := make(map[int64]bool, len(reported))
+	for _, id := range reported {
+		reportedSet[id] = true
+	}
+
+	now := time.Now()
+	views := make([]MeetupView, len(meetups))
+	for i, meetup := range meetups {
+		views[i] = MeetupView{
+			Meetup:         meetup,
+			IsRecipient:    meetup.RecipientID == viewerID,
+			NoShowReported: reportedSet[meetup.ID],
+		}
+		if meetup.Status == "accepted" && meetup.Slot != nil && item.Status == "LOST" && !views[i].NoShowReported {
+			start := meetup.Slot.StartsAt
+			views[i].CanReportNoShow = now.After(start.Add(noShowGrace)) && now.Before(start.Add(noShowWindow))
+		}
+	}
+	return views
+}
+
+// meetupPlaceSuggestions are the admin-managed places offered as meeting
+// points
+func meetupPlaceSuggestions(db *gorm.DB) []string {
+	var names []string
+	db.Model(&models.Place{}).Where("is_active = ?", true).Order("full_name ASC").Pluck("full_name", &names)
+	return names
+}
+
+// parseMeetupSlots reads the starts_at/place pairs of the proposal form.
+// Rows left completely empty are skipped.
+func parseMeetupSlots(c *gin.Context, now time.Time) ([]models.MeetupSlot, bool) {
+	starts := c.PostFormArray("starts_at")
+	places := c.PostFormArray("place")
+	if len(starts) != len(places) {
+		return nil, false
+	}
+
+	var slots []models.MeetupSlot
+	for i := range starts {
+		start := strings.TrimSpace(starts[i])
+		place := strings.TrimSpace(places[i])
+		if start == "" && place == "" {
+			continue
+		}
+		at, err := time.ParseInLocation(meetupInputLayout, start, time.Local)
+		if err != nil || place == "" || utf8.RuneCountInString(place) > 255 {
+			return nil, false
+		}
+		if !at.After(now) || at.After(now.Add(meetupMaxAhead)) {
+			return nil, false
+		}
+		slots = append(slots, models.MeetupSlot{StartsAt: at, Place: place})
+	}
+	return slots, len(slots) > 0 && len(slots) <= maxMeetupSlots
+}
+
+// ProposeMeetup offers time slots and places for the handover. The owner or
+// the selected finder may propose; a proposal from the recipient of a
+// pending one counters it, and any earlier open proposal or agreed meetup
+// is replaced.
+func ProposeMeetup(c *gin.Context) {
+	itemID := c.Param("pk")
+	user := c.MustGet("user").(*models.User)
+
+	if utils.IsRestricted(user) {
+		c.String(http.StatusForbidden, "Your account has been banned or suspended. You cannot arrange meetups.")
+		return
+	}
+
+	var item models.LostItem
+	if err := config.DB.First(&item, itemID).Error; err != nil {
+		c.String(http.StatusNotFound, "Item not found")
+		return
+	}
+	recipientID := meetupCounterpart(&item, user.ID)
+	if recipientID == 0 {
+		c.String(http.StatusForbidden, "Not authorized")
+		return
+	}
+	redirectError := func(code string) {
+		c.Redirect(http.StatusFound, "/item/"+itemID+"?meetup="+code+"#meetup")
+	}
+	if item.Status != "LOST" {
+		redirectError("closed")
+		return
+	}
+
+	now := time.Now()
+	slots, ok := parseMeetupSlots(c, now)
+	if !ok {
+		redirectError("slots")
+		return
+	}
+	note := strings.TrimSpace(c.PostForm("note"))
+	if utf8.RuneCountInString(note) > maxMeetupNote {
+		redirectError("note")
+		return
+	}
+	texts := []string{note}
+	for _, slot := range slots {
+		texts = append(texts, slot.Place)
+	}
+	if moderation := utils.CheckContent(config.DB, "comments", texts...); moderation.Blocked() {
+		redirectError("blocked")
+		return
+	}
+
+	tx := config.DB.Begin()
+	// Answering a pending proposal counters it; anything else open is replaced
+	result := tx.Model(&models.Meetup{}).
+		Where("item_id = ? AND status = ? AND recipient_id = ?", item.ID, "pending", user.ID).
+		Updates(map[string]interface{}{"status": "countered", "responded_at": now})
+	if result.Error != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to save meetup")
+		return
+	}
+	countered := result.RowsAffected
+	if err := tx.Model(&models.Meetup{}).
+		Where("item_id = ? AND status IN ?", item.ID, []string{"pending", "accepted"}).
+		Update("status", "cancelled").Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to save meetup")
+		return
+	}
+
+	meetup := models.Meetup{
+		ItemID:      item.ID,
+		ProposerID:  user.ID,
+		RecipientID: recipientID,
+		Note:        note,
+		Status:      "pending",
+		Slots:       slots,
+	}
+	if err := tx.Create(&meetup).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to save meetup")
+		return
+	}
+	if err := syncHandoverState(tx, &item, user.ID); err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to save meetup")
+		return
+	}
+
+	title := "Usulan jadwal serah terima"
+	if countered > 0 {
+		title = "Usulan jadwal balasan"
+	}
+	if err := utils.Notify(tx, &models.Notification{
+		UserID:        recipientID,
+		Type:          "handover",
+		Title:         title,
+		Message:       fmt.Sprintf("%s mengusulkan %d pilihan waktu untuk serah terima '%s'.", user.Username, len(slots), item.Title),
+		ReferenceURL:  fmt.Sprintf("/item/%d#meetup", item.ID),
+		RelatedItemID: &item.ID,
+	}); err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to save meetup")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to save meetup")
+		return
+	}
+	utils.NotificationHub.Publish(recipientID)
+
+	c.Redirect(http.StatusFound, "/item/"+itemID+"#meetup")
+}
+
+// loadMeetup fetches the meetup in the URL for one of its two sides
+func loadMeetup(c *gin.Context, user *models.User) (*models.Meetup, bool) {
+	var meetup models.Meetup
+	if err := config.DB.Preload("Item").Preload("Slots").Preload("Slot").First(&meetup, c.Param("id")).Error; err != nil {
+		c.String(http.StatusNotFound, "Meetup not found")
+		return nil, false
+	}
+	if meetup.ProposerID != user.ID && meetup.RecipientID != user.ID {
+		c.String(http.StatusForbidden, "Not authorized")
+		return nil, false
+	}
+	return &meetup, true
+}
+
+// respondMeetup records a status change, notifies the other side and sends
+// the user back to the item page
+func respondMeetup(c *gin.Context, meetup *models.Meetup, user *models.User, updates map[string]interface{}, title, message string) {
+	otherID := meetup.ProposerID
+	if otherID == user.ID {
+		otherID = meetup.RecipientID
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Model(meetup).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to update meetup")
+		return
+	}
+	if err := syncHandoverState(tx, &meetup.Item, user.ID); err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to update meetup")
+		return
+	}
+	if err := utils.Notify(tx, &models.Notification{
+		UserID:        otherID,
+		Type:          "handover",
+		Title:         title,
+		Message:       message,
+		ReferenceURL:  fmt.Sprintf("/item/%d#meetup", meetup.ItemID),
+		RelatedItemID: &meetup.ItemID,
+	}); err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to update meetup")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to update meetup")
+		return
+	}
+	utils.NotificationHub.Publish(otherID)
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d#meetup", meetup.ItemID))
+}
+
+// AcceptMeetup lets the recipient pick one of the proposed slots
+func AcceptMeetup(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	meetup, ok := loadMeetup(c, user)
+	if !ok {
+		return
+	}
+	if meetup.RecipientID != user.ID || meetup.Status != "pending" || meetup.Item.Status != "LOST" {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?meetup=state#meetup", meetup.ItemID))
+		return
+	}
+
+	slotID, _ := strconv.ParseInt(c.PostForm("slot_id"), 10, 64)
+	var slot *models.MeetupSlot
+	for i := range meetup.Slots {
+		if meetup.Slots[i].ID == slotID {
+			slot = &meetup.Slots[i]
+		}
+	}
+	if slot == nil || !slot.StartsAt.After(time.Now()) {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?meetup=slot#meetup", meetup.ItemID))
+		return
+	}
+
+	respondMeetup(c, meetup, user, map[string]interface{}{
+		"status":       "accepted",
+		"slot_id":      slot.ID,
+		"responded_at": time.Now(),
+	}, "Jadwal serah terima disepakati",
+		fmt.Sprintf("%s setuju bertemu %s di %s untuk serah terima '%s'.",
+			user.Username, slot.StartsAt.Format("02 Jan 2006 15:04"), slot.Place, meetup.Item.Title))
+}
+
+// DeclineMeetup lets the recipient turn down a proposal without countering
+func DeclineMeetup(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	meetup, ok := loadMeetup(c, user)
+	if !ok {
+		return
+	}
+	if meetup.RecipientID != user.ID || meetup.Status != "pending" {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?meetup=state#meetup", meetup.ItemID))
+		return
+	}
+
+	respondMeetup(c, meetup, user, map[string]interface{}{
+		"status":       "declined",
+		"responded_at": time.Now(),
+	}, "Usulan jadwal ditolak",
+		fmt.Sprintf("%s menolak usulan jadwal serah terima '%s'. Usulkan waktu lain.", user.Username, meetup.Item.Title))
+}
+
+// CancelMeetup lets either side withdraw a pending proposal or call off an
+// agreed meetup
+func CancelMeetup(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	meetup, ok := loadMeetup(c, user)
+	if !ok {
+		return
+	}
+	if meetup.Status != "pending" && meetup.Status != "accepted" {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?meetup=state#meetup", meetup.ItemID))
+		return
+	}
+
+	respondMeetup(c, meetup, user, map[string]interface{}{
+		"status": "cancelled",
+	}, "Jadwal serah terima dibatalkan",
+		fmt.Sprintf("%s membatalkan jadwal serah terima '%s'.", user.Username, meetup.Item.Title))
+}
+
+// MeetupCalendar exports an agreed meetup as an .ics file
+func MeetupCalendar(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	meetup, ok := loadMeetup(c, user)
+	if !ok {
+		return
+	}
+	if meetup.Status != "accepted" || meetup.Slot == nil {
+		c.String(http.StatusNotFound, "Meetup is not agreed")
+		return
+	}
+
+	description := fmt.Sprintf("Serah terima '%s' lewat TemuIN. Kode serah terima ada di halaman postingan.", meetup.Item.Title)
+	if meetup.Note != "" {
+		description += "\n\n" + meetup.Note
+	}
+	ics := utils.ICS(utils.CalendarEvent{
+		UID:         fmt.Sprintf("meetup-%d@temuin", meetup.ID),
+		Summary:     "Serah terima: " + meetup.Item.Title,
+		Location:    meetup.Slot.Place,
+		Description: description,
+		URL:         config.AppBaseURL + fmt.Sprintf("/item/%d", meetup.ItemID),
+		Start:       meetup.Slot.StartsAt,
+		End:         meetup.Slot.StartsAt.Add(time.Duration(config.MeetupDurationMinutes) * time.Minute),
+		AlarmBefore: time.Duration(config.MeetupReminderMinutes) * time.Minute,
+	})
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="serah-terima-%d.ics"`, meetup.ID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", ics)
+}
+
+// ReportNoShow lets one side report that the other missed an agreed meetup.
+// Reports count against the other user's reputation, one per meetup and
+// reporter, and only while the item has not been returned.
+func ReportNoShow(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	meetup, ok := loadMeetup(c, user)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if meetup.Status != "accepted" || meetup.Slot == nil || meetup.Item.Status != "LOST" ||
+		!now.After(meetup.Slot.StartsAt.Add(noShowGrace)) || now.After(meetup.Slot.StartsAt.Add(noShowWindow)) {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d?meetup=noshow#meetup", meetup.ItemID))
+		return
+	}
+	note := strings.TrimSpace(c.PostForm("note"))
+	if utf8.RuneCountInString(note) > maxMeetupNote {
+		note = string([]rune(note)[:maxMeetupNote])
+	}
+
+	reportedID := meetup.ProposerID
+	if reportedID == user.ID {
+		reportedID = meetup.RecipientID
+	}
+	var existing int64
+	config.DB.Model(&models.MeetupNoShow{}).Where("meetup_id = ? AND reporter_id = ?", meetup.ID, user.ID).Count(&existing)
+	if existing > 0 {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d#meetup", meetup.ItemID))
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Create(&models.MeetupNoShow{
+		MeetupID:   meetup.ID,
+		ReporterID: user.ID,
+		ReportedID: reportedID,
+		Note:       note,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to report no-show")
+		return
+	}
+	if err := utils.Notify(tx, &models.Notification{
+		UserID:        reportedID,
+		Type:          "handover",
+		Title:         "Anda dilaporkan tidak hadir",
+		Message:       fmt.Sprintf("%s melaporkan Anda tidak datang ke jadwal serah terima '%s' pada %s. Laporan ini memengaruhi reputasi Anda.", user.Username, meetup.Item.Title, meetup.Slot.StartsAt.Format("02 Jan 2006 15:04")),
+		ReferenceURL:  fmt.Sprintf("/item/%d#meetup", meetup.ItemID),
+		RelatedItemID: &meetup.ItemID,
+	}); err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to report no-show")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to report no-show")
+		return
+	}
+	utils.NotificationHub.Publish(reportedID)
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d#meetup", meetup.ItemID))
+}
+
+// cancelMeetups calls off the item's open meetups, such as when the owner
+// picks another finder
+func cancelMeetups(tx *gorm.DB, itemID int64) error {
+	return tx.Model(&models.Meetup{}).
+		Where("item_id = ? AND status IN ?", itemID, []string{"pending", "accepted"}).
+		Update("status", "cancelled").Error
+}
+
+// syncHandoverState moves the post to handover_pending while a meetup is
+// accepted and back to finder_selected when none is. Must run inside the
+// transaction that changed the meetups.
+func syncHandoverState(tx *gorm.DB, item *models.LostItem, actorID int64) error {
+	var accepted models.Meetup
+	err := tx.Preload("Slot").Where("item_id = ? AND status = ?", item.ID, "accepted").Order("id DESC").First(&accepted).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	hasAccepted := err == nil
+
+	switch {
+	case hasAccepted && item.State == utils.ItemFinderSelected:
+		note := ""
+		if accepted.Slot != nil {
+			note = accepted.Slot.StartsAt.Format("02 Jan 2006 15:04") + " di " + accepted.Slot.Place
+		}
+		return utils.TransitionItem(tx, item, utils.ItemHandoverPending, &actorID, note)
+	case !hasAccepted && item.State == utils.ItemHandoverPending:
+		return utils.TransitionItem(tx, item, utils.ItemFinderSelected, &actorID, "Jadwal serah terima dibatalkan")
+	}
+	return nil
+}
+
+// deleteMeetups removes an item's meetups and their slots. No-show reports
+// stay so the reputation they feed survives the post. Must run inside the
+// delete transaction.
+func deleteMeetups(tx *gorm.DB, itemID int64) error {
+	meetupIDs := tx.Model(&models.Meetup{}).Select("id").Where("item_id = ?", itemID)
+	if err := tx.Where("meetup_id IN (?)", meetupIDs).Delete(&models.MeetupSlot{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("item_id = ?", itemID).Delete(&models.Meetup{}).Error
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxMessageLength = 2000
+
+// ConversationSummary is one row of the inbox
+type ConversationSummary struct {
+	Conversation models.Conversation
+	Other        models.User
+	LastMessage  *models.DirectMessage
+	Unread       int64
+}
+
+// unreadMessageCount counts messages waiting for the user across all their threads
+func unreadMessageCount(userID int64) int64 {
+	var count int64
+	config.DB.Model(&models.DirectMessage{}).
+		Where("sender_id <> ? AND read_at IS NULL", userID).
+		Where("conversation_id IN (?)", participantConversations(userID)).
+		Count(&count)
+	return count
+}
+
+// participantConversations selects the IDs of threads the user takes part in,
+// either as the claimant or as the owner of the item
+func participantConversations(userID int64) *gorm.DB {
+	ownedItems := config.DB.Model(&models.LostItem{}).Select("id").Where("user_id = ?", userID)
+	return config.DB.Model(&models.Conversation{}).Select("id").
+		Where("claimant_id = ? OR item_id IN (?)", userID, ownedItems)
+}
+
+// deleteConversations removes every private thread about an item
+func deleteConversations(tx *gorm.DB, itemID int64) error {
+	conversationIDs := tx.Model(&models.Conversation{}).Select("id").Where("item_id = ?", itemID)
+	if err := tx.Where("conversation_id IN (?)", conversationIDs).Delete(&models.DirectMessage{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("item_id = ?", itemID).Delete(&models.Conversation{}).Error
+}
+
+// loadConversation fetches a thread the user is part of
+func loadConversation(c *gin.Context, user *models.User) (*models.Conversation, bool) {
+	var conversation models.Conversation
+	if err := config.DB.Preload("Item").Preload("Item.User").Preload("Claimant").
+		First(&conversation, c.Param("id")).Error; err != nil {
+		c.String(http.StatusNotFound, "Conversation not found")
+		return nil, false
+	}
+
+	if conversation.ClaimantID != user.ID && conversation.Item.UserID != user.ID {
+		c.String(http.StatusForbidden, "Not authorized to view this conversation")
+		return nil, false
+	}
+	return &conversation, true
+}
+
+// StartConversation opens (or reuses) the private thread between an item's
+// owner and a claimant. Claimants open their own thread; owners pick a claimant.
+func StartConversation(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var item models.LostItem
+	if err := config.DB.First(&item, c.Param("pk")).Error; err != nil {
+		c.String(http.StatusNotFound, "Item not found")
+		return
+	}
+
+	claimantID := user.ID
+	if item.UserID == user.ID {
+		claimantID, _ = strconv.ParseInt(c.PostForm("claimant_id"), 10, 64)
+	}
+
+	// Threads only exist between the owner and someone who claimed the item
+	var claims int64
+	config.DB.Model(&models.ItemClaim{}).Where("item_id = ? AND user_id = ?", item.ID, claimantID).Count(&claims)
+	if claims == 0 || claimantID == item.UserID {
+		c.String(http.StatusForbidden, "Private messages are only available between the owner and a claimant")
+		return
+	}
+
+	conversation := models.Conversation{ItemID: item.ID, ClaimantID: claimantID}
+	if err := config.DB.Where(conversation).FirstOrCreate(&conversation).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to open conversation")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/messages/%d", conversation.ID))
+}
+
+// MessageInbox lists the user's private threads, most recent first
+func MessageInbox(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var conversations []models.Conversation
+	config.DB.Preload("Item").Preload("Item.User").Preload("Claimant").
+		Where("id IN (?)", participantConversations(user.ID)).
+		Order("last_message_at IS NULL, last_message_at DESC, created_at DESC").
+		Find(&conversations)
+
+	ids := make([]int64, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+
+	// Unread counts per thread
+	type unreadRow struct {
+		ConversationID int64
+		Unread         int64
+	}
+	var unreadRows []unreadRow
+	unreadByConversation := make(map[int64]int64)
+	if len(ids) > 0 {
+		config.DB.Model(&models.DirectMessage{}).
+			Select("conversation_id, COUNT(*) AS unread").
+			Where("conversation_id IN ? AND sender_id <> ? AND read_at IS NULL", ids, user.ID).
+			Group("conversation_id").
+			Scan(&unreadRows)
+	}
+	for _, row := range unreadRows {
+		unreadByConversation[row.ConversationID] = row.Unread
+	}
+
+	summaries := make([]ConversationSummary, 0, len(conversations))
+	for _, conversation := range conversations {
+		summary := ConversationSummary{
+			Conversation: conversation,
+			Other:        conversation.Claimant,
+			Unread:       unreadByConversation[conversation.ID],
+		}
+		if conversation.ClaimantID == user.ID {
+			summary.Other = conversation.Item.User
+		}
+
+		var last models.DirectMessage
+		if config.DB.Where("conversation_id = ?", conversation.ID).Order("created_at DESC, id DESC").First(&last).Error == nil {
+			summary.LastMessage = &last
+		}
+		summaries = append(summaries, summary)
+	}
+
+	utils.RenderTemplate(c, "templates/core/messages.html", map[string]interface{}{
+		"conversations": summaries,
+	})
+}
+
+// ConversationPage shows a thread and marks the other participant's messages as read
+func ConversationPage(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	conversation, ok := loadConversation(c, user)
+	if !ok {
+		return
+	}
+
+	config.DB.Model(&models.DirectMessage{}).
+		Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversation.ID, user.ID).
+		Update("read_at", time.Now())
+	utils.NotificationHub.Publish(user.ID)
+
+	var messages []models.DirectMessage
+	config.DB.Preload("Sender").Where("conversation_id = ?", conversation.ID).
+		Order("created_at ASC, id ASC").Find(&messages)
+
+	utils.RenderTemplate(c, "templates/core/conversation.html", map[string]interface{}{
+		"conversation": conversation,
+		"messages":     messages,
+		"max_length":   maxMessageLength,
+		"error":        c.Query("error"),
+	})
+}
+
+// SendMessage posts a private message to a thread
+func SendMessage(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	// Check if user is banned or suspended
+	if utils.IsRestricted(user) {
+		c.String(http.StatusForbidden, "Your account has been banned or suspended. You cannot send messages.")
+		return
+	}
+
+	conversation, ok := loadConversation(c, user)
+	if !ok {
+		return
+	}
+
+	content := strings.TrimSpace(c.PostForm("content"))
+	redirect := fmt.Sprintf("/messages/%d", conversation.ID)
+	if content == "" {
+		c.Redirect(http.StatusFound, redirect+"?error=empty")
+		return
+	}
+	if utf8.RuneCountInString(content) > maxMessageLength {
+		c.Redirect(http.StatusFound, redirect+"?error=too_long")
+		return
+	}
+
+	tx := config.DB.Begin()
+	message := models.DirectMessage{
+		ConversationID: conversation.ID,
+		SenderID:       user.ID,
+		Content:        content,
+	}
+	if err := tx.Create(&message).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+	if err := tx.Model(conversation).Update("last_message_at", message.CreatedAt).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+	tx.Commit()
+
+	// Wake the other participant so their unread badge updates
+	recipientID := conversation.ClaimantID
+	if recipientID == user.ID {
+		recipientID = conversation.Item.UserID
+	}
+	utils.NotificationHub.Publish(recipientID)
+
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// AdminConversation lets moderators read a thread about a reported item
+func AdminConversation(c *gin.Context) {
+	var conversation models.Conversation
+	if err := config.DB.Preload("Item").Preload("Item.User").Preload("Claimant").
+		First(&conversation, c.Param("id")).Error; err != nil {
+		c.String(http.StatusNotFound, "Conversation not found")
+		return
+	}
+
+	// Private threads are only opened to moderators once the item is reported
+	var reports int64
+	config.DB.Model(&models.ItemReport{}).Where("item_id = ?", conversation.ItemID).Count(&reports)
+	if reports == 0 {
+		c.String(http.StatusForbidden, "This conversation is not attached to a report")
+		return
+	}
+
+	var messages []models.DirectMessage
+	config.DB.Preload("Sender").Where("conversation_id = ?", conversation.ID).
+		Order("created_at ASC, id ASC").Find(&messages)
+
+	utils.RenderTemplate(c, "templates/core/conversation.html", map[string]interface{}{
+		"conversation": conversation,
+		"messages":     messages,
+		"read_only":    true,
+	})
+}
@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	ruleKinds   = map[string]bool{"keyword": true, "regex": true, "phone": true, "url": true, "bank": true}
+	ruleScopes  = map[string]bool{"all": true, "items": true, "comments": true}
+	ruleActions = map[string]bool{"block": true, "hold": true, "flag": true}
+)
+
+// blockedContentMessage is shown when a moderation rule rejects a post or comment
+func blockedContentMessage(result utils.ModerationResult) string {
+	names := make([]string, 0, len(result.Hits))
+	for _, rule := range result.Hits {
+		if rule.Action == "block" {
+			names = append(names, rule.Name)
+		}
+	}
+	return "Konten Anda tidak dapat dikirim karena melanggar aturan: " + strings.Join(names, ", ") +
+		". Jangan mencantumkan nomor telepon, tautan, rekening, atau penawaran jual beli."
+}
+
+// applyModeration holds the content and opens automatic reports according to the
+// rule hits. Blocked content never reaches this point. Returns the admins to
+// publish to once the caller commits.
+func applyModeration(tx *gorm.DB, result utils.ModerationResult, item *models.LostItem, comment *models.Comment) ([]int64, error) {
+	if len(result.Hits) == 0 {
+		return nil, nil
+	}
+	var notified []int64
+
+	target := fmt.Sprintf("postingan '%s'", item.Title)
+	if comment != nil {
+		target = fmt.Sprintf("komentar pada postingan '%s'", item.Title)
+	}
+
+	if result.Held() {
+		var err error
+		if comment != nil {
+			err = tx.Model(comment).Update("is_held", true).Error
+		} else {
+			err = tx.Model(item).Update("is_held", true).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		admins, err := notifyAdmins(tx, "Konten Ditahan Otomatis",
+			fmt.Sprintf("Sebuah %s ditahan oleh aturan moderasi dan menunggu tinjauan.", target),
+			"/admin/moderation/held", &item.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		notified = append(notified, admins...)
+	}
+
+	for _, rule := range result.FlagRules() {
+		reason := rule.ReportReason
+		if reason == "" {
+			reason = "other"
+		}
+		description := fmt.Sprintf("Ditandai otomatis oleh aturan '%s'.", rule.Name)
+		if comment != nil {
+			description += " Komentar: " + comment.Content
+		}
+		dueAt := time.Now().Add(config.ReportSLA(reason))
+		ruleID := rule.ID
+		report := models.ItemReport{
+			ItemID:      item.ID,
+			RuleID:      &ruleID,
+			Reason:      reason,
+			Description: description,
+			Status:      "pending",
+			DueAt:       &dueAt,
+		}
+		if err := tx.Create(&report).Error; err != nil {
+			return nil, err
+		}
+		if err := utils.QueueWebhook(tx, "report.created", reportWebhookData(&report)); err != nil {
+			return nil, err
+		}
+		admins, err := notifyAdmins(tx, "Laporan Otomatis: "+rule.Name,
+			fmt.Sprintf("Aturan '%s' menandai %s.", rule.Name, target),
+			fmt.Sprintf("/admin/reports?highlight=%d", report.ID), &item.ID, &report.ID)
+		if err != nil {
+			return nil, err
+		}
+		notified = append(notified, admins...)
+	}
+	return notified, nil
+}
+
+// notifyAdmins sends a report notification to every admin and returns their
+// IDs, which the caller publishes to NotificationHub after commit
+func notifyAdmins(tx *gorm.DB, title, message, url string, itemID, reportID *int64) ([]int64, error) {
+	var admins []models.User
+	tx.Where("is_superuser = ?", true).Find(&admins)
+	notified := make([]int64, 0, len(admins))
+	for _, admin := range admins {
+		notification := models.Notification{
+			UserID:          admin.ID,
+			Type:            "report",
+			Title:           title,
+			Message:         message,
+			ReferenceURL:    url,
+			RelatedItemID:   itemID,
+			RelatedReportID: reportID,
+		}
+		if err := utils.Notify(tx, &notification); err != nil {
+			return nil, err
+		}
+		notified = append(notified, admin.ID)
+	}
+	return notified, nil
+}
+
+// AdminRuleList shows the moderation rules with their hit statistics
+func AdminRuleList(c *gin.Context) {
+	var rules []models.ModerationRule
+	config.DB.Preload("CreatedBy").Order("is_active DESC, hit_count DESC, id ASC").Find(&rules)
+
+	var heldItems, heldComments int64
+	config.DB.Model(&models.LostItem{}).Where("is_held = ?", true).Count(&heldItems)
+	config.DB.Model(&models.Comment{}).Where("is_held = ?", true).Count(&heldComments)
+
+	utils.RenderTemplate(c, "templates/admin_rules.html", map[string]interface{}{
+		"rules":         rules,
+		"held_items":    heldItems,
+		"held_comments": heldComments,
+	})
+}
+
+// RuleRequest is the body for creating or updating a moderation rule
+type RuleRequest struct {
+	Name         string `json:"name" form:"name"`
+	Kind         string `json:"kind" form:"kind"`
+	Pattern      string `json:"pattern" form:"pattern"`
+	Scope        string `json:"scope" form:"scope"`
+	Action       string `json:"action" form:"action"`
+	ReportReason string `json:"report_reason" form:"report_reason"`
+}
+
+// validate checks the request and returns an error message for the admin
+func (req *RuleRequest) validate() string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Scope == "" {
+		req.Scope = "all"
+	}
+	if req.Name == "" {
+		return "Nama aturan wajib diisi"
+	}
+	if !ruleKinds[req.Kind] || !ruleScopes[req.Scope] || !ruleActions[req.Action] {
+		return "Jenis, cakupan, atau tindakan tidak valid"
+	}
+	if req.Action == "flag" && req.ReportReason != "" && !validReportReasons[req.ReportReason] {
+		return "Alasan laporan tidak valid"
+	}
+	if err := utils.CompileRulePattern(req.Kind, req.Pattern); err != nil {
+		return "Pola tidak valid: " + err.Error()
+	}
+	return ""
+}
+
+// CreateRule adds a moderation rule
+func CreateRule(c *gin.Context) {
+	admin := c.MustGet("user").(*models.User)
+
+	var req RuleRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	rule := models.ModerationRule{
+		Name:         req.Name,
+		Kind:         req.Kind,
+		Pattern:      req.Pattern,
+		Scope:        req.Scope,
+		Action:       req.Action,
+		ReportReason: req.ReportReason,
+		IsActive:     true,
+		CreatedByID:  &admin.ID,
+	}
+	if err := config.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "id": rule.ID})
+}
+
+// UpdateRule edits a moderation rule, keeping its statistics
+func UpdateRule(c *gin.Context) {
+	var rule models.ModerationRule
+	if err := config.DB.First(&rule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+
+	var req RuleRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	rule.Name = req.Name
+	rule.Kind = req.Kind
+	rule.Pattern = req.Pattern
+	rule.Scope = req.Scope
+	rule.Action = req.Action
+	rule.ReportReason = req.ReportReason
+	if err := config.DB.Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ToggleRule enables or disables a moderation rule
+func ToggleRule(c *gin.Context) {
+	var rule models.ModerationRule
+	if err := config.DB.First(&rule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+
+	rule.IsActive = !rule.IsActive
+	if err := config.DB.Model(&rule).Update("is_active", rule.IsActive).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "is_active": rule.IsActive})
+}
+
+// DeleteRule removes a moderation rule; reports it opened are kept
+func DeleteRule(c *gin.Context) {
+	var rule models.ModerationRule
+	if err := config.DB.First(&rule, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Model(&models.ItemReport{}).Where("rule_id = ?", rule.ID).Update("rule_id", nil).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detach reports"})
+		return
+	}
+	if err := tx.Delete(&rule).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rule"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// AdminHeldContent lists posts and comments held by moderation rules
+func AdminHeldContent(c *gin.Context) {
+	var items []models.LostItem
+	config.DB.Preload("User").Where("is_held = ?", true).Order("created_at ASC").Find(&items)
+
+	var comments []models.Comment
+	config.DB.Preload("User").Preload("Item").Where("is_held = ?", true).Order("created_at ASC").Find(&comments)
+
+	utils.RenderTemplate(c, "templates/admin_held.html", map[string]interface{}{
+		"items":    items,
+		"comments": comments,
+	})
+}
+
+// ApproveHeldItem publishes a held post
+func ApproveHeldItem(c *gin.Context) {
+	var item models.LostItem
+	if err := config.DB.Where("is_held = ?", true).First(&item, c.Param("pk")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Held item not found"})
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Model(&item).Update("is_held", false).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve item"})
+		return
+	}
+	// Held posts were never announced, so item.created goes out on approval
+	if err := utils.QueueWebhook(tx, "item.created", itemWebhookData(&item)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue webhooks"})
+		return
+	}
+	notification := models.Notification{
+		UserID:        item.UserID,
+		Type:          "system_update",
+		Title:         "Postingan Disetujui",
+		Message:       fmt.Sprintf("Postingan '%s' telah ditinjau admin dan kini tampil untuk umum.", item.Title),
+		ReferenceURL:  fmt.Sprintf("/item/%d", item.ID),
+		RelatedItemID: &item.ID,
+	}
+	if err := utils.Notify(tx, &notification); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		return
+	}
+	tx.Commit()
+	utils.NotificationHub.Publish(notification.UserID)
+	go notifySavedSearches(item)
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// RejectHeldItem removes a held post, refunding its bounty
+func RejectHeldItem(c *gin.Context) {
+	admin := c.MustGet("user").(*models.User)
+
+	var item models.LostItem
+	if err := config.DB.Where("is_held = ?", true).First(&item, c.Param("pk")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Held item not found"})
+		return
+	}
+
+	tx := config.DB.Begin()
+	blobs := newBlobWrites(c)
+	if err := adminRemoveItem(tx, blobs, &item, admin.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	notification := models.Notification{
+		UserID:  item.UserID,
+		Type:    "warning",
+		Title:   "Postingan Ditolak",
+		Message: fmt.Sprintf("Postingan '%s' ditolak karena melanggar aturan konten.", item.Title),
+	}
+	if err := utils.Notify(tx, &notification); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		return
+	}
+	tx.Commit()
+	blobs.commit()
+	utils.NotificationHub.Publish(notification.UserID)
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ApproveHeldComment publishes a held comment
+func ApproveHeldComment(c *gin.Context) {
+	result := config.DB.Model(&models.Comment{}).
+		Where("id = ? AND is_held = ?", c.Param("id"), true).
+		Update("is_held", false)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve comment"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Held comment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// RejectHeldComment deletes a held comment
+func RejectHeldComment(c *gin.Context) {
+	result := config.DB.Where("id = ? AND is_held = ?", c.Param("id"), true).Delete(&models.Comment{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Held comment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"temuin/config"
 	"temuin/models"
 	"temuin/utils"
+	"time"
 
 	"github.com/flosch/pongo2/v6"
 	"github.com/gin-gonic/gin"
@@ -31,7 +34,7 @@ func GetNotifications(c *gin.Context) {
 	})
 }
 
-// GetUnreadCount returns count of unread notifications
+// GetUnreadCount returns count of unread notifications and private messages
 func GetUnreadCount(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 
@@ -41,11 +44,124 @@ func GetUnreadCount(c *gin.Context) {
 		Count(&count)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"count":   count,
+		"success":  true,
+		"count":    count,
+		"messages": unreadMessageCount(user.ID),
 	})
 }
 
+const (
+	streamReplayLimit  = 50               // notifications replayed after a reconnect
+	streamResyncPeriod = 25 * time.Second // heartbeat, also catches wake-ups that beat their commit
+)
+
+// unreadCounts is the payload of the "unread" stream event
+type unreadCounts struct {
+	Count    int64 `json:"count"`
+	Messages int64 `json:"messages"`
+}
+
+func loadUnreadCounts(userID int64) unreadCounts {
+	var counts unreadCounts
+	config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&counts.Count)
+	counts.Messages = unreadMessageCount(userID)
+	return counts
+}
+
+// writeEvent writes one SSE frame; id may be empty
+func writeEvent(c *gin.Context, id, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	_, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// StreamNotifications pushes new notifications and unread-count changes over
+// Server-Sent Events. Event IDs are notification IDs, so a reconnecting browser
+// sends Last-Event-ID and receives whatever it missed.
+func StreamNotifications(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var lastID int64
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		lastID, _ = strconv.ParseInt(id, 10, 64)
+	} else {
+		// Fresh connection: the page already shows existing notifications
+		config.DB.Model(&models.Notification{}).
+			Where("user_id = ?", user.ID).
+			Select("COALESCE(MAX(id), 0)").
+			Scan(&lastID)
+	}
+
+	wake, unsubscribe := utils.NotificationHub.Subscribe(user.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", 5000)
+
+	var lastCounts *unreadCounts
+	send := func() error {
+		var notifications []models.Notification
+		config.DB.Where("user_id = ? AND id > ?", user.ID, lastID).
+			Order("id ASC").
+			Limit(streamReplayLimit).
+			Find(&notifications)
+		for _, notification := range notifications {
+			if err := writeEvent(c, strconv.FormatInt(notification.ID, 10), "notification", notification); err != nil {
+				return err
+			}
+			lastID = notification.ID
+		}
+
+		counts := loadUnreadCounts(user.ID)
+		if lastCounts == nil || *lastCounts != counts {
+			if err := writeEvent(c, "", "unread", counts); err != nil {
+				return err
+			}
+			lastCounts = &counts
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	if send() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(streamResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-wake:
+			if send() != nil {
+				return
+			}
+		case <-ticker.C:
+			// Comment line keeps proxies from closing an idle stream
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			if send() != nil {
+				return
+			}
+		}
+	}
+}
+
 // MarkAsRead marks a notification as read
 func MarkAsRead(c *gin.Context) {
 	notificationID := c.Param("id")
@@ -62,6 +178,7 @@ func MarkAsRead(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark as read"})
 		return
 	}
+	utils.NotificationHub.Publish(user.ID)
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
@@ -76,6 +193,7 @@ func MarkAllAsRead(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark all as read"})
 		return
 	}
+	utils.NotificationHub.Publish(user.ID)
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const placeSuggestLimit = 10
+
+// PlaceRow is a place in the admin tree with its label and post count
+type PlaceRow struct {
+	utils.PlaceOption
+	KindLabel string
+	AliasList string
+	ItemCount int64
+}
+
+// AdminPlaceList shows the places directory as a tree
+func AdminPlaceList(c *gin.Context) {
+	// Posts pinned directly to each place
+	var counts []struct {
+		PlaceID int64
+		Count   int64
+	}
+	config.DB.Model(&models.LostItem{}).
+		Select("place_id, COUNT(*) AS count").
+		Where("place_id IS NOT NULL").
+		Group("place_id").
+		Scan(&counts)
+	itemCounts := make(map[int64]int64, len(counts))
+	for _, row := range counts {
+		itemCounts[row.PlaceID] = row.Count
+	}
+
+	options := utils.PlaceOptions(config.DB, false)
+	rows := make([]PlaceRow, 0, len(options))
+	for _, option := range options {
+		aliases := make([]string, len(option.Aliases))
+		for i, alias := range option.Aliases {
+			aliases[i] = alias.Alias
+		}
+		rows = append(rows, PlaceRow{
+			PlaceOption: option,
+			KindLabel:   utils.PlaceKindLabels[option.Kind],
+			AliasList:   strings.Join(aliases, ", "),
+			ItemCount:   itemCounts[option.ID],
+		})
+	}
+
+	kinds := make([]map[string]string, len(utils.PlaceKinds))
+	for i, kind := range utils.PlaceKinds {
+		kinds[i] = map[string]string{"value": kind, "label": utils.PlaceKindLabels[kind]}
+	}
+
+	var unmatched int64
+	config.DB.Model(&models.LostItem{}).
+		Where("place_id IS NULL AND location IS NOT NULL AND location <> ''").
+		Count(&unmatched)
+
+	utils.RenderTemplate(c, "templates/admin_places.html", map[string]interface{}{
+		"places":    rows,
+		"kinds":     kinds,
+		"unmatched": unmatched,
+	})
+}
+
+// PlaceRequest is the body for creating or updating a place
+type PlaceRequest struct {
+	ParentID *int64   `json:"parent_id" form:"parent_id"`
+	Kind     string   `json:"kind" form:"kind"`
+	Name     string   `json:"name" form:"name"`
+	Aliases  []string `json:"aliases" form:"aliases"`
+}
+
+// validate checks the request against the hierarchy and returns an error
+// message for the admin. placeID is the place being edited, or 0.
+func (req *PlaceRequest) validate(placeID int64) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return "Nama tempat wajib diisi (maks. 100 karakter)"
+	}
+	if _, ok := utils.PlaceKindLabels[req.Kind]; !ok {
+		return "Jenis tempat tidak dikenal"
+	}
+	if req.ParentID != nil && *req.ParentID == 0 {
+		req.ParentID = nil
+	}
+
+	parentKind := ""
+	if req.ParentID != nil {
+		var parent models.Place
+		if err := config.DB.First(&parent, *req.ParentID).Error; err != nil {
+			return "Induk tidak ditemukan"
+		}
+		// A place cannot move under itself or its own children
+		if placeID != 0 {
+			for _, id := range utils.PlaceSubtreeIDs(config.DB, placeID) {
+				if id == parent.ID {
+					return "Tempat tidak bisa dipindah ke bawah dirinya sendiri"
+				}
+			}
+		}
+		parentKind = parent.Kind
+	}
+	if !utils.ValidPlaceParent(req.Kind, parentKind) {
+		if parentKind == "" {
+			return utils.PlaceKindLabels[req.Kind] + " harus berada di bawah tempat lain"
+		}
+		return utils.PlaceKindLabels[req.Kind] + " tidak bisa berada di bawah " + utils.PlaceKindLabels[parentKind]
+	}
+
+	var aliases []string
+	for _, alias := range req.Aliases {
+		for _, part := range strings.Split(alias, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				if len(part) > 100 {
+					return "Alias terlalu panjang: " + part
+				}
+				aliases = append(aliases, part)
+			}
+		}
+	}
+	req.Aliases = aliases
+	return ""
+}
+
+// replaceAliases swaps the aliases of a place. Aliases normalizing to the
+// same text as another place's alias are rejected by the unique index.
+func replaceAliases(tx *gorm.DB, placeID int64, aliases []string) error {
+	if err := tx.Where("place_id = ?", placeID).Delete(&models.PlaceAlias{}).Error; err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, alias := range aliases {
+		normalized := utils.NormalizePlace(alias)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		if err := tx.Create(&models.PlaceAlias{PlaceID: placeID, Alias: alias, Normalized: normalized}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreatePlace adds a place with its aliases
+func CreatePlace(c *gin.Context) {
+	var req PlaceRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(0); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	place := models.Place{ParentID: req.ParentID, Kind: req.Kind, Name: req.Name, FullName: req.Name, IsActive: true}
+	tx := config.DB.Begin()
+	if err := tx.Create(&place).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create place"})
+		return
+	}
+	if err := utils.RefreshPlaceNames(tx, &place); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create place"})
+		return
+	}
+	if err := replaceAliases(tx, place.ID, req.Aliases); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alias sudah dipakai tempat lain"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "id": place.ID})
+}
+
+// UpdatePlace renames or moves a place and replaces its aliases. The full
+// names of places under it follow.
+func UpdatePlace(c *gin.Context) {
+	var place models.Place
+	if err := config.DB.First(&place, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+		return
+	}
+
+	var req PlaceRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(place.ID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Children must still fit under the new kind
+	var children []models.Place
+	config.DB.Where("parent_id = ?", place.ID).Find(&children)
+	for _, child := range children {
+		if !utils.ValidPlaceParent(child.Kind, req.Kind) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis tidak cocok dengan tempat di bawahnya"})
+			return
+		}
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Model(&place).Updates(map[string]interface{}{
+		"parent_id": req.ParentID,
+		"kind":      req.Kind,
+		"name":      req.Name,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update place"})
+		return
+	}
+	place.ParentID, place.Kind, place.Name = req.ParentID, req.Kind, req.Name
+	if err := utils.RefreshPlaceNames(tx, &place); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update place"})
+		return
+	}
+	if err := replaceAliases(tx, place.ID, req.Aliases); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alias sudah dipakai tempat lain"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TogglePlace hides or shows a place in autocomplete and filters. Posts keep
+// their place.
+func TogglePlace(c *gin.Context) {
+	var place models.Place
+	if err := config.DB.First(&place, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+		return
+	}
+
+	place.IsActive = !place.IsActive
+	if err := config.DB.Model(&place).Update("is_active", place.IsActive).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update place"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "is_active": place.IsActive})
+}
+
+// DeletePlace removes a place without children. Posts at the place keep
+// their free-text location.
+func DeletePlace(c *gin.Context) {
+	var place models.Place
+	if err := config.DB.First(&place, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+		return
+	}
+
+	var children int64
+	config.DB.Model(&models.Place{}).Where("parent_id = ?", place.ID).Count(&children)
+	if children > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hapus atau pindahkan tempat di bawahnya terlebih dahulu"})
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Model(&models.LostItem{}).Where("place_id = ?", place.ID).Update("place_id", nil).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detach posts"})
+		return
+	}
+	if err := tx.Where("place_id = ?", place.ID).Delete(&models.PlaceAlias{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete aliases"})
+		return
+	}
+	if err := tx.Delete(&place).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete place"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// SuggestPlaces answers the location autocomplete on the report and edit forms
+func SuggestPlaces(c *gin.Context) {
+	suggestions, err := utils.SuggestPlaces(config.DB, c.Query("q"), placeSuggestLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load places"})
+		return
+	}
+	if suggestions == nil {
+		suggestions = []utils.PlaceSuggestion{}
+	}
+	c.JSON(http.StatusOK, gin.H{"places": suggestions})
+}
+
+// resolvePlace reads the place picked in the location autocomplete. The
+// place must be active; an empty value means free text only.
+func resolvePlace(value string) (*models.Place, bool) {
+	id := parseOptionalID(value)
+	if id == nil {
+		return nil, true
+	}
+	var place models.Place
+	if err := config.DB.Where("id = ? AND is_active = ?", *id, true).First(&place).Error; err != nil {
+		return nil, false
+	}
+	return &place, true
+}
+
+func placeIDOf(place *models.Place) *int64 {
+	if place == nil {
+		return nil
+	}
+	return &place.ID
+}
+
+// setPlaceFilter adds the place filter options to a browse page
+func setPlaceFilter(ctx pongo2.Context, c *gin.Context) {
+	ctx["places"] = utils.PlaceOptions(config.DB, true)
+	if id := parseOptionalID(c.Query("place")); id != nil {
+		ctx["active_place_id"] = *id
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"temuin/config"
 	"temuin/models"
-	"time"
+	"temuin/storage"
+	"temuin/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -36,12 +38,13 @@ func UpdateProfile(c *gin.Context) {
 		user.Username = newUsername
 	}
 
+	// Old pictures are removed once the new one is saved
+	var stalePicture string
+
 	// Handle profile picture deletion
 	if c.PostForm("delete_picture") == "true" {
 		if user.ProfilePicture != "" {
-			profilesDir := "static/images/profiles"
-			oldPath := filepath.Join(profilesDir, user.ProfilePicture)
-			os.Remove(oldPath)
+			stalePicture = user.ProfilePicture
 			user.ProfilePicture = ""
 		}
 	}
@@ -49,17 +52,19 @@ func UpdateProfile(c *gin.Context) {
 	// Handle profile picture upload
 	file, err := c.FormFile("profile_picture")
 	if err == nil {
-		// Create profiles directory if not exists
-		profilesDir := "static/images/profiles"
-		os.MkdirAll(profilesDir, os.ModePerm)
-
-		// Generate unique filename
-		ext := filepath.Ext(file.Filename)
-		filename := fmt.Sprintf("%d_%d%s", user.ID, time.Now().Unix(), ext)
-		savePath := filepath.Join(profilesDir, filename)
+		upload, msg := readImageFile(file)
+		if msg != "" {
+			if isJSON {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": msg})
+				return
+			}
+			c.Redirect(http.StatusFound, "/profile?error=upload_failed")
+			return
+		}
 
-		// Save file
-		if err := c.SaveUploadedFile(file, savePath); err != nil {
+		// Avatars are shown small, the detail size is plenty
+		key := storage.NewKey(fmt.Sprintf("avatars/%d", user.ID)) + ".jpg"
+		if err := storage.Default.Put(c.Request.Context(), key, upload.Detail, upload.ContentType); err != nil {
 			if isJSON {
 				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Gagal menyimpan foto"})
 				return
@@ -68,17 +73,17 @@ func UpdateProfile(c *gin.Context) {
 			return
 		}
 
-		// Delete old profile picture if exists AND we haven't already deleted it above
-		if user.ProfilePicture != "" && user.ProfilePicture != filename {
-			oldPath := filepath.Join(profilesDir, user.ProfilePicture)
-			os.Remove(oldPath)
+		if user.ProfilePicture != "" {
+			stalePicture = user.ProfilePicture
 		}
-
-		user.ProfilePicture = filename
+		user.ProfilePicture = key
 	}
 
 	// Save updates to database
 	if err := config.DB.Save(user).Error; err != nil {
+		if user.ProfilePicture != "" && user.ProfilePicture != stalePicture {
+			deleteProfilePicture(c, user.ProfilePicture)
+		}
 		if isJSON {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Gagal menyimpan perubahan"})
 			return
@@ -87,6 +92,10 @@ func UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if stalePicture != "" {
+		deleteProfilePicture(c, stalePicture)
+	}
+
 	if isJSON {
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Profile berhasil diperbarui"})
 		return
@@ -95,6 +104,31 @@ func UpdateProfile(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/profile?success=true")
 }
 
+// legacyProfilesDir held avatars before blob storage. User.ProfilePicture is
+// a bare file name there, and a storage key ("avatars/...") otherwise.
+const legacyProfilesDir = "static/images/profiles"
+
+func isLegacyProfilePicture(picture string) bool {
+	return !strings.Contains(picture, "/")
+}
+
+func deleteProfilePicture(c *gin.Context, picture string) {
+	if isLegacyProfilePicture(picture) {
+		os.Remove(filepath.Join(legacyProfilesDir, picture))
+		return
+	}
+	storage.DeleteAll(c.Request.Context(), storage.Default, []string{picture})
+}
+
+// profilePictureVersion versions the avatar URL. Every upload gets a new
+// storage key, so the key's hash changes with the picture.
+func profilePictureVersion(picture string) string {
+	if picture == "" {
+		return ""
+	}
+	return utils.ContentHash([]byte(picture))
+}
+
 // GetProfilePicture serves profile pictures
 func GetProfilePicture(c *gin.Context) {
 	userID := c.Param("user_id")
@@ -110,6 +144,63 @@ func GetProfilePicture(c *gin.Context) {
 		return
 	}
 
-	filepath := filepath.Join("static/images/profiles", user.ProfilePicture)
-	c.File(filepath)
+	// Only signed-in users see avatars, so shared caches must not keep them
+	if isLegacyProfilePicture(user.ProfilePicture) {
+		c.Header("Cache-Control", "private, no-cache")
+		c.File(filepath.Join(legacyProfilesDir, filepath.Base(user.ProfilePicture)))
+		return
+	}
+
+	image := loadBlobImage(c, user.ProfilePicture)
+	if image == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	cacheControl := "private, no-cache"
+	if version := c.Query("v"); version != "" && version == profilePictureVersion(user.ProfilePicture) {
+		cacheControl = "private, max-age=31536000, immutable"
+	}
+	writeImage(c, image, cacheControl)
+}
+
+// NotificationPreferenceRow is one line of the preferences table on Profile
+type NotificationPreferenceRow struct {
+	Key   string
+	Label string
+	InApp bool
+	Email string
+}
+
+func notificationPreferenceRows(userID int64) []NotificationPreferenceRow {
+	prefs := utils.LoadPreferences(config.DB, userID)
+	rows := make([]NotificationPreferenceRow, 0, len(utils.NotificationTypes))
+	for _, t := range utils.NotificationTypes {
+		pref := prefs[t.Key]
+		rows = append(rows, NotificationPreferenceRow{Key: t.Key, Label: t.Label, InApp: pref.InApp, Email: pref.Email})
+	}
+	return rows
+}
+
+// UpdateNotificationPreferences saves the in-app and email channel of every notification type
+func UpdateNotificationPreferences(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	tx := config.DB.Begin()
+	for _, t := range utils.NotificationTypes {
+		email := c.PostForm("email_" + t.Key)
+		if !utils.ValidEmailMode(email) {
+			email = "off"
+		}
+		pref := models.NotificationPreference{UserID: user.ID, Type: t.Key}
+		if err := tx.Where(pref).
+			Assign(map[string]interface{}{"in_app": c.PostForm("in_app_"+t.Key) == "1", "email": email}).
+			FirstOrCreate(&pref).Error; err != nil {
+			tx.Rollback()
+			c.Redirect(http.StatusFound, "/profile?error=save_failed#notifications")
+			return
+		}
+	}
+	tx.Commit()
+
+	c.Redirect(http.StatusFound, "/profile?saved=notifications#notifications")
 }
@@ -0,0 +1,665 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// identifierKindOptions lists the kinds for the registry and found forms
+func identifierKindOptions() []map[string]string {
+	kinds := make([]map[string]string, len(utils.IdentifierKinds))
+	for i, kind := range utils.IdentifierKinds {
+		kinds[i] = map[string]string{"value": kind, "label": utils.IdentifierKindLabels[kind]}
+	}
+	return kinds
+}
+
+// RegistryPage lists the identifiers a user registered and the found reports
+// they filed or that matched their belongings
+func RegistryPage(c *gin.Context) {
+	renderRegistry(c, "", nil)
+}
+
+// registryEntry is one row of the user's registered identifiers
+type registryEntry struct {
+	Label     string
+	Kind      string
+	Hint      string
+	CreatedAt time.Time
+	DeleteURL string
+}
+
+// registryEntries lists the user's identifiers together with their open
+// conflicting registrations, which look the same until an admin decides, so
+// the page never tells whether a number belonged to someone else
+func registryEntries(userID int64) []registryEntry {
+	var identifiers []models.Identifier
+	config.DB.Where("user_id = ?", userID).Find(&identifiers)
+	var conflicts []models.IdentifierConflict
+	config.DB.Preload("Identifier").Where("user_id = ? AND status = ?", userID, "open").Find(&conflicts)
+
+	entries := make([]registryEntry, 0, len(identifiers)+len(conflicts))
+	for _, identifier := range identifiers {
+		entries = append(entries, registryEntry{
+			Label:     identifier.Label,
+			Kind:      identifier.Kind,
+			Hint:      identifier.Hint,
+			CreatedAt: identifier.CreatedAt,
+			DeleteURL: fmt.Sprintf("/registry/%d/delete", identifier.ID),
+		})
+	}
+	for _, conflict := range conflicts {
+		entries = append(entries, registryEntry{
+			Label:     conflict.Label,
+			Kind:      conflict.Identifier.Kind,
+			Hint:      conflict.Identifier.Hint,
+			CreatedAt: conflict.CreatedAt,
+			DeleteURL: fmt.Sprintf("/registry/pending/%d/delete", conflict.ID),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	return entries
+}
+
+// renderRegistry renders the registry page, keeping the submitted form on errors
+func renderRegistry(c *gin.Context, errMsg string, form map[string]string) {
+	user := c.MustGet("user").(*models.User)
+
+	var matched []models.FoundReport
+	config.DB.Preload("Finder").Where("owner_id = ?", user.ID).Order("created_at DESC").Find(&matched)
+
+	var filed []models.FoundReport
+	config.DB.Where("finder_id = ?", user.ID).Order("created_at DESC").Find(&filed)
+
+	if form == nil {
+		form = map[string]string{"kind": "imei"}
+	}
+	utils.RenderTemplate(c, "templates/core/registry.html", map[string]interface{}{
+		"identifiers":    registryEntries(user.ID),
+		"matched":        matched,
+		"filed":          filed,
+		"kinds":          identifierKindOptions(),
+		"kind_labels":    utils.IdentifierKindLabels,
+		"form":           form,
+		"error":          errMsg,
+		"saved":          c.Query("saved"),
+		"max_identifier": config.MaxIdentifiersPerUser,
+	})
+}
+
+// RegisterIdentifier hashes and stores an identifier for the current user.
+// Found reports already filed with it are matched right away.
+func RegisterIdentifier(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	form := map[string]string{
+		"kind":  c.PostForm("kind"),
+		"label": strings.TrimSpace(c.PostForm("label")),
+	}
+	if form["label"] == "" || len([]rune(form["label"])) > 100 {
+		renderRegistry(c, "Nama barang wajib diisi (maks. 100 karakter)", form)
+		return
+	}
+	normalized, msg := utils.NormalizeIdentifier(form["kind"], c.PostForm("identifier"))
+	if msg != "" {
+		renderRegistry(c, msg, form)
+		return
+	}
+
+	// Open conflicts are listed as registered, so they count as well
+	var count, pending int64
+	config.DB.Model(&models.Identifier{}).Where("user_id = ?", user.ID).Count(&count)
+	config.DB.Model(&models.IdentifierConflict{}).Where("user_id = ? AND status = ?", user.ID, "open").Count(&pending)
+	if count+pending >= int64(config.MaxIdentifiersPerUser) {
+		renderRegistry(c, fmt.Sprintf("Maksimal %d nomor terdaftar", config.MaxIdentifiersPerUser), form)
+		return
+	}
+
+	hash := utils.HashIdentifier(normalized)
+	var existing models.Identifier
+	if config.DB.Where("hash = ?", hash).First(&existing).Error == nil {
+		if existing.UserID == user.ID {
+			renderRegistry(c, "Nomor ini sudah Anda daftarkan sebagai '"+existing.Label+"'", form)
+			return
+		}
+		// A repeated attempt gets the same answer as a repeated registration
+		var attempt models.IdentifierConflict
+		if config.DB.Where("identifier_id = ? AND user_id = ? AND status = ?", existing.ID, user.ID, "open").First(&attempt).Error == nil {
+			renderRegistry(c, "Nomor ini sudah Anda daftarkan sebagai '"+attempt.Label+"'", form)
+			return
+		}
+		// The first to register keeps it until an admin decides otherwise.
+		// The user gets the usual response and sees the number in their list,
+		// so the registry is no oracle.
+		if err := queueIdentifierConflict(&existing, user, form["label"]); err != nil {
+			renderRegistry(c, "Gagal menyimpan nomor", form)
+			return
+		}
+		c.Redirect(http.StatusFound, "/registry?saved=identifier")
+		return
+	}
+
+	identifier := models.Identifier{
+		UserID: user.ID,
+		Kind:   form["kind"],
+		Label:  form["label"],
+		Hash:   hash,
+		Hint:   utils.IdentifierHint(normalized),
+	}
+	tx := config.DB.Begin()
+	if err := tx.Create(&identifier).Error; err != nil {
+		tx.Rollback()
+		renderRegistry(c, "Gagal menyimpan nomor", form)
+		return
+	}
+
+	var reports []models.FoundReport
+	tx.Where("identifier_hash = ? AND owner_id IS NULL AND status = ? AND finder_id <> ?", hash, "open", user.ID).
+		Find(&reports)
+	for i := range reports {
+		if err := matchFoundReport(tx, &reports[i], identifier.UserID, identifierMatchMessage(&reports[i], &identifier)); err != nil {
+			tx.Rollback()
+			renderRegistry(c, "Gagal menyimpan nomor", form)
+			return
+		}
+	}
+	tx.Commit()
+
+	if len(reports) > 0 {
+		utils.NotificationHub.Publish(user.ID)
+	}
+	c.Redirect(http.StatusFound, "/registry?saved=identifier")
+}
+
+// queueIdentifierConflict records an attempt to register existing and asks
+// the admins to review it. Past config.ConflictsPerDay attempts a day the
+// user's conflicts still wait in the queue, but no longer notify the admins.
+func queueIdentifierConflict(existing *models.Identifier, user *models.User, label string) error {
+	var today int64
+	config.DB.Model(&models.IdentifierConflict{}).
+		Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-24*time.Hour)).
+		Count(&today)
+
+	conflict := models.IdentifierConflict{IdentifierID: existing.ID, UserID: user.ID, Label: label, Status: "open"}
+	tx := config.DB.Begin()
+	if err := tx.Create(&conflict).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if today >= int64(config.ConflictsPerDay) {
+		return tx.Commit().Error
+	}
+	admins, err := notifyAdmins(tx, "Konflik Nomor Terdaftar",
+		fmt.Sprintf("%s mencoba mendaftarkan %s %s yang sudah terdaftar atas nama pengguna lain.",
+			user.Username, utils.IdentifierKindLabels[existing.Kind], existing.Hint),
+		"/admin/registry/conflicts", nil, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Commit()
+
+	utils.NotificationHub.Publish(admins...)
+	return nil
+}
+
+// AdminIdentifierConflicts lists attempts to register identifiers that
+// already belonged to someone else
+func AdminIdentifierConflicts(c *gin.Context) {
+	showAll := c.Query("show") == "all"
+	query := config.DB.Preload("User").Preload("Identifier.User").Order("created_at DESC").Limit(200)
+	if !showAll {
+		query = query.Where("status = ?", "open")
+	}
+	var conflicts []models.IdentifierConflict
+	query.Find(&conflicts)
+
+	utils.RenderTemplate(c, "templates/admin_identifier_conflicts.html", map[string]interface{}{
+		"conflicts":   conflicts,
+		"kind_labels": utils.IdentifierKindLabels,
+		"show_all":    showAll,
+	})
+}
+
+// ResolveIdentifierConflict either moves the identifier to the user who
+// tried to register it or dismisses the attempt
+func ResolveIdentifierConflict(c *gin.Context) {
+	admin := c.MustGet("user").(*models.User)
+	action := c.PostForm("action")
+	if action != "transfer" && action != "dismiss" {
+		c.String(http.StatusBadRequest, "Invalid action")
+		return
+	}
+
+	var conflict models.IdentifierConflict
+	if err := config.DB.Preload("Identifier").Where("id = ? AND status = ?", c.Param("id"), "open").First(&conflict).Error; err != nil {
+		c.String(http.StatusNotFound, "Konflik tidak ditemukan")
+		return
+	}
+
+	now := time.Now()
+	status := "dismissed"
+	if action == "transfer" {
+		status = "transferred"
+	}
+	tx := config.DB.Begin()
+	if err := tx.Model(&conflict).Updates(map[string]interface{}{
+		"status":         status,
+		"resolved_by_id": admin.ID,
+		"resolved_at":    now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Gagal menyimpan")
+		return
+	}
+
+	var notified []int64
+	if action == "transfer" {
+		previousOwner := conflict.Identifier.UserID
+		if err := tx.Model(&conflict.Identifier).Updates(map[string]interface{}{
+			"user_id": conflict.UserID,
+			"label":   conflict.Label,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.String(http.StatusInternalServerError, "Gagal memindahkan nomor")
+			return
+		}
+		for _, n := range []models.Notification{
+			{
+				UserID:       conflict.UserID,
+				Type:         "found_item",
+				Title:        "Nomor terdaftar atas nama Anda",
+				Message:      fmt.Sprintf("Admin memverifikasi bahwa '%s' (%s) milik Anda.", conflict.Label, conflict.Identifier.Hint),
+				ReferenceURL: "/registry",
+			},
+			{
+				UserID:       previousOwner,
+				Type:         "found_item",
+				Title:        "Nomor terdaftar dipindahkan",
+				Message:      fmt.Sprintf("Admin memindahkan '%s' (%s) ke pemilik yang terverifikasi. Hubungi admin jika ini keliru.", conflict.Identifier.Label, conflict.Identifier.Hint),
+				ReferenceURL: "/registry",
+			},
+		} {
+			if err := utils.Notify(tx, &n); err != nil {
+				tx.Rollback()
+				c.String(http.StatusInternalServerError, "Gagal mengirim notifikasi")
+				return
+			}
+			notified = append(notified, n.UserID)
+		}
+		// Other open attempts on the same identifier are settled too
+		if err := tx.Model(&models.IdentifierConflict{}).
+			Where("identifier_id = ? AND status = ? AND id <> ?", conflict.IdentifierID, "open", conflict.ID).
+			Updates(map[string]interface{}{"status": "dismissed", "resolved_by_id": admin.ID, "resolved_at": now}).Error; err != nil {
+			tx.Rollback()
+			c.String(http.StatusInternalServerError, "Gagal menyimpan")
+			return
+		}
+	}
+	tx.Commit()
+
+	utils.NotificationHub.Publish(notified...)
+	c.Redirect(http.StatusFound, "/admin/registry/conflicts")
+}
+
+// DeleteIdentifier removes one of the user's registered identifiers
+func DeleteIdentifier(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).Delete(&models.Identifier{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.String(http.StatusNotFound, "Nomor tidak ditemukan")
+		return
+	}
+	c.Redirect(http.StatusFound, "/registry")
+}
+
+// WithdrawIdentifierConflict removes one of the user's pending entries, the
+// counterpart of DeleteIdentifier for a registration an admin has not decided
+func WithdrawIdentifierConflict(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	result := config.DB.Model(&models.IdentifierConflict{}).
+		Where("id = ? AND user_id = ? AND status = ?", c.Param("id"), user.ID, "open").
+		Update("status", "withdrawn")
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.String(http.StatusNotFound, "Nomor tidak ditemukan")
+		return
+	}
+	c.Redirect(http.StatusFound, "/registry")
+}
+
+// matchFoundReport assigns a found report to an owner and notifies them.
+// Matches come from a registered identifier, which never leaves the hash,
+// or from a belonging tag the finder scanned.
+func matchFoundReport(tx *gorm.DB, report *models.FoundReport, ownerID int64, message string) error {
+	now := time.Now()
+	report.OwnerID = &ownerID
+	report.MatchedAt = &now
+	if err := tx.Model(report).Updates(map[string]interface{}{
+		"owner_id":   ownerID,
+		"matched_at": now,
+	}).Error; err != nil {
+		return err
+	}
+
+	return utils.Notify(tx, &models.Notification{
+		UserID:       ownerID,
+		Type:         "found_item",
+		Title:        "Barang terdaftar Anda ditemukan",
+		Message:      message,
+		ReferenceURL: fmt.Sprintf("/found/%d", report.ID),
+	})
+}
+
+// identifierMatchMessage tells the owner which registered item a report matched
+func identifierMatchMessage(report *models.FoundReport, identifier *models.Identifier) string {
+	where := ""
+	if report.Location != "" {
+		where = " di " + report.Location
+	}
+	return fmt.Sprintf("Seseorang menemukan '%s'%s dengan %s yang cocok dengan '%s' (%s) yang Anda daftarkan.",
+		report.Title, where, utils.IdentifierKindLabels[report.IdentifierKind], identifier.Label, identifier.Hint)
+}
+
+// tagMatchMessage tells the owner a finder reported an item from its tag
+func tagMatchMessage(report *models.FoundReport, tag *models.BelongingTag) string {
+	where := ""
+	if report.Location != "" {
+		where = " di " + report.Location
+	}
+	return fmt.Sprintf("Seseorang memindai tag '%s' dan melaporkan temuan '%s'%s.", tag.Label, report.Title, where)
+}
+
+// FoundReportPage shows the form for reporting a found item. Coming from a
+// tag's page, the report goes straight to the tag's owner.
+func FoundReportPage(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	ctx := map[string]interface{}{
+		"kinds": identifierKindOptions(),
+		"form":  map[string]string{"kind": "imei"},
+	}
+	if tag, ok := scannedTag(c.Query("tag"), user); ok {
+		ctx["tag"] = tag
+		ctx["form"] = map[string]string{"kind": "imei", "title": tag.Label}
+	}
+	utils.RenderTemplate(c, "templates/core/found_report.html", ctx)
+}
+
+// scannedTag loads an active tag a finder came from; the owner's own tags
+// do not count
+func scannedTag(code string, user *models.User) (*models.BelongingTag, bool) {
+	if code == "" {
+		return nil, false
+	}
+	var tag models.BelongingTag
+	if err := config.DB.Where("code = ? AND is_active = ?", code, true).First(&tag).Error; err != nil || tag.UserID == user.ID {
+		return nil, false
+	}
+	return &tag, true
+}
+
+// SubmitFoundReport files a finder's report. An identifier is hashed and
+// matched against the registry; the finder only learns whether an owner was
+// notified, never who.
+func SubmitFoundReport(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	if utils.IsRestricted(user) {
+		c.String(http.StatusForbidden, "Your account has been banned or suspended. You cannot report found items.")
+		return
+	}
+
+	form := map[string]string{
+		"title":       strings.TrimSpace(c.PostForm("title")),
+		"description": strings.TrimSpace(c.PostForm("description")),
+		"location":    strings.TrimSpace(c.PostForm("location")),
+		"kind":        c.PostForm("kind"),
+	}
+	tag, fromTag := scannedTag(c.PostForm("tag"), user)
+	renderError := func(msg string) {
+		ctx := map[string]interface{}{
+			"kinds": identifierKindOptions(),
+			"form":  form,
+			"error": msg,
+		}
+		if fromTag {
+			ctx["tag"] = tag
+		}
+		utils.RenderTemplate(c, "templates/core/found_report.html", ctx)
+	}
+
+	if form["title"] == "" || len([]rune(form["title"])) > 200 {
+		renderError("Nama barang wajib diisi (maks. 200 karakter)")
+		return
+	}
+	if len([]rune(form["description"])) > 2000 || len([]rune(form["location"])) > 255 {
+		renderError("Deskripsi atau lokasi terlalu panjang")
+		return
+	}
+
+	report := models.FoundReport{
+		FinderID:    user.ID,
+		Title:       form["title"],
+		Description: form["description"],
+		Location:    form["location"],
+		Status:      "open",
+	}
+	if fromTag {
+		report.TagID = &tag.ID
+	}
+	if raw := strings.TrimSpace(c.PostForm("identifier")); raw != "" {
+		normalized, msg := utils.NormalizeIdentifier(form["kind"], raw)
+		if msg != "" {
+			renderError(msg)
+			return
+		}
+		report.IdentifierKind = form["kind"]
+		report.IdentifierHash = utils.HashIdentifier(normalized)
+		report.IdentifierHint = utils.IdentifierHint(normalized)
+	}
+
+	// Each report answers whether an identifier is registered, so finders
+	// get a daily allowance
+	var recent int64
+	config.DB.Model(&models.FoundReport{}).
+		Where("finder_id = ? AND created_at > ?", user.ID, time.Now().Add(-24*time.Hour)).
+		Count(&recent)
+	if recent >= int64(config.FoundReportsPerDay) {
+		renderError(fmt.Sprintf("Maksimal %d laporan temuan per hari", config.FoundReportsPerDay))
+		return
+	}
+
+	// The owner reads the report, so blocking rules apply; it is never
+	// public, so there is nothing to hold
+	if moderation := utils.CheckContent(config.DB, "items", report.Title, report.Description, report.Location); moderation.Blocked() {
+		renderError(blockedContentMessage(moderation))
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Create(&report).Error; err != nil {
+		tx.Rollback()
+		renderError("Gagal menyimpan laporan")
+		return
+	}
+	var matchErr error
+	if fromTag {
+		matchErr = matchFoundReport(tx, &report, tag.UserID, tagMatchMessage(&report, tag))
+	} else if report.IdentifierHash != "" {
+		var identifier models.Identifier
+		if tx.Where("hash = ? AND user_id <> ?", report.IdentifierHash, user.ID).First(&identifier).Error == nil {
+			matchErr = matchFoundReport(tx, &report, identifier.UserID, identifierMatchMessage(&report, &identifier))
+		}
+	}
+	if matchErr != nil {
+		tx.Rollback()
+		renderError("Gagal menyimpan laporan")
+		return
+	}
+	tx.Commit()
+
+	if report.OwnerID != nil {
+		utils.NotificationHub.Publish(*report.OwnerID)
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/found/%d", report.ID))
+}
+
+// loadFoundReport fetches a found report visible to the finder, the matched
+// owner and admins
+func loadFoundReport(c *gin.Context, user *models.User) (*models.FoundReport, bool) {
+	var report models.FoundReport
+	if err := config.DB.Preload("Finder").Preload("Owner").Preload("Item").First(&report, c.Param("id")).Error; err != nil {
+		c.String(http.StatusNotFound, "Laporan tidak ditemukan")
+		return nil, false
+	}
+	isOwner := report.OwnerID != nil && *report.OwnerID == user.ID
+	if report.FinderID != user.ID && !isOwner && !user.IsSuperuser {
+		c.String(http.StatusNotFound, "Laporan tidak ditemukan")
+		return nil, false
+	}
+	return &report, true
+}
+
+// FoundReportDetail shows a found report. The owner sees which of their
+// registered items matched and can link it to one of their lost posts.
+func FoundReportDetail(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	report, ok := loadFoundReport(c, user)
+	if !ok {
+		return
+	}
+	isOwner := report.OwnerID != nil && *report.OwnerID == user.ID
+
+	ctx := map[string]interface{}{
+		"report":      report,
+		"is_finder":   report.FinderID == user.ID,
+		"is_owner":    isOwner,
+		"kind_labels": utils.IdentifierKindLabels,
+		"error":       c.Query("error"),
+	}
+	if isOwner {
+		var identifier models.Identifier
+		if report.IdentifierHash != "" && config.DB.Where("hash = ? AND user_id = ?", report.IdentifierHash, user.ID).First(&identifier).Error == nil {
+			ctx["identifier"] = identifier
+		}
+		var tag models.BelongingTag
+		if report.TagID != nil && config.DB.Where("id = ? AND user_id = ?", *report.TagID, user.ID).First(&tag).Error == nil {
+			ctx["tag"] = tag
+		}
+		if report.Status == "open" {
+			var posts []models.LostItem
+			config.DB.Select("id", "title", "created_at").
+				Where("user_id = ? AND status = ?", user.ID, "LOST").
+				Order("created_at DESC").
+				Find(&posts)
+			ctx["posts"] = posts
+		}
+	}
+	utils.RenderTemplate(c, "templates/core/found_detail.html", ctx)
+}
+
+// LinkFoundReport attaches a matched report to the owner's lost post: the
+// finder becomes a claimant there, so the usual messages, finder selection
+// and return confirmation take over.
+func LinkFoundReport(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	report, ok := loadFoundReport(c, user)
+	if !ok {
+		return
+	}
+	if report.OwnerID == nil || *report.OwnerID != user.ID || report.Status != "open" {
+		c.String(http.StatusForbidden, "Not authorized")
+		return
+	}
+
+	var item models.LostItem
+	if err := config.DB.Where("id = ? AND user_id = ? AND status = ?", c.PostForm("item_id"), user.ID, "LOST").
+		First(&item).Error; err != nil {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/found/%d?error=post", report.ID))
+		return
+	}
+
+	tx := config.DB.Begin()
+	var claim models.ItemClaim
+	created := false
+	if err := tx.Where("item_id = ? AND user_id = ?", item.ID, report.FinderID).First(&claim).Error; err != nil {
+		claim = models.ItemClaim{ItemID: item.ID, UserID: report.FinderID}
+		if err := tx.Create(&claim).Error; err != nil {
+			tx.Rollback()
+			c.String(http.StatusInternalServerError, "Gagal menghubungkan laporan")
+			return
+		}
+		created = true
+	}
+	if err := tx.Model(report).Updates(map[string]interface{}{
+		"item_id": item.ID,
+		"status":  "linked",
+	}).Error; err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Gagal menghubungkan laporan")
+		return
+	}
+	if err := utils.Notify(tx, &models.Notification{
+		UserID:        report.FinderID,
+		Type:          "found_item",
+		Title:         "Pemilik barang temuan Anda merespons",
+		Message:       fmt.Sprintf("Pemilik '%s' menghubungkan laporan Anda ke postingannya. Anda tercatat sebagai penemu.", report.Title),
+		ReferenceURL:  fmt.Sprintf("/item/%d", item.ID),
+		RelatedItemID: &item.ID,
+	}); err != nil {
+		tx.Rollback()
+		c.String(http.StatusInternalServerError, "Gagal menghubungkan laporan")
+		return
+	}
+	tx.Commit()
+
+	if created {
+		if err := utils.QueueWebhook(config.DB, "claim.created", claimWebhookData(&claim)); err != nil {
+			log.Printf("[webhook] queue claim %d: %v", claim.ID, err)
+		}
+	}
+	utils.NotificationHub.Publish(report.FinderID)
+	c.Redirect(http.StatusFound, fmt.Sprintf("/item/%d", item.ID))
+}
+
+// unlinkFoundReports reopens the found reports linked to a post being
+// deleted, so the owner can link them to another post
+func unlinkFoundReports(tx *gorm.DB, itemID int64) error {
+	return tx.Model(&models.FoundReport{}).Where("item_id = ?", itemID).Updates(map[string]interface{}{
+		"item_id": nil,
+		"status":  "open",
+	}).Error
+}
+
+// CloseFoundReport lets the finder or the owner close a report, such as when
+// the item was handed over in person or turned in elsewhere
+func CloseFoundReport(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	report, ok := loadFoundReport(c, user)
+	if !ok {
+		return
+	}
+	isOwner := report.OwnerID != nil && *report.OwnerID == user.ID
+	if report.FinderID != user.ID && !isOwner {
+		c.String(http.StatusForbidden, "Not authorized")
+		return
+	}
+
+	config.DB.Model(report).Update("status", "closed")
+	c.Redirect(http.StatusFound, fmt.Sprintf("/found/%d", report.ID))
+}
@@ -1,25 +1,40 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"temuin/config"
 	"temuin/models"
+	"temuin/storage"
 	"temuin/utils"
+	"time"
 
 	"github.com/flosch/pongo2/v6"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// validReportReasons are the reason codes accepted on a report
+var validReportReasons = map[string]bool{
+	"fraud":          true,
+	"spam":           true,
+	"buying_selling": true,
+	"inappropriate":  true,
+	"other":          true,
+}
+
 // SubmitReport handles report submission from users
 func SubmitReport(c *gin.Context) {
 	itemID := c.Param("pk")
 	user := c.MustGet("user").(*models.User)
 
-	// Check if user is banned
-	if user.IsBanned {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been banned."})
+	// Check if user is banned or suspended
+	if utils.IsRestricted(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been banned or suspended."})
 		return
 	}
 
@@ -28,15 +43,7 @@ func SubmitReport(c *gin.Context) {
 	description := c.PostForm("description")
 
 	// Validate reason
-	validReasons := map[string]bool{
-		"fraud":          true,
-		"spam":           true,
-		"buying_selling": true,
-		"inappropriate":  true,
-		"other":          true,
-	}
-
-	if !validReasons[reason] {
+	if !validReportReasons[reason] {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report reason"})
 		return
 	}
@@ -62,19 +69,43 @@ func SubmitReport(c *gin.Context) {
 		return
 	}
 
-	// Create report
+	evidence, errMsg := readReportEvidence(c)
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	// Create report with its SLA deadline
+	dueAt := time.Now().Add(config.ReportSLA(reason))
 	report := models.ItemReport{
 		ItemID:      iid,
-		ReporterID:  user.ID,
+		ReporterID:  &user.ID,
 		Reason:      reason,
 		Description: description,
 		Status:      "pending",
+		DueAt:       &dueAt,
+		Evidence:    evidence,
 	}
 
+	// Evidence goes to blob storage like item photos; its rows are created
+	// together with the report
+	blobs := newBlobWrites(c)
+	for i := range report.Evidence {
+		key, err := blobs.putEvidence(iid, report.Evidence[i].ImageData, report.Evidence[i].ContentType)
+		if err != nil {
+			blobs.rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store evidence"})
+			return
+		}
+		report.Evidence[i].BlobKey = key
+		report.Evidence[i].ImageData = nil
+	}
 	if err := config.DB.Create(&report).Error; err != nil {
+		blobs.rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
 		return
 	}
+	utils.QueueWebhook(config.DB, "report.created", reportWebhookData(&report))
 
 	// Create notification for all admins
 	var admins []models.User
@@ -98,39 +129,297 @@ func SubmitReport(c *gin.Context) {
 			RelatedItemID:   &iid,
 			RelatedReportID: &report.ID,
 		}
-		config.DB.Create(&notification)
+		if utils.Notify(config.DB, &notification) == nil {
+			utils.NotificationHub.Publish(admin.ID)
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Laporan berhasil dikirim"})
 }
 
-// AdminReportList displays all reports for admin
+// evidenceContentTypes are the sniffed image types accepted as report evidence
+var evidenceContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// readReportEvidence reads the optional "evidence" files from the multipart form,
+// enforcing the count, size and type limits. Returns a user-facing message on failure.
+func readReportEvidence(c *gin.Context) ([]models.ReportEvidence, string) {
+	form, err := c.MultipartForm()
+	if err != nil || form.File["evidence"] == nil {
+		return nil, ""
+	}
+
+	files := form.File["evidence"]
+	if len(files) > config.EvidenceMaxFiles {
+		return nil, fmt.Sprintf("Maksimal %d gambar bukti per laporan", config.EvidenceMaxFiles)
+	}
+
+	maxSize := int64(config.EvidenceMaxSizeMB) << 20
+	var evidence []models.ReportEvidence
+	for _, fh := range files {
+		if fh.Size > maxSize {
+			return nil, fmt.Sprintf("Ukuran gambar %s melebihi %d MB", fh.Filename, config.EvidenceMaxSizeMB)
+		}
+
+		file, err := fh.Open()
+		if err != nil {
+			return nil, "Gagal membaca gambar bukti"
+		}
+		data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+		file.Close()
+		if err != nil {
+			return nil, "Gagal membaca gambar bukti"
+		}
+		if int64(len(data)) > maxSize {
+			return nil, fmt.Sprintf("Ukuran gambar %s melebihi %d MB", fh.Filename, config.EvidenceMaxSizeMB)
+		}
+
+		// Trust the file contents, not the client-supplied name or header
+		contentType := http.DetectContentType(data)
+		if !evidenceContentTypes[contentType] {
+			return nil, fmt.Sprintf("Format %s tidak didukung (JPG, PNG, GIF, atau WEBP)", fh.Filename)
+		}
+
+		evidence = append(evidence, models.ReportEvidence{
+			ImageData:   data,
+			ContentType: contentType,
+			Size:        len(data),
+		})
+	}
+	return evidence, ""
+}
+
+// GetReportEvidence serves an evidence image to admins
+func GetReportEvidence(c *gin.Context) {
+	var evidence models.ReportEvidence
+	if err := config.DB.First(&evidence, c.Param("id")).Error; err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	data := evidence.ImageData
+	if evidence.BlobKey != "" {
+		obj, err := storage.Default.Get(c.Request.Context(), evidence.BlobKey)
+		if err != nil {
+			if !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("[evidence] load %s: %v", evidence.BlobKey, err)
+			}
+			c.Status(http.StatusNotFound)
+			return
+		}
+		data = obj.Data
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, evidence.ContentType, data)
+}
+
+// openReportStatuses are the statuses still waiting on a moderator decision
+var openReportStatuses = []string{"pending", "in_review", "reviewed"}
+
+const reportsPerPage = 20
+
+// ReportGroup is every report filed against one item, shown as a single queue row
+type ReportGroup struct {
+	Item          models.LostItem
+	Reports       []models.ItemReport
+	Conversations []models.Conversation
+	ReportCount   int
+	Reasons       []string
+	Status        string
+	Assignee      *models.User
+	DueAt         *time.Time
+	Overdue       bool
+	DueSoon       bool
+}
+
+// reportGroupRow is the aggregate row scanned from the grouped queue query
+type reportGroupRow struct {
+	ItemID     int64
+	FirstDueAt *time.Time
+}
+
+// reportQueueFilter narrows the queue by the query string filters
+func reportQueueFilter(c *gin.Context, user *models.User) func(*gorm.DB) *gorm.DB {
+	status := c.DefaultQuery("status", "open")
+	reason := c.Query("reason")
+	assignee := c.Query("assignee")
+	itemID := c.Query("item")
+
+	return func(db *gorm.DB) *gorm.DB {
+		switch status {
+		case "all":
+		case "open":
+			db = db.Where("core_itemreport.status IN ?", openReportStatuses)
+		default:
+			db = db.Where("core_itemreport.status = ?", status)
+		}
+		if reason != "" {
+			db = db.Where("core_itemreport.reason = ?", reason)
+		}
+		switch assignee {
+		case "":
+		case "me":
+			db = db.Where("core_itemreport.assignee_id = ?", user.ID)
+		case "unassigned":
+			db = db.Where("core_itemreport.assignee_id IS NULL")
+		default:
+			db = db.Where("core_itemreport.assignee_id = ?", assignee)
+		}
+		if itemID != "" {
+			db = db.Where("core_itemreport.item_id = ?", itemID)
+		}
+		return db
+	}
+}
+
+// AdminReportList displays the report queue grouped per item
 func AdminReportList(c *gin.Context) {
-	// Get all reports
-	var reports []models.ItemReport
-	config.DB.Preload("Item.User").Preload("Item").Preload("Reporter").Order("created_at DESC").Find(&reports)
+	user := c.MustGet("user").(*models.User)
+
+	// A highlight coming from a notification jumps to that report's item
+	var highlightID int64
+	if hid, err := strconv.ParseInt(c.Query("highlight"), 10, 64); err == nil {
+		highlightID = hid
+		if c.Query("item") == "" {
+			var highlighted models.ItemReport
+			if config.DB.First(&highlighted, hid).Error == nil {
+				q := c.Request.URL.Query()
+				q.Set("item", strconv.FormatInt(highlighted.ItemID, 10))
+				q.Set("status", "all")
+				c.Request.URL.RawQuery = q.Encode()
+			}
+		}
+	}
+
+	filter := reportQueueFilter(c, user)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	// Count distinct items for pagination
+	var totalGroups int64
+	filter(config.DB.Model(&models.ItemReport{})).Distinct("item_id").Count(&totalGroups)
+	totalPages := int((totalGroups + reportsPerPage - 1) / reportsPerPage)
+
+	// Most urgent items first
+	var rows []reportGroupRow
+	filter(config.DB.Model(&models.ItemReport{})).
+		Select("item_id, MIN(due_at) AS first_due_at").
+		Group("item_id").
+		Order("first_due_at ASC, item_id ASC").
+		Limit(reportsPerPage).
+		Offset((page - 1) * reportsPerPage).
+		Scan(&rows)
+
+	groups := make([]ReportGroup, 0, len(rows))
+	if len(rows) > 0 {
+		itemIDs := make([]int64, len(rows))
+		for i, row := range rows {
+			itemIDs[i] = row.ItemID
+		}
+
+		var items []models.LostItem
+		config.DB.Preload("User").Where("id IN ?", itemIDs).Find(&items)
+		itemByID := make(map[int64]models.LostItem, len(items))
+		for _, item := range items {
+			itemByID[item.ID] = item
+		}
+
+		var reports []models.ItemReport
+		// Evidence blobs are served separately, only their metadata is needed here
+		withEvidence := config.DB.Preload("Reporter").Preload("Assignee").Preload("Rule").
+			Preload("Evidence", func(db *gorm.DB) *gorm.DB {
+				return db.Select("id", "report_id", "content_type", "size", "created_at")
+			})
+		filter(withEvidence).
+			Where("item_id IN ?", itemIDs).
+			Order("created_at ASC").
+			Find(&reports)
+		reportsByItem := make(map[int64][]models.ItemReport)
+		for _, report := range reports {
+			reportsByItem[report.ItemID] = append(reportsByItem[report.ItemID], report)
+		}
+
+		// Private threads on reported items are open to moderators
+		var conversations []models.Conversation
+		config.DB.Preload("Claimant").Where("item_id IN ?", itemIDs).Order("last_message_at DESC").Find(&conversations)
+		conversationsByItem := make(map[int64][]models.Conversation)
+		for _, conversation := range conversations {
+			conversationsByItem[conversation.ItemID] = append(conversationsByItem[conversation.ItemID], conversation)
+		}
+
+		now := time.Now()
+		for _, row := range rows {
+			group := ReportGroup{
+				Item:          itemByID[row.ItemID],
+				Reports:       reportsByItem[row.ItemID],
+				Conversations: conversationsByItem[row.ItemID],
+				DueAt:         row.FirstDueAt,
+			}
+			group.ReportCount = len(group.Reports)
+
+			seenReason := make(map[string]bool)
+			for _, report := range group.Reports {
+				if !seenReason[report.Reason] {
+					seenReason[report.Reason] = true
+					group.Reasons = append(group.Reasons, report.Reason)
+				}
+				if group.Assignee == nil && report.Assignee != nil {
+					group.Assignee = report.Assignee
+				}
+			}
+			group.Status = groupStatus(group.Reports)
+
+			isOpen := group.Status != "resolved" && group.Status != "dismissed"
+			if isOpen && group.DueAt != nil {
+				group.Overdue = group.DueAt.Before(now)
+				group.DueSoon = !group.Overdue && group.DueAt.Before(now.Add(2*time.Hour))
+			}
+			groups = append(groups, group)
+		}
+	}
 
 	// Get statistics
 	var totalReports int64
 	var pendingReports int64
 	var resolvedReports int64
+	var overdueReports int64
 
 	config.DB.Model(&models.ItemReport{}).Count(&totalReports)
-	config.DB.Model(&models.ItemReport{}).Where("status = ?", "pending").Count(&pendingReports)
-	config.DB.Model(&models.ItemReport{}).Where("status = ?", "resolved").Count(&resolvedReports)
+	config.DB.Model(&models.ItemReport{}).Where("status IN ?", openReportStatuses).Count(&pendingReports)
+	config.DB.Model(&models.ItemReport{}).Where("status IN ?", []string{"resolved", "dismissed"}).Count(&resolvedReports)
+	config.DB.Model(&models.ItemReport{}).Where("status IN ? AND due_at < ?", openReportStatuses, time.Now()).Count(&overdueReports)
+
+	// Moderators that reports can be assigned to
+	var moderators []models.User
+	config.DB.Where("is_superuser = ?", true).Order("username ASC").Find(&moderators)
 
 	ctx := utils.GetGlobalContext(c)
-	ctx["reports"] = reports
+	ctx["groups"] = groups
+	ctx["moderators"] = moderators
 	ctx["total_reports"] = totalReports
 	ctx["pending_reports"] = pendingReports
 	ctx["resolved_reports"] = resolvedReports
-
-	// Check for highlight parameter
-	highlightID := c.Query("highlight")
-	if highlightID != "" {
-		if hid, err := strconv.ParseInt(highlightID, 10, 64); err == nil {
-			ctx["highlight_id"] = hid
-		}
+	ctx["overdue_reports"] = overdueReports
+	ctx["page"] = page
+	ctx["total_pages"] = totalPages
+	ctx["has_prev"] = page > 1
+	ctx["has_next"] = page < totalPages
+	ctx["prev_page"] = page - 1
+	ctx["next_page"] = page + 1
+	ctx["filter_status"] = c.DefaultQuery("status", "open")
+	ctx["filter_reason"] = c.Query("reason")
+	ctx["filter_assignee"] = c.Query("assignee")
+	ctx["filter_item"] = c.Query("item")
+	if highlightID != 0 {
+		ctx["highlight_id"] = highlightID
 	}
 
 	tpl, err := pongo2.FromFile("templates/admin_reports.html")
@@ -146,39 +435,126 @@ func AdminReportList(c *gin.Context) {
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out))
 }
 
+// groupStatus returns the least advanced status among a group's reports
+func groupStatus(reports []models.ItemReport) string {
+	order := []string{"pending", "in_review", "reviewed", "resolved", "dismissed"}
+	for _, status := range order {
+		for _, report := range reports {
+			if report.Status == status {
+				return status
+			}
+		}
+	}
+	return "pending"
+}
+
+// detachReports clears audit trail references and evidence of an item's
+// reports so they can be deleted. Stored evidence goes once the caller commits.
+func detachReports(tx *gorm.DB, blobs *blobWrites, itemID int64) error {
+	reportIDs := tx.Model(&models.ItemReport{}).Select("id").Where("item_id = ?", itemID)
+	if err := tx.Model(&models.ModerationAction{}).Where("report_id IN (?)", reportIDs).Update("report_id", nil).Error; err != nil {
+		return err
+	}
+	var evidenceKeys []string
+	if err := tx.Model(&models.ReportEvidence{}).Where("report_id IN (?) AND blob_key <> ''", reportIDs).Pluck("blob_key", &evidenceKeys).Error; err != nil {
+		return err
+	}
+	blobs.discardKeys(evidenceKeys...)
+	if err := tx.Where("report_id IN (?)", reportIDs).Delete(&models.ReportEvidence{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("related_report_id IN (?)", reportIDs).Delete(&models.Notification{}).Error
+}
+
+// closeReports marks the given reports as handled and tells each reporter the
+// outcome. Returns the reporters to publish to after commit.
+func closeReports(tx *gorm.DB, reports []models.ItemReport, status, outcome string) ([]int64, error) {
+	now := time.Now()
+	for i := range reports {
+		reports[i].Status = status
+		reports[i].Outcome = outcome
+		reports[i].ResolvedAt = &now
+		if err := tx.Model(&reports[i]).Updates(map[string]interface{}{
+			"status":      status,
+			"outcome":     outcome,
+			"resolved_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return notifyReporters(tx, reports, outcome)
+}
+
+// notifyReporters sends each reporter the moderation outcome of their report
+// and returns the reporters to publish to after commit
+func notifyReporters(tx *gorm.DB, reports []models.ItemReport, outcome string) ([]int64, error) {
+	outcomeText := map[string]string{
+		"warned":    "pemilik postingan telah diberi teguran",
+		"removed":   "postingan tersebut telah dihapus",
+		"dismissed": "admin tidak menemukan pelanggaran",
+		"resolved":  "laporan telah ditindaklanjuti",
+	}
+
+	var notified []int64
+	for _, report := range reports {
+		// Automatic flags have nobody to notify
+		if report.ReporterID == nil {
+			continue
+		}
+		notification := models.Notification{
+			UserID:  *report.ReporterID,
+			Type:    "report",
+			Title:   "Laporan Anda Telah Ditinjau",
+			Message: fmt.Sprintf("Terima kasih atas laporan Anda atas postingan '%s'. Hasil peninjauan: %s.", report.Item.Title, outcomeText[outcome]),
+		}
+		// Removed items no longer exist, so don't link to them
+		if outcome != "removed" {
+			itemID := report.ItemID
+			notification.ReferenceURL = fmt.Sprintf("/item/%d", report.ItemID)
+			notification.RelatedItemID = &itemID
+		}
+		if err := utils.Notify(tx, &notification); err != nil {
+			return nil, err
+		}
+		notified = append(notified, notification.UserID)
+	}
+	return notified, nil
+}
+
 // ResolveReport marks a report as resolved
 func ResolveReport(c *gin.Context) {
 	reportID := c.Param("id")
 
+	// Only open reports, like the bulk actions, so a closed one is not reopened
+	// with another outcome or its reporter notified twice
 	var report models.ItemReport
-	if err := config.DB.First(&report, reportID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+	if err := config.DB.Preload("Item").Where("status IN ?", openReportStatuses).First(&report, reportID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found or already closed"})
 		return
 	}
 
-	report.Status = "resolved"
-	if err := config.DB.Save(&report).Error; err != nil {
+	outcome := report.Outcome
+	if outcome == "" {
+		outcome = "resolved"
+	}
+
+	tx := config.DB.Begin()
+	notified, err := closeReports(tx, []models.ItemReport{report}, "resolved", outcome)
+	if err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report"})
 		return
 	}
+	tx.Commit()
+	utils.NotificationHub.Publish(notified...)
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Report resolved"})
 }
 
-// WarnUser sends a warning notification to the post owner
-func WarnUser(c *gin.Context) {
-	reportID := c.Param("id")
-
-	var report models.ItemReport
-	if err := config.DB.Preload("Item").Preload("Item.User").First(&report, reportID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
-		return
-	}
-
-	// Mark report as reviewed
-	report.Status = "reviewed"
-	config.DB.Save(&report)
-
+// warnItemOwner adds a strike to the reported post's owner and sends them a warning.
+// Returns the escalation outcome from utils.ApplyStrike and the new strike count;
+// the caller publishes to the owner after commit.
+func warnItemOwner(tx *gorm.DB, admin *models.User, report *models.ItemReport) (string, int, error) {
 	reasonText := map[string]string{
 		"fraud":          "penipuan",
 		"spam":           "spam",
@@ -187,17 +563,157 @@ func WarnUser(c *gin.Context) {
 		"other":          "pelanggaran",
 	}
 
+	owner := report.Item.User
+	strikeReason := fmt.Sprintf("Postingan '%s' dilaporkan karena %s", report.Item.Title, reasonText[report.Reason])
+	outcome, err := utils.ApplyStrike(tx, &owner, strikeReason, &admin.ID, &report.ID)
+	if err != nil {
+		return "", 0, err
+	}
+
 	// Create warning notification for post owner
+	message := fmt.Sprintf("Postingan '%s' Anda telah dilaporkan karena %s dan ditinjau oleh admin. Mohon patuhi aturan komunitas kami. Teguran ke-%d dari batas %d.", report.Item.Title, reasonText[report.Reason], owner.StrikeCount, config.StrikeBanThreshold)
+	if outcome != "strike" {
+		message += " " + utils.RestrictionMessage(&owner)
+	}
+
 	notification := models.Notification{
 		UserID:          report.Item.UserID,
 		Type:            "warning",
 		Title:           "⚠️ Teguran untuk Postingan Anda",
-		Message:         fmt.Sprintf("Postingan '%s' Anda telah dilaporkan karena %s dan ditinjau oleh admin. Mohon patuhi aturan komunitas kami.", report.Item.Title, reasonText[report.Reason]),
+		Message:         message,
 		ReferenceURL:    fmt.Sprintf("/item/%d", report.ItemID),
 		RelatedItemID:   &report.ItemID,
 		RelatedReportID: &report.ID,
 	}
-	config.DB.Create(&notification)
+	if err := utils.Notify(tx, &notification); err != nil {
+		return "", 0, err
+	}
+
+	return outcome, owner.StrikeCount, nil
+}
+
+// WarnUser sends a warning notification to the post owner and adds a strike.
+// Strikes escalate to a suspension and then a permanent ban at the
+// thresholds configured in config.InitModeration.
+func WarnUser(c *gin.Context) {
+	reportID := c.Param("id")
+	admin := c.MustGet("user").(*models.User)
+
+	// A closed report cannot earn the owner another strike
+	var report models.ItemReport
+	if err := config.DB.Preload("Item").Preload("Item.User").Where("status IN ?", openReportStatuses).First(&report, reportID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found or already closed"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	outcome, strikes, err := warnItemOwner(tx, admin, &report)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to warn user"})
+		return
+	}
+
+	// Closed the same way as the bulk warn action, which also tells the reporter
+	notified, err := closeReports(tx, []models.ItemReport{report}, "resolved", "warned")
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update report"})
+		return
+	}
+
+	tx.Commit()
+	utils.NotificationHub.Publish(append(notified, report.Item.UserID)...)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Warning sent to user", "action": outcome, "strikes": strikes})
+}
+
+// BulkReportRequest applies one queue action to every open report of the given items
+type BulkReportRequest struct {
+	ItemIDs    []int64 `json:"item_ids" binding:"required"`
+	Action     string  `json:"action" binding:"required"` // assign, resolve, dismiss, warn, remove
+	AssigneeID *int64  `json:"assignee_id"`
+}
+
+// BulkReportAction handles assign/resolve/dismiss/warn/remove for selected queue rows
+func BulkReportAction(c *gin.Context) {
+	admin := c.MustGet("user").(*models.User)
+
+	var req BulkReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.ItemIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if req.Action == "assign" && req.AssigneeID != nil {
+		var assignee models.User
+		if err := config.DB.Where("id = ? AND is_superuser = ?", *req.AssigneeID, true).First(&assignee).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Assignee must be a moderator"})
+			return
+		}
+	}
+
+	tx := config.DB.Begin()
+	blobs := newBlobWrites(c)
+	processed := 0
+	var notified []int64
+
+	for _, itemID := range req.ItemIDs {
+		var reports []models.ItemReport
+		tx.Preload("Item").Preload("Item.User").
+			Where("item_id = ? AND status IN ?", itemID, openReportStatuses).
+			Order("created_at DESC").
+			Find(&reports)
+		if len(reports) == 0 {
+			continue
+		}
+
+		var err error
+		var users []int64
+		switch req.Action {
+		case "assign":
+			updates := map[string]interface{}{"assignee_id": req.AssigneeID}
+			err = tx.Model(&models.ItemReport{}).
+				Where("item_id = ? AND status IN ?", itemID, openReportStatuses).
+				Updates(updates).Error
+			if err == nil && req.AssigneeID != nil {
+				err = tx.Model(&models.ItemReport{}).
+					Where("item_id = ? AND status = ?", itemID, "pending").
+					Update("status", "in_review").Error
+			}
+		case "resolve":
+			users, err = closeReports(tx, reports, "resolved", "resolved")
+		case "dismiss":
+			users, err = closeReports(tx, reports, "dismissed", "dismissed")
+		case "warn":
+			// One strike per item, charged against the most recent report
+			if _, _, err = warnItemOwner(tx, admin, &reports[0]); err == nil {
+				users, err = closeReports(tx, reports, "resolved", "warned")
+				users = append(users, reports[0].Item.UserID)
+			}
+		case "remove":
+			item := reports[0].Item
+			if err = adminRemoveItem(tx, blobs, &item, admin.ID); err == nil {
+				users, err = notifyReporters(tx, reports, "removed")
+			}
+		default:
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown action"})
+			return
+		}
+
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to %s item #%d", req.Action, itemID)})
+			return
+		}
+		notified = append(notified, users...)
+		processed++
+	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Warning sent to user"})
+	tx.Commit()
+	blobs.commit()
+	utils.NotificationHub.Publish(notified...)
+	c.JSON(http.StatusOK, gin.H{"success": true, "processed": processed})
 }
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxSavedSearches = 20
+
+var savedSearchStatuses = map[string]bool{"": true, "LOST": true, "RETURNED": true, "CLOSED": true}
+
+// parseOptionalID reads an optional positive ID from the form
+func parseOptionalID(value string) *int64 {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return nil
+	}
+	return &id
+}
+
+// describeSavedSearch builds a default name from the filters
+func describeSavedSearch(search *models.SavedSearch) string {
+	var parts []string
+	if search.Query != "" {
+		parts = append(parts, fmt.Sprintf("\"%s\"", search.Query))
+	}
+	if search.SubCategory != nil {
+		parts = append(parts, search.SubCategory.Name)
+	} else if search.Category != nil {
+		parts = append(parts, search.Category.Name)
+	}
+	if search.Location != "" {
+		parts = append(parts, "di "+search.Location)
+	}
+	if search.Status != "" {
+		parts = append(parts, search.Status)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// CreateSavedSearch stores the current filters from Home or the Profile form
+func CreateSavedSearch(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	search := models.SavedSearch{
+		UserID:        user.ID,
+		Name:          strings.TrimSpace(c.PostForm("name")),
+		Query:         strings.TrimSpace(c.PostForm("q")),
+		Location:      strings.TrimSpace(c.PostForm("location")),
+		Status:        c.PostForm("status"),
+		CategoryID:    parseOptionalID(c.PostForm("category_id")),
+		SubCategoryID: parseOptionalID(c.PostForm("subcategory_id")),
+		IsActive:      true,
+	}
+
+	// The Profile form uses a single select of "category:ID" / "subcategory:ID"
+	if kind, id, ok := strings.Cut(c.PostForm("category_filter"), ":"); ok {
+		switch kind {
+		case "category":
+			search.CategoryID = parseOptionalID(id)
+		case "subcategory":
+			search.SubCategoryID = parseOptionalID(id)
+		}
+	}
+
+	if !savedSearchStatuses[search.Status] {
+		c.String(http.StatusBadRequest, "Status tidak valid")
+		return
+	}
+	if len(search.Query) > 200 || len(search.Name) > 100 || len(search.Location) > 255 {
+		c.String(http.StatusBadRequest, "Filter terlalu panjang")
+		return
+	}
+
+	// A subcategory pins its category
+	if search.SubCategoryID != nil {
+		var sub models.SubCategory
+		if config.DB.First(&sub, *search.SubCategoryID).Error != nil {
+			c.String(http.StatusBadRequest, "Subkategori tidak ditemukan")
+			return
+		}
+		search.SubCategory = &sub
+		search.CategoryID = &sub.CategoryID
+	}
+	if search.CategoryID != nil {
+		var category models.Category
+		if config.DB.First(&category, *search.CategoryID).Error != nil {
+			c.String(http.StatusBadRequest, "Kategori tidak ditemukan")
+			return
+		}
+		search.Category = &category
+	}
+
+	if search.Query == "" && search.Location == "" && search.CategoryID == nil {
+		c.String(http.StatusBadRequest, "Isi minimal kata kunci, kategori, atau lokasi")
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.SavedSearch{}).Where("user_id = ?", user.ID).Count(&count)
+	if count >= maxSavedSearches {
+		c.String(http.StatusBadRequest, fmt.Sprintf("Maksimal %d pencarian tersimpan", maxSavedSearches))
+		return
+	}
+
+	if search.Name == "" {
+		if name := []rune(describeSavedSearch(&search)); len(name) > 100 {
+			search.Name = string(name[:100])
+		} else {
+			search.Name = string(name)
+		}
+	}
+
+	// Associations are only loaded for the default name
+	search.Category = nil
+	search.SubCategory = nil
+	if err := config.DB.Create(&search).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Gagal menyimpan pencarian")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/profile?saved=search#saved-searches")
+}
+
+// loadOwnSavedSearch fetches a saved search belonging to the current user
+func loadOwnSavedSearch(c *gin.Context) (*models.SavedSearch, bool) {
+	user := c.MustGet("user").(*models.User)
+
+	var search models.SavedSearch
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&search).Error; err != nil {
+		c.String(http.StatusNotFound, "Pencarian tidak ditemukan")
+		return nil, false
+	}
+	return &search, true
+}
+
+// ToggleSavedSearch pauses or resumes alerts for a saved search
+func ToggleSavedSearch(c *gin.Context) {
+	search, ok := loadOwnSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	config.DB.Model(search).Update("is_active", !search.IsActive)
+	c.Redirect(http.StatusFound, "/profile#saved-searches")
+}
+
+// DeleteSavedSearch removes a saved search
+func DeleteSavedSearch(c *gin.Context) {
+	search, ok := loadOwnSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	config.DB.Delete(search)
+	c.Redirect(http.StatusFound, "/profile#saved-searches")
+}
+
+// savedSearchMatches applies the same rules as the Home filters: the query
+// matches when any of its stems is among the post's search terms (as in
+// utils.SearchItems), location is a case-insensitive substring, status and
+// category are exact
+func savedSearchMatches(search *models.SavedSearch, item *models.LostItem, itemTerms map[string]bool) bool {
+	if search.Status != "" && search.Status != item.Status {
+		return false
+	}
+	if search.CategoryID != nil && *search.CategoryID != item.CategoryID {
+		return false
+	}
+	if search.SubCategoryID != nil && (item.SubCategoryID == nil || *search.SubCategoryID != *item.SubCategoryID) {
+		return false
+	}
+	if search.Location != "" && !strings.Contains(strings.ToLower(item.Location), strings.ToLower(search.Location)) {
+		return false
+	}
+	if search.Query != "" {
+		matched := false
+		for _, term := range utils.SearchTerms(search.Query) {
+			if itemTerms[term] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// notifySavedSearches alerts owners of saved searches matching a newly
+// visible post. It runs in its own goroutine after the post is created (or
+// approved), so posting never waits on matching.
+func notifySavedSearches(item models.LostItem) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[saved-search] matching item %d panicked: %v", item.ID, r)
+		}
+	}()
+
+	var searches []models.SavedSearch
+	config.DB.Joins("JOIN auth_user ON auth_user.id = core_savedsearch.user_id").
+		Where("core_savedsearch.is_active = ? AND core_savedsearch.user_id <> ? AND auth_user.is_banned = ?", true, item.UserID, false).
+		Where("auth_user.suspended_until IS NULL OR auth_user.suspended_until <= ?", time.Now()).
+		Find(&searches)
+	if len(searches) == 0 {
+		return
+	}
+
+	// The post is indexed in the transaction that saves it, so its terms are
+	// already there when matching runs
+	var terms []string
+	config.DB.Model(&models.SearchTerm{}).Where("item_id = ?", item.ID).Pluck("term", &terms)
+	itemTerms := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		itemTerms[term] = true
+	}
+
+	// One alert per user even if several of their searches match
+	notified := make(map[int64]bool)
+	now := time.Now()
+	for i := range searches {
+		search := &searches[i]
+		if notified[search.UserID] || !savedSearchMatches(search, &item, itemTerms) {
+			continue
+		}
+
+		message := fmt.Sprintf("'%s' cocok dengan pencarian tersimpan Anda.", item.Title)
+		if item.Location != "" {
+			message = fmt.Sprintf("'%s' di %s cocok dengan pencarian tersimpan Anda.", item.Title, item.Location)
+		}
+
+		tx := config.DB.Begin()
+		notification := models.Notification{
+			UserID:        search.UserID,
+			Type:          "saved_search",
+			Title:         fmt.Sprintf("Postingan baru cocok dengan \"%s\"", search.Name),
+			Message:       message,
+			ReferenceURL:  fmt.Sprintf("/item/%d", item.ID),
+			RelatedItemID: &item.ID,
+		}
+		if err := utils.Notify(tx, &notification); err != nil {
+			tx.Rollback()
+			log.Printf("[saved-search] notify user %d for item %d: %v", search.UserID, item.ID, err)
+			continue
+		}
+		if err := tx.Model(search).Updates(map[string]interface{}{
+			"match_count":      gorm.Expr("match_count + 1"),
+			"last_notified_at": now,
+		}).Error; err != nil {
+			tx.Rollback()
+			continue
+		}
+		tx.Commit()
+
+		notified[search.UserID] = true
+		utils.NotificationHub.Publish(search.UserID)
+	}
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// tagCodeAlphabet leaves out look-alike characters, so a code copied by
+	// hand from a worn sticker still works
+	tagCodeAlphabet  = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	tagCodeLength    = 12
+	maxTagMessage    = 1000
+	tagQRDefaultSize = 512
+	tagQRMaxSize     = 2048
+)
+
+// newTagCode returns a random code of tagCodeLength characters (60 bits)
+func newTagCode() string {
+	b := make([]byte, tagCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = tagCodeAlphabet[int(b[i])%len(tagCodeAlphabet)]
+	}
+	return string(b)
+}
+
+// TagSummary is a tag on the owner's page with its messages
+type TagSummary struct {
+	models.BelongingTag
+	URL         string
+	LastScanned string
+	Messages    []models.TagMessage
+	Unread      int
+}
+
+// TagListPage lists the user's belonging tags with the messages finders left.
+// Opening the page marks those messages as read.
+func TagListPage(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var tags []models.BelongingTag
+	config.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&tags)
+
+	ids := make([]int64, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	messagesByTag := make(map[int64][]models.TagMessage)
+	if len(ids) > 0 {
+		var messages []models.TagMessage
+		config.DB.Where("tag_id IN ?", ids).Order("created_at DESC").Find(&messages)
+		for _, message := range messages {
+			messagesByTag[message.TagID] = append(messagesByTag[message.TagID], message)
+		}
+		config.DB.Model(&models.TagMessage{}).
+			Where("tag_id IN ? AND read_at IS NULL", ids).
+			Update("read_at", time.Now())
+	}
+
+	summaries := make([]TagSummary, len(tags))
+	for i, tag := range tags {
+		summaries[i] = TagSummary{BelongingTag: tag, URL: utils.TagURL(tag.Code), Messages: messagesByTag[tag.ID]}
+		for _, message := range summaries[i].Messages {
+			if message.ReadAt == nil {
+				summaries[i].Unread++
+			}
+		}
+		if tag.LastScannedAt != nil {
+			summaries[i].LastScanned = tag.LastScannedAt.Format("02 Jan 15:04")
+		}
+	}
+
+	utils.RenderTemplate(c, "templates/core/tags.html", map[string]interface{}{
+		"tags":     summaries,
+		"max_tags": config.MaxTagsPerUser,
+		"error":    c.Query("error"),
+	})
+}
+
+// readTagForm reads the label and finder note of a tag
+func readTagForm(c *gin.Context) (string, string, bool) {
+	label := strings.TrimSpace(c.PostForm("label"))
+	note := strings.TrimSpace(c.PostForm("note"))
+	if label == "" || utf8.RuneCountInString(label) > 100 || utf8.RuneCountInString(note) > 255 {
+		return "", "", false
+	}
+	return label, note, true
+}
+
+// CreateTag adds a belonging tag with a fresh code
+func CreateTag(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	label, note, ok := readTagForm(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/tags?error=invalid")
+		return
+	}
+	var count int64
+	config.DB.Model(&models.BelongingTag{}).Where("user_id = ?", user.ID).Count(&count)
+	if count >= int64(config.MaxTagsPerUser) {
+		c.Redirect(http.StatusFound, "/tags?error=limit")
+		return
+	}
+
+	tag := models.BelongingTag{UserID: user.ID, Code: newTagCode(), Label: label, Note: note, IsActive: true}
+	if err := config.DB.Create(&tag).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Gagal membuat tag")
+		return
+	}
+	c.Redirect(http.StatusFound, fmt.Sprintf("/tags#tag-%d", tag.ID))
+}
+
+// loadOwnTag fetches a tag belonging to the current user
+func loadOwnTag(c *gin.Context) (*models.BelongingTag, bool) {
+	user := c.MustGet("user").(*models.User)
+
+	var tag models.BelongingTag
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&tag).Error; err != nil {
+		c.String(http.StatusNotFound, "Tag tidak ditemukan")
+		return nil, false
+	}
+	return &tag, true
+}
+
+// UpdateTag changes the label and finder note; the code, and so the printed
+// sticker, stays the same
+func UpdateTag(c *gin.Context) {
+	tag, ok := loadOwnTag(c)
+	if !ok {
+		return
+	}
+	label, note, valid := readTagForm(c)
+	if !valid {
+		c.Redirect(http.StatusFound, "/tags?error=invalid")
+		return
+	}
+	config.DB.Model(tag).Updates(map[string]interface{}{"label": label, "note": note})
+	c.Redirect(http.StatusFound, fmt.Sprintf("/tags#tag-%d", tag.ID))
+}
+
+// ToggleTag stops or resumes a tag, such as when the item was given away
+func ToggleTag(c *gin.Context) {
+	tag, ok := loadOwnTag(c)
+	if !ok {
+		return
+	}
+	config.DB.Model(tag).Update("is_active", !tag.IsActive)
+	c.Redirect(http.StatusFound, fmt.Sprintf("/tags#tag-%d", tag.ID))
+}
+
+// DeleteTag removes a tag with its messages. Found reports filed from it keep
+// their owner.
+func DeleteTag(c *gin.Context) {
+	tag, ok := loadOwnTag(c)
+	if !ok {
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.TagMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.FoundReport{}).Where("tag_id = ?", tag.ID).Update("tag_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Gagal menghapus tag")
+		return
+	}
+	c.Redirect(http.StatusFound, "/tags")
+}
+
+// TagQRCode serves a tag's QR code as PNG, or as SVG with ?format=svg
+func TagQRCode(c *gin.Context) {
+	tag, ok := loadOwnTag(c)
+	if !ok {
+		return
+	}
+	url := utils.TagURL(tag.Code)
+	filename := "temuin-tag-" + strings.ToLower(tag.Code)
+
+	if c.Query("format") == "svg" {
+		svg, err := utils.QRCodeSVG(url)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Gagal membuat QR")
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.svg"`, filename))
+		c.Data(http.StatusOK, "image/svg+xml", svg)
+		return
+	}
+
+	size, err := strconv.Atoi(c.Query("size"))
+	if err != nil || size < 64 || size > tagQRMaxSize {
+		size = tagQRDefaultSize
+	}
+	png, err := utils.QRCodePNG(url, size)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Gagal membuat QR")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.png"`, filename))
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// TagSheet renders the user's active tags as a printable A4 sticker sheet.
+// ?id= may be repeated to print only some tags.
+func TagSheet(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	query := config.DB.Where("user_id = ? AND is_active = ?", user.ID, true)
+	if ids := c.QueryArray("id"); len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	var tags []models.BelongingTag
+	query.Order("created_at").Find(&tags)
+	if len(tags) == 0 {
+		c.Redirect(http.StatusFound, "/tags?error=empty")
+		return
+	}
+
+	pdf, err := utils.TagSheetPDF(tags)
+	if err != nil {
+		log.Printf("[tags] sheet for user %d: %v", user.ID, err)
+		c.String(http.StatusInternalServerError, "Gagal membuat PDF")
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="temuin-tags.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// loadScannedTag fetches a tag by the code in its QR
+func loadScannedTag(c *gin.Context) (*models.BelongingTag, bool) {
+	var tag models.BelongingTag
+	if err := config.DB.Where("code = ?", strings.ToUpper(c.Param("code"))).First(&tag).Error; err != nil {
+		c.String(http.StatusNotFound, "Tag tidak ditemukan")
+		return nil, false
+	}
+	return &tag, true
+}
+
+// renderTagScan renders the public page of a tag
+func renderTagScan(c *gin.Context, tag *models.BelongingTag, extra map[string]interface{}) {
+	ctx := map[string]interface{}{
+		"tag":         tag,
+		"max_message": maxTagMessage,
+		"sent":        c.Query("sent") != "",
+	}
+	if u, exists := c.Get("user"); exists {
+		ctx["is_tag_owner"] = u.(*models.User).ID == tag.UserID
+	}
+	for k, v := range extra {
+		ctx[k] = v
+	}
+	utils.RenderTemplate(c, "templates/core/tag_scan.html", ctx)
+}
+
+// ScanTag is the public page a tag's QR code opens. It shows the owner's note
+// and a form for an anonymous message; signed-in finders can also file a
+// found report that goes straight to the owner.
+func ScanTag(c *gin.Context) {
+	tag, ok := loadScannedTag(c)
+	if !ok {
+		return
+	}
+	if tag.IsActive && c.Query("sent") == "" {
+		config.DB.Model(tag).Updates(map[string]interface{}{
+			"scan_count":      gorm.Expr("scan_count + 1"),
+			"last_scanned_at": time.Now(),
+		})
+	}
+	renderTagScan(c, tag, nil)
+}
+
+// tagSenderHash identifies an anonymous sender for rate limiting only, with
+// the same keyed hash as identifiers so the IP is never stored
+func tagSenderHash(c *gin.Context) string {
+	return utils.HashIdentifier("ip:" + c.ClientIP())
+}
+
+// SendTagMessage delivers an anonymous message from a tag's page to its
+// owner. Senders are limited per hour and tags per day.
+func SendTagMessage(c *gin.Context) {
+	tag, ok := loadScannedTag(c)
+	if !ok {
+		return
+	}
+	if !tag.IsActive {
+		c.String(http.StatusGone, "Tag ini sudah tidak aktif")
+		return
+	}
+
+	content := strings.TrimSpace(c.PostForm("content"))
+	contact := strings.TrimSpace(c.PostForm("contact"))
+	form := map[string]interface{}{"content": content, "contact": contact}
+	renderError := func(msg string) {
+		form["error"] = msg
+		renderTagScan(c, tag, form)
+	}
+
+	if content == "" {
+		renderError("Pesan tidak boleh kosong")
+		return
+	}
+	if utf8.RuneCountInString(content) > maxTagMessage || utf8.RuneCountInString(contact) > 100 {
+		renderError(fmt.Sprintf("Pesan maksimal %d karakter dan kontak maksimal 100 karakter", maxTagMessage))
+		return
+	}
+
+	senderHash := tagSenderHash(c)
+	var sent int64
+	config.DB.Model(&models.TagMessage{}).
+		Where("sender_hash = ? AND created_at > ?", senderHash, time.Now().Add(-time.Hour)).
+		Count(&sent)
+	if sent >= int64(config.TagMessagesPerHour) {
+		renderError("Terlalu banyak pesan. Coba lagi dalam satu jam.")
+		return
+	}
+	var received int64
+	config.DB.Model(&models.TagMessage{}).
+		Where("tag_id = ? AND created_at > ?", tag.ID, time.Now().Add(-24*time.Hour)).
+		Count(&received)
+	if received >= int64(config.TagMessagesPerDay) {
+		renderError("Tag ini sudah menerima banyak pesan hari ini. Coba lagi besok.")
+		return
+	}
+
+	if moderation := utils.CheckContent(config.DB, "comments", content); moderation.Blocked() {
+		renderError(blockedContentMessage(moderation))
+		return
+	}
+
+	tx := config.DB.Begin()
+	message := models.TagMessage{TagID: tag.ID, Content: content, Contact: contact, SenderHash: senderHash}
+	if err := tx.Create(&message).Error; err != nil {
+		tx.Rollback()
+		renderError("Gagal mengirim pesan")
+		return
+	}
+	if err := utils.Notify(tx, &models.Notification{
+		UserID:       tag.UserID,
+		Type:         "found_item",
+		Title:        fmt.Sprintf("Pesan dari penemu '%s'", tag.Label),
+		Message:      "Seseorang memindai tag Anda dan meninggalkan pesan: " + content,
+		ReferenceURL: fmt.Sprintf("/tags#tag-%d", tag.ID),
+	}); err != nil {
+		tx.Rollback()
+		renderError("Gagal mengirim pesan")
+		return
+	}
+	tx.Commit()
+
+	utils.NotificationHub.Publish(tag.UserID)
+	c.Redirect(http.StatusFound, "/t/"+tag.Code+"?sent=1")
+}
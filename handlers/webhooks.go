@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"temuin/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const webhookDeliveriesPerPage = 50
+
+// itemWebhookData is the public view of a post sent with item.* events
+func itemWebhookData(item *models.LostItem) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             item.ID,
+		"title":          item.Title,
+		"status":         item.Status,
+		"state":          item.State,
+		"location":       item.Location,
+		"bounty_coins":   item.BountyCoins,
+		"category_id":    item.CategoryID,
+		"subcategory_id": item.SubCategoryID,
+		"url":            fmt.Sprintf("%s/item/%d", config.AppBaseURL, item.ID),
+		"created_at":     item.CreatedAt,
+	}
+}
+
+// claimWebhookData is sent with claim.created; claimants are identified by ID only
+func claimWebhookData(claim *models.ItemClaim) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         claim.ID,
+		"item_id":    claim.ItemID,
+		"user_id":    claim.UserID,
+		"item_url":   fmt.Sprintf("%s/item/%d", config.AppBaseURL, claim.ItemID),
+		"created_at": claim.CreatedAt,
+	}
+}
+
+// reportWebhookData is sent with report.created. Descriptions and evidence
+// stay inside the admin panel.
+func reportWebhookData(report *models.ItemReport) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         report.ID,
+		"item_id":    report.ItemID,
+		"reason":     report.Reason,
+		"automatic":  report.ReporterID == nil,
+		"item_url":   fmt.Sprintf("%s/item/%d", config.AppBaseURL, report.ItemID),
+		"created_at": report.CreatedAt,
+	}
+}
+
+// WebhookSummary is a subscription with its delivery counters for the admin list
+type WebhookSummary struct {
+	models.WebhookSubscription
+	EventList []string
+	Pending   int64
+	Failed    int64
+	LastSent  *models.WebhookDelivery
+}
+
+// AdminWebhookList shows webhook subscriptions and their delivery health
+func AdminWebhookList(c *gin.Context) {
+	var subscriptions []models.WebhookSubscription
+	config.DB.Preload("CreatedBy").Order("created_at DESC").Find(&subscriptions)
+
+	summaries := make([]WebhookSummary, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		summary := WebhookSummary{WebhookSubscription: sub, EventList: strings.Split(sub.Events, ",")}
+		config.DB.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", sub.ID, "pending").
+			Count(&summary.Pending)
+		config.DB.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", sub.ID, "failed").
+			Count(&summary.Failed)
+
+		var last models.WebhookDelivery
+		if config.DB.Where("subscription_id = ? AND attempts > 0", sub.ID).
+			Order("id DESC").Limit(1).Find(&last).RowsAffected > 0 {
+			summary.LastSent = &last
+		}
+		summaries = append(summaries, summary)
+	}
+
+	utils.RenderTemplate(c, "templates/admin_webhooks.html", map[string]interface{}{
+		"subscriptions": summaries,
+		"events":        utils.WebhookEvents,
+	})
+}
+
+// WebhookRequest is the body for creating or updating a subscription
+type WebhookRequest struct {
+	Name   string   `json:"name" form:"name"`
+	URL    string   `json:"url" form:"url"`
+	Events []string `json:"events" form:"events"`
+}
+
+// validate checks the request and returns an error message for the admin
+func (req *WebhookRequest) validate() string {
+	req.Name = strings.TrimSpace(req.Name)
+	req.URL = strings.TrimSpace(req.URL)
+	if req.Name == "" {
+		return "Nama webhook wajib diisi"
+	}
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "URL harus diawali http:// atau https://"
+	}
+	if len(req.Events) == 0 {
+		return "Pilih minimal satu event"
+	}
+	for _, event := range req.Events {
+		if !utils.ValidWebhookEvent(event) {
+			return "Event tidak dikenal: " + event
+		}
+	}
+	return ""
+}
+
+// CreateWebhook adds a subscription and returns its signing secret once
+func CreateWebhook(c *gin.Context) {
+	admin := c.MustGet("user").(*models.User)
+
+	var req WebhookRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	sub := models.WebhookSubscription{
+		Name:        req.Name,
+		URL:         req.URL,
+		Secret:      utils.NewWebhookSecret(),
+		Events:      strings.Join(req.Events, ","),
+		IsActive:    true,
+		CreatedByID: &admin.ID,
+	}
+	if err := config.DB.Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "id": sub.ID, "secret": sub.Secret})
+}
+
+// UpdateWebhook edits a subscription's name, URL and events; the secret is kept
+func UpdateWebhook(c *gin.Context) {
+	var sub models.WebhookSubscription
+	if err := config.DB.First(&sub, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	sub.Name = req.Name
+	sub.URL = req.URL
+	sub.Events = strings.Join(req.Events, ",")
+	if err := config.DB.Save(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ToggleWebhook enables or disables a subscription
+func ToggleWebhook(c *gin.Context) {
+	var sub models.WebhookSubscription
+	if err := config.DB.First(&sub, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	sub.IsActive = !sub.IsActive
+	if err := config.DB.Model(&sub).Update("is_active", sub.IsActive).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "is_active": sub.IsActive})
+}
+
+// RotateWebhookSecret replaces the signing secret and returns the new one
+func RotateWebhookSecret(c *gin.Context) {
+	var sub models.WebhookSubscription
+	if err := config.DB.First(&sub, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	sub.Secret = utils.NewWebhookSecret()
+	if err := config.DB.Model(&sub).Update("secret", sub.Secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "secret": sub.Secret})
+}
+
+// DeleteWebhook removes a subscription together with its delivery log
+func DeleteWebhook(c *gin.Context) {
+	var sub models.WebhookSubscription
+	if err := config.DB.First(&sub, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Where("subscription_id = ?", sub.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete deliveries"})
+		return
+	}
+	if err := tx.Delete(&sub).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// AdminWebhookDeliveries shows the delivery log of one subscription
+func AdminWebhookDeliveries(c *gin.Context) {
+	var sub models.WebhookSubscription
+	if err := config.DB.First(&sub, c.Param("id")).Error; err != nil {
+		c.String(http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	status := c.Query("status")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	query := config.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", sub.ID)
+	if status == "pending" || status == "success" || status == "failed" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var deliveries []models.WebhookDelivery
+	query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * webhookDeliveriesPerPage).
+		Limit(webhookDeliveriesPerPage).
+		Find(&deliveries)
+
+	utils.RenderTemplate(c, "templates/admin_webhook_deliveries.html", map[string]interface{}{
+		"subscription":  sub,
+		"deliveries":    deliveries,
+		"filter_status": status,
+		"page":          page,
+		"has_prev":      page > 1,
+		"has_next":      int64(page*webhookDeliveriesPerPage) < total,
+		"prev_page":     page - 1,
+		"next_page":     page + 1,
+		"total":         total,
+	})
+}
+
+// RedeliverWebhook queues a fresh copy of a delivery with the same event ID,
+// so receivers that deduplicate on X-TemuIN-Delivery stay idempotent
+func RedeliverWebhook(c *gin.Context) {
+	var original models.WebhookDelivery
+	if err := config.DB.First(&original, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if original.Status == "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pengiriman ini masih dalam antrean"})
+		return
+	}
+
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         "pending",
+		NextAttemptAt:  &now,
+	}
+	if err := config.DB.Create(&delivery).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue delivery"})
+		return
+	}
+	utils.WakeWebhookWorker()
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "id": delivery.ID})
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"temuin/config"
 	"temuin/routes"
+	"temuin/storage"
+	"temuin/utils"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
@@ -20,8 +22,28 @@ func main() {
 	config.ConnectDB()
 	config.InitGoogleOAuth()
 	config.InitMidtrans()
+	config.InitModeration()
+	config.InitMail()
+	config.InitWebhooks()
+	config.InitListing()
+	config.InitImages()
+	config.InitStorage()
+	config.InitRegistry()
+	config.InitHandover()
+	config.InitServer()
+	storage.Init()
+	utils.InitImageCache(config.ImageCacheMB)
+
+	utils.StartMailWorker(config.DB)
+	utils.StartWebhookWorker(config.DB)
+	utils.StartMeetupReminders(config.DB)
+	utils.StartItemExpiry(config.DB)
 
 	r := gin.Default()
+	// Per-sender limits (tag messages, handover attempts) key on ClientIP
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		log.Fatal("❌ Invalid TRUSTED_PROXIES:", err)
+	}
 
 	r.Static("/static", "./static")
 	r.Static("/media", "../media")
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"temuin/config"
 	"temuin/models"
+	"temuin/utils"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -33,11 +34,20 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
-		// Check if user is banned
-		if user.IsBanned {
+		// Lift a suspension whose window has passed before checking access
+		utils.LiftExpiredSuspension(config.DB, &user)
+
+		// Check if user is banned or suspended
+		if utils.IsRestricted(&user) {
 			session.Delete("user_id")
+			// Keep a restricted marker so the user can still reach the appeal form
+			session.Set("appeal_user_id", user.ID)
 			session.Save()
-			c.Redirect(http.StatusFound, "/login?error=banned")
+			if user.IsBanned {
+				c.Redirect(http.StatusFound, "/login?error=banned")
+			} else {
+				c.Redirect(http.StatusFound, "/login?error=suspended")
+			}
 			c.Abort()
 			return
 		}
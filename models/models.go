@@ -9,18 +9,21 @@ type User struct {
 	ID             int64  `gorm:"primaryKey;autoIncrement"`
 	Password       string `gorm:"size:128;not null"`
 	LastLogin      *time.Time
-	IsSuperuser    bool      `gorm:"column:is_superuser;default:false"`
-	Username       string    `gorm:"size:150;unique;not null"`
-	FirstName      string    `gorm:"column:first_name;size:150;not null"`
-	LastName       string    `gorm:"column:last_name;size:150;not null"`
-	Email          string    `gorm:"size:254;not null"`
-	IsStaff        bool      `gorm:"column:is_staff;default:false"`
-	IsActive       bool      `gorm:"column:is_active;default:true"`
-	IsBanned       bool      `gorm:"column:is_banned;default:false"`
-	DateJoined     time.Time `gorm:"column:date_joined;autoCreateTime"`
-	PhoneNumber    string    `gorm:"column:phone_number;size:15;default:null"`
-	CoinBalance    int       `gorm:"column:coin_balance;default:0"`
-	ProfilePicture string    `gorm:"column:profile_picture;size:255;default:null"`
+	IsSuperuser    bool       `gorm:"column:is_superuser;default:false"`
+	Username       string     `gorm:"size:150;unique;not null"`
+	FirstName      string     `gorm:"column:first_name;size:150;not null"`
+	LastName       string     `gorm:"column:last_name;size:150;not null"`
+	Email          string     `gorm:"size:254;not null"`
+	IsStaff        bool       `gorm:"column:is_staff;default:false"`
+	IsActive       bool       `gorm:"column:is_active;default:true"`
+	IsBanned       bool       `gorm:"column:is_banned;default:false"`
+	BanReason      string     `gorm:"column:ban_reason;size:255;default:null"`
+	SuspendedUntil *time.Time `gorm:"column:suspended_until;default:null"`
+	StrikeCount    int        `gorm:"column:strike_count;default:0"`
+	DateJoined     time.Time  `gorm:"column:date_joined;autoCreateTime"`
+	PhoneNumber    string     `gorm:"column:phone_number;size:15;default:null"`
+	CoinBalance    int        `gorm:"column:coin_balance;default:0"`
+	ProfilePicture string     `gorm:"column:profile_picture;size:255;default:null"`
 }
 
 // TableName overrides the table name to match Django's
@@ -51,12 +54,93 @@ func (SubCategory) TableName() string {
 	return "core_subcategory"
 }
 
+// AttributeField is one field of a subcategory's attribute schema, such as a
+// phone's brand or IMEI. Values are stored as ItemAttribute rows.
+type AttributeField struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement"`
+	SubCategoryID int64     `gorm:"column:subcategory_id;not null;index"`
+	Name          string    `gorm:"size:100;not null"`
+	Kind          string    `gorm:"size:10;not null"`                // enum, text, number, date
+	Options       string    `gorm:"type:text"`                       // enum choices, one per line
+	IsPrivate     bool      `gorm:"column:is_private;default:false"` // shown only to the owner, used to check claims
+	IsRequired    bool      `gorm:"column:is_required;default:false"`
+	Position      int       `gorm:"column:position;default:0"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (AttributeField) TableName() string {
+	return "core_attributefield"
+}
+
+// ItemAttribute is a post's value for one AttributeField, normalized by
+// utils.NormalizeAttribute
+type ItemAttribute struct {
+	ID      int64  `gorm:"primaryKey;autoIncrement"`
+	ItemID  int64  `gorm:"column:item_id;not null;uniqueIndex:idx_itemattribute_item_field"`
+	FieldID int64  `gorm:"column:field_id;not null;uniqueIndex:idx_itemattribute_item_field;index:idx_itemattribute_field_value"`
+	Value   string `gorm:"size:255;not null;index:idx_itemattribute_field_value"`
+
+	Field AttributeField `gorm:"foreignKey:FieldID"`
+}
+
+func (ItemAttribute) TableName() string {
+	return "core_itemattribute"
+}
+
+// ClaimAttribute is what a claimant says about one field of the item they
+// found, compared against the post by utils.ClaimSignals
+type ClaimAttribute struct {
+	ID      int64  `gorm:"primaryKey;autoIncrement"`
+	ClaimID int64  `gorm:"column:claim_id;not null;uniqueIndex:idx_claimattribute_claim_field"`
+	FieldID int64  `gorm:"column:field_id;not null;uniqueIndex:idx_claimattribute_claim_field"`
+	Value   string `gorm:"size:255;not null"`
+
+	Field AttributeField `gorm:"foreignKey:FieldID"`
+}
+
+func (ClaimAttribute) TableName() string {
+	return "core_claimattribute"
+}
+
+// Place is an admin-managed location: a site (campus, venue) containing
+// buildings, which contain floors and rooms
+type Place struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	ParentID  *int64    `gorm:"column:parent_id;index"`
+	Kind      string    `gorm:"size:20;not null"` // site, building, floor, room
+	Name      string    `gorm:"size:100;not null"`
+	FullName  string    `gorm:"column:full_name;size:255;not null"` // "Kampus A › Gedung B › Lantai 2", kept in sync by utils.RefreshPlaceNames
+	IsActive  bool      `gorm:"column:is_active;default:true"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+
+	Parent  *Place       `gorm:"foreignKey:ParentID"`
+	Aliases []PlaceAlias `gorm:"foreignKey:PlaceID"`
+}
+
+func (Place) TableName() string {
+	return "core_place"
+}
+
+// PlaceAlias is another way users write a place ("gd b lt 2")
+type PlaceAlias struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"`
+	PlaceID    int64  `gorm:"column:place_id;not null;index"`
+	Alias      string `gorm:"size:100;not null"`
+	Normalized string `gorm:"size:100;not null;uniqueIndex"` // utils.NormalizePlace(Alias)
+}
+
+func (PlaceAlias) TableName() string {
+	return "core_placealias"
+}
+
 type LostItem struct {
 	ID              int64      `gorm:"primaryKey;autoIncrement"`
 	Title           string     `gorm:"size:200;not null"`
 	Description     string     `gorm:"type:longtext;not null"`
-	Image           string     `gorm:"size:100;default:null"`
-	Status          string     `gorm:"size:10;default:'LOST'"` // LOST, FOUND, RETURNED
+	Image           string     `gorm:"size:100;default:null"`        // content hash of the primary photo (versions /images/:pk), empty without photos
+	Status          string     `gorm:"size:10;default:'LOST'"`       // LOST, RETURNED, CLOSED; follows State, see utils.ItemStatus
+	State           string     `gorm:"size:20;default:'open';index"` // lifecycle, changed only through utils.TransitionItem
 	BountyCoins     int        `gorm:"column:bounty_coins;default:0"`
 	IsHighlighted   bool       `gorm:"column:is_highlighted;default:false"`
 	HighlightExpiry *time.Time `gorm:"column:highlight_expiry;default:null"`
@@ -69,10 +153,15 @@ type LostItem struct {
 	OwnerConfirmed  bool       `gorm:"column:owner_confirmed;default:false"`
 	FinderConfirmed bool       `gorm:"column:finder_confirmed;default:false"`
 	Location        string     `gorm:"size:255;default:null"`
+	IsHeld          bool       `gorm:"column:is_held;default:false;index"`                  // hidden until an admin reviews it, see ModerationRule
+	Latitude        *float64   `gorm:"column:latitude;default:null;index:idx_lostitem_geo"` // exact map pin, see utils.CanSeeExactLocation
+	Longitude       *float64   `gorm:"column:longitude;default:null;index:idx_lostitem_geo"`
+	PlaceID         *int64     `gorm:"column:place_id;default:null;index"`
 
 	User        User         `gorm:"foreignKey:UserID"`
 	Category    Category     `gorm:"foreignKey:CategoryID"`
 	SubCategory *SubCategory `gorm:"foreignKey:SubCategoryID"`
+	Place       *Place       `gorm:"foreignKey:PlaceID"`
 	Finder      *User        `gorm:"foreignKey:FinderID"`
 	Comments    []Comment    `gorm:"foreignKey:ItemID"`
 }
@@ -81,14 +170,78 @@ func (LostItem) TableName() string {
 	return "core_lostitem"
 }
 
+// ItemHistory records one lifecycle transition of a post. Rows are kept when
+// the post is deleted so the last transition (removed) stays on record.
+type ItemHistory struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	ItemID    int64     `gorm:"column:item_id;not null;index"`
+	FromState string    `gorm:"column:from_state;size:20"`
+	ToState   string    `gorm:"column:to_state;size:20;not null"`
+	ActorID   *int64    `gorm:"column:actor_id"` // nil for system transitions such as expiry
+	Note      string    `gorm:"size:255"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+
+	Actor *User `gorm:"foreignKey:ActorID"`
+}
+
+func (ItemHistory) TableName() string {
+	return "core_itemhistory"
+}
+
+// LostItemImage is one photo of a post. Position orders the gallery from 1;
+// the primary photo is the one shown on cards and at /images/:pk.
 type LostItemImage struct {
-	ItemID      int64  `gorm:"primaryKey"`
-	ImageData   []byte `gorm:"type:longblob"`
-	ContentType string `gorm:"size:50"`
+	ID          int64     `gorm:"primaryKey;autoIncrement"`
+	ItemID      int64     `gorm:"column:item_id;not null;index:idx_itemimage_item_position"`
+	Position    int       `gorm:"column:position;not null;default:1;index:idx_itemimage_item_position"`
+	IsPrimary   bool      `gorm:"column:is_primary;default:false"`
+	BlobKey     string    `gorm:"column:blob_key;size:100;default:''"` // storage key prefix, the sizes are <key>/full.jpg, /detail.jpg and /thumb.jpg
+	ImageData   []byte    `gorm:"type:longblob"`                       // legacy: bytes stored before blob storage, see cmd/migrate/migrate_blobs
+	DetailData  []byte    `gorm:"type:mediumblob"`
+	ThumbData   []byte    `gorm:"type:mediumblob"`
+	ContentType string    `gorm:"size:50"`
+	ContentHash string    `gorm:"column:content_hash;size:16;default:''"` // utils.ContentHash of the full photo
+	PHash       uint64    `gorm:"column:phash;default:0"`                 // utils.PerceptualHash, 0 when not computed or too featureless
+	Width       int       `gorm:"column:width;default:0"`
+	Height      int       `gorm:"column:height;default:0"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
 }
 
+// Photos used to be keyed by item in core_lostitem_image; see
+// cmd/migrate/split_item_images
 func (LostItemImage) TableName() string {
-	return "core_lostitem_image"
+	return "core_lostitem_photo"
+}
+
+// ImageMatch records that a photo looks like an earlier photo on another
+// user's post, a sign of a reused stock or stolen picture
+type ImageMatch struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement"`
+	ImageID       int64      `gorm:"column:image_id;not null;uniqueIndex:idx_imagematch_pair"` // the newer photo
+	MatchImageID  int64      `gorm:"column:match_image_id;not null;uniqueIndex:idx_imagematch_pair;index"`
+	ItemID        int64      `gorm:"column:item_id;not null;index"`
+	MatchItemID   int64      `gorm:"column:match_item_id;not null;index"`
+	Distance      int        `gorm:"column:distance;not null"` // differing perceptual hash bits
+	DismissedAt   *time.Time `gorm:"column:dismissed_at;index"`
+	DismissedByID *int64     `gorm:"column:dismissed_by_id"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ImageMatch) TableName() string {
+	return "core_image_match"
+}
+
+// Blob holds an uploaded file when STORAGE_BACKEND is "db", see storage.DBStore
+type Blob struct {
+	Key         string    `gorm:"column:blob_key;primaryKey;size:255"`
+	Data        []byte    `gorm:"type:longblob"`
+	ContentType string    `gorm:"size:50"`
+	Size        int       `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Blob) TableName() string {
+	return "core_blob"
 }
 
 type Comment struct {
@@ -97,6 +250,7 @@ type Comment struct {
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
 	UserID    int64     `gorm:"column:user_id;not null"`
 	ItemID    int64     `gorm:"column:item_id;not null"`
+	IsHeld    bool      `gorm:"column:is_held;default:false"` // hidden until an admin reviews it, see ModerationRule
 
 	User User     `gorm:"foreignKey:UserID"`
 	Item LostItem `gorm:"foreignKey:ItemID"`
@@ -134,28 +288,430 @@ func (ItemClaim) TableName() string {
 	return "core_itemclaim"
 }
 
+// HandoverCode is a one-time code one side of a return reveals at the
+// handover. The owner shows theirs once the item is back in their hands and
+// the selected finder enters it (or scans its QR); or the finder shows theirs
+// and the owner scans it. Either completes the return and releases the
+// bounty. A new finder or too many wrong guesses revoke it.
+type HandoverCode struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement"`
+	ItemID    int64      `gorm:"column:item_id;not null;index"`
+	FinderID  int64      `gorm:"column:finder_id;not null"`
+	ShownBy   string     `gorm:"column:shown_by;size:10;default:'owner'"` // owner, finder; the other side enters it
+	Code      string     `gorm:"size:6;not null"`
+	Failures  int        `gorm:"column:failures;default:0"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (HandoverCode) TableName() string {
+	return "core_handovercode"
+}
+
+// HandoverAttempt logs every code entry, so disputes over a return can be
+// checked against who tried which code and when
+type HandoverAttempt struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	ItemID    int64     `gorm:"column:item_id;not null;index"`
+	CodeID    *int64    `gorm:"column:code_id"` // nil when no code was active
+	UserID    int64     `gorm:"column:user_id;not null"`
+	Entered   string    `gorm:"size:20"`
+	Method    string    `gorm:"size:10"` // code, qr
+	Success   bool      `gorm:"default:false"`
+	IPHash    string    `gorm:"column:ip_hash;size:64"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID"`
+}
+
+func (HandoverAttempt) TableName() string {
+	return "core_handoverattempt"
+}
+
+// Meetup is a proposal of time slots and places for handing over an item,
+// sent by the owner or the selected finder. The recipient accepts one slot
+// or counters with a proposal of their own.
+type Meetup struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement"`
+	ItemID      int64      `gorm:"column:item_id;not null;index"`
+	ProposerID  int64      `gorm:"column:proposer_id;not null"`
+	RecipientID int64      `gorm:"column:recipient_id;not null"`
+	Note        string     `gorm:"size:500"`
+	Status      string     `gorm:"size:20;default:'pending';index"` // pending, accepted, countered, declined, cancelled
+	SlotID      *int64     `gorm:"column:slot_id"`                  // the accepted slot
+	RemindedAt  *time.Time `gorm:"column:reminded_at"`
+	RespondedAt *time.Time `gorm:"column:responded_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	Item      LostItem     `gorm:"foreignKey:ItemID"`
+	Proposer  User         `gorm:"foreignKey:ProposerID"`
+	Recipient User         `gorm:"foreignKey:RecipientID"`
+	Slots     []MeetupSlot `gorm:"foreignKey:MeetupID"`
+	Slot      *MeetupSlot  `gorm:"foreignKey:SlotID"`
+}
+
+func (Meetup) TableName() string {
+	return "core_meetup"
+}
+
+// MeetupSlot is one time and place offered in a Meetup
+type MeetupSlot struct {
+	ID       int64     `gorm:"primaryKey;autoIncrement"`
+	MeetupID int64     `gorm:"column:meetup_id;not null;index"`
+	StartsAt time.Time `gorm:"column:starts_at;not null;index"`
+	Place    string    `gorm:"size:255;not null"`
+}
+
+func (MeetupSlot) TableName() string {
+	return "core_meetupslot"
+}
+
+// MeetupNoShow is one side reporting that the other missed an agreed
+// meetup. It counts against the reported user's utils.Reputation and is
+// kept when the post is deleted.
+type MeetupNoShow struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	MeetupID   int64     `gorm:"column:meetup_id;not null;uniqueIndex:idx_meetupnoshow_meetup_reporter"`
+	ReporterID int64     `gorm:"column:reporter_id;not null;uniqueIndex:idx_meetupnoshow_meetup_reporter"`
+	ReportedID int64     `gorm:"column:reported_id;not null;index"`
+	Note       string    `gorm:"size:500"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (MeetupNoShow) TableName() string {
+	return "core_meetupnoshow"
+}
+
 type ItemReport struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement"`
+	ItemID      int64      `gorm:"column:item_id;not null"`
+	ReporterID  *int64     `gorm:"column:reporter_id"` // nil when flagged automatically by a ModerationRule
+	RuleID      *int64     `gorm:"column:rule_id;index"`
+	Reason      string     `gorm:"size:50;not null"` // fraud, spam, buying_selling, inappropriate, other
+	Description string     `gorm:"type:text"`
+	Status      string     `gorm:"size:20;default:'pending'"` // pending, in_review, reviewed, resolved, dismissed
+	AssigneeID  *int64     `gorm:"column:assignee_id;index"`
+	DueAt       *time.Time `gorm:"column:due_at;index"` // SLA deadline, see config.ReportSLA
+	Outcome     string     `gorm:"size:20"`             // warned, removed, dismissed, resolved
+	ResolvedAt  *time.Time `gorm:"column:resolved_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	Item     LostItem         `gorm:"foreignKey:ItemID"`
+	Reporter *User            `gorm:"foreignKey:ReporterID"`
+	Rule     *ModerationRule  `gorm:"foreignKey:RuleID"`
+	Assignee *User            `gorm:"foreignKey:AssigneeID"`
+	Evidence []ReportEvidence `gorm:"foreignKey:ReportID"`
+}
+
+func (ItemReport) TableName() string {
+	return "core_itemreport"
+}
+
+// ReportEvidence is an image attached to a report by the reporter. Stored the
+// same way as LostItemImage and only served to admins.
+type ReportEvidence struct {
 	ID          int64     `gorm:"primaryKey;autoIncrement"`
-	ItemID      int64     `gorm:"column:item_id;not null"`
-	ReporterID  int64     `gorm:"column:reporter_id;not null"`
-	Reason      string    `gorm:"size:50;not null"` // fraud, spam, buying_selling, inappropriate, other
-	Description string    `gorm:"type:text"`
-	Status      string    `gorm:"size:20;default:'pending'"` // pending, reviewed, resolved
+	ReportID    int64     `gorm:"column:report_id;not null;index"`
+	BlobKey     string    `gorm:"column:blob_key;size:100;default:''"` // storage key of the image
+	ImageData   []byte    `gorm:"type:longblob"`                       // legacy: bytes stored before blob storage, see cmd/migrate/migrate_blobs
+	ContentType string    `gorm:"size:50"`
+	Size        int       `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ReportEvidence) TableName() string {
+	return "core_reportevidence"
+}
+
+// Conversation is a private thread between an item's owner and one claimant
+type Conversation struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement"`
+	ItemID        int64      `gorm:"column:item_id;not null;uniqueIndex:idx_conversation_item_claimant"`
+	ClaimantID    int64      `gorm:"column:claimant_id;not null;uniqueIndex:idx_conversation_item_claimant"`
+	LastMessageAt *time.Time `gorm:"column:last_message_at;index"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	Item     LostItem        `gorm:"foreignKey:ItemID"`
+	Claimant User            `gorm:"foreignKey:ClaimantID"`
+	Messages []DirectMessage `gorm:"foreignKey:ConversationID"`
+}
+
+func (Conversation) TableName() string {
+	return "core_conversation"
+}
+
+type DirectMessage struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement"`
+	ConversationID int64      `gorm:"column:conversation_id;not null;index"`
+	SenderID       int64      `gorm:"column:sender_id;not null"`
+	Content        string     `gorm:"type:text;not null"`
+	ReadAt         *time.Time `gorm:"column:read_at"` // set when the other participant opens the thread
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	Sender User `gorm:"foreignKey:SenderID"`
+}
+
+func (DirectMessage) TableName() string {
+	return "core_directmessage"
+}
+
+// SearchTerm is one row of the embedded full-text index: a stemmed term of a
+// post with its field-weighted frequency. Rows are rebuilt whenever the post
+// is created or edited and removed with it, see utils.IndexItem.
+type SearchTerm struct {
+	ID     int64   `gorm:"primaryKey;autoIncrement"`
+	ItemID int64   `gorm:"column:item_id;not null;uniqueIndex:idx_searchterm_item_term"`
+	Term   string  `gorm:"size:64;not null;uniqueIndex:idx_searchterm_item_term;index:idx_searchterm_term"`
+	Weight float64 `gorm:"not null"` // title hits count 3, location 2, description 1
+}
+
+func (SearchTerm) TableName() string {
+	return "core_searchterm"
+}
+
+// SavedSearch is a stored set of browse filters. New posts matching it notify
+// the owner through the "saved_search" notification type.
+type SavedSearch struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement"`
+	UserID         int64      `gorm:"column:user_id;not null;index"`
+	Name           string     `gorm:"size:100;not null"`
+	Query          string     `gorm:"size:200"`
+	CategoryID     *int64     `gorm:"column:category_id"`
+	SubCategoryID  *int64     `gorm:"column:subcategory_id"`
+	Location       string     `gorm:"size:255"`
+	Status         string     `gorm:"size:10"` // empty matches any status
+	IsActive       bool       `gorm:"column:is_active;default:true;index"`
+	MatchCount     int        `gorm:"column:match_count;default:0"`
+	LastNotifiedAt *time.Time `gorm:"column:last_notified_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	User        User         `gorm:"foreignKey:UserID"`
+	Category    *Category    `gorm:"foreignKey:CategoryID"`
+	SubCategory *SubCategory `gorm:"foreignKey:SubCategoryID"`
+}
+
+func (SavedSearch) TableName() string {
+	return "core_savedsearch"
+}
+
+// Identifier is a serial number, IMEI or document number an owner registered
+// before losing the item. Only a keyed hash is stored, see
+// utils.HashIdentifier; Hint keeps the last characters so the owner can tell
+// entries apart.
+type Identifier struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	UserID    int64     `gorm:"column:user_id;not null;index"`
+	Kind      string    `gorm:"size:10;not null"` // imei, serial, document
+	Label     string    `gorm:"size:100;not null"`
+	Hash      string    `gorm:"size:64;not null;uniqueIndex"`
+	Hint      string    `gorm:"size:10"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID"`
+}
+
+func (Identifier) TableName() string {
+	return "core_identifier"
+}
+
+// IdentifierConflict is an attempt to register an identifier another user
+// already registered. The attempt looks successful to the user, so the
+// registry cannot be probed, and admins decide who the item belongs to.
+type IdentifierConflict struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement"`
+	IdentifierID int64      `gorm:"column:identifier_id;not null;index"`
+	UserID       int64      `gorm:"column:user_id;not null;index"` // user who tried to register it
+	Label        string     `gorm:"size:100;not null"`
+	Status       string     `gorm:"size:10;default:'open';index"` // open, transferred, dismissed, withdrawn
+	ResolvedByID *int64     `gorm:"column:resolved_by_id"`
+	ResolvedAt   *time.Time `gorm:"column:resolved_at"`
+	CreatedAt    time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	Identifier Identifier `gorm:"foreignKey:IdentifierID"`
+	User       User       `gorm:"foreignKey:UserID"`
+}
+
+func (IdentifierConflict) TableName() string {
+	return "core_identifierconflict"
+}
+
+// FoundReport is a finder's private report of an item they picked up. When
+// its identifier matches a registered one, the owner is notified and can link
+// it to their lost post, which makes the finder a claimant there.
+type FoundReport struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement"`
+	FinderID       int64      `gorm:"column:finder_id;not null;index"`
+	Title          string     `gorm:"size:200;not null"`
+	Description    string     `gorm:"type:text"`
+	Location       string     `gorm:"size:255"`
+	IdentifierKind string     `gorm:"column:identifier_kind;size:10"`
+	IdentifierHash string     `gorm:"column:identifier_hash;size:64;index"` // empty without an identifier
+	IdentifierHint string     `gorm:"column:identifier_hint;size:10"`
+	OwnerID        *int64     `gorm:"column:owner_id;index"`        // registered owner, once matched
+	TagID          *int64     `gorm:"column:tag_id"`                // belonging tag the finder scanned, if any
+	ItemID         *int64     `gorm:"column:item_id"`               // owner's post it was linked to
+	Status         string     `gorm:"size:10;default:'open';index"` // open, linked, closed
+	MatchedAt      *time.Time `gorm:"column:matched_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	Finder User      `gorm:"foreignKey:FinderID"`
+	Owner  *User     `gorm:"foreignKey:OwnerID"`
+	Item   *LostItem `gorm:"foreignKey:ItemID"`
+}
+
+func (FoundReport) TableName() string {
+	return "core_foundreport"
+}
+
+// BelongingTag is a printable QR sticker for something a user owns. The code
+// opens the public page /t/:code, where a finder can message the owner
+// without either side seeing the other's contact details.
+type BelongingTag struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement"`
+	UserID        int64      `gorm:"column:user_id;not null;index"`
+	Code          string     `gorm:"size:16;not null;uniqueIndex"`
+	Label         string     `gorm:"size:100;not null"`
+	Note          string     `gorm:"size:255"` // shown to finders, e.g. a reward offer
+	IsActive      bool       `gorm:"column:is_active;default:true"`
+	ScanCount     int        `gorm:"column:scan_count;default:0"`
+	LastScannedAt *time.Time `gorm:"column:last_scanned_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID"`
+}
+
+func (BelongingTag) TableName() string {
+	return "core_belongingtag"
+}
+
+// TagMessage is an anonymous message a finder left on a tag's page. SenderHash
+// is a keyed hash of the sender's IP, kept only for rate limiting.
+type TagMessage struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement"`
+	TagID      int64      `gorm:"column:tag_id;not null;index"`
+	Content    string     `gorm:"type:text;not null"`
+	Contact    string     `gorm:"size:100"` // optional way to reach the finder
+	SenderHash string     `gorm:"column:sender_hash;size:64;index"`
+	ReadAt     *time.Time `gorm:"column:read_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (TagMessage) TableName() string {
+	return "core_tagmessage"
+}
+
+// NotificationPreference is a user's channel choice for one notification type.
+// Missing rows fall back to the defaults in utils.DefaultPreference.
+type NotificationPreference struct {
+	ID     int64  `gorm:"primaryKey;autoIncrement"`
+	UserID int64  `gorm:"column:user_id;not null;uniqueIndex:idx_notificationpref_user_type"`
+	Type   string `gorm:"size:30;not null;uniqueIndex:idx_notificationpref_user_type"`
+	InApp  bool   `gorm:"column:in_app;not null"` // no default tag, gorm would store an explicit false as true
+	Email  string `gorm:"size:10;default:'off'"`  // off, instant, digest
+}
+
+func (NotificationPreference) TableName() string {
+	return "core_notificationpreference"
+}
+
+// EmailDelivery is one queued email. Instant emails are sent by the mail worker;
+// digest entries wait until the daily digest bundles them into a single email.
+type EmailDelivery struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement"`
+	UserID         int64      `gorm:"column:user_id;not null;index"`
+	NotificationID *int64     `gorm:"column:notification_id"`
+	Type           string     `gorm:"size:30"`
+	ToAddress      string     `gorm:"column:to_address;size:254;not null"`
+	Subject        string     `gorm:"size:255;not null"`
+	TextBody       string     `gorm:"column:text_body;type:text"`
+	HTMLBody       string     `gorm:"column:html_body;type:longtext"`
+	Status         string     `gorm:"size:20;default:'pending';index"` // pending, sent, failed, digest, digested
+	Attempts       int        `gorm:"default:0"`
+	LastError      string     `gorm:"column:last_error;type:text"`
+	NextAttemptAt  *time.Time `gorm:"column:next_attempt_at;index"`
+	SentAt         *time.Time `gorm:"column:sent_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	User User `gorm:"foreignKey:UserID"`
+}
+
+func (EmailDelivery) TableName() string {
+	return "core_emaildelivery"
+}
+
+// WebhookSubscription is an admin-configured endpoint that receives signed
+// event payloads, e.g. a campus security system mirroring new posts
+type WebhookSubscription struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement"`
+	Name        string    `gorm:"size:100;not null"`
+	URL         string    `gorm:"column:url;size:500;not null"`
+	Secret      string    `gorm:"size:64;not null"`  // HMAC-SHA256 key for X-TemuIN-Signature
+	Events      string    `gorm:"size:255;not null"` // comma-separated, e.g. "item.created,report.created"
+	IsActive    bool      `gorm:"column:is_active;default:true"`
+	CreatedByID *int64    `gorm:"column:created_by_id"`
 	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
 
-	Item     LostItem `gorm:"foreignKey:ItemID"`
-	Reporter User     `gorm:"foreignKey:ReporterID"`
+	CreatedBy *User `gorm:"foreignKey:CreatedByID"`
 }
 
-func (ItemReport) TableName() string {
-	return "core_itemreport"
+func (WebhookSubscription) TableName() string {
+	return "core_webhooksubscription"
+}
+
+// WebhookDelivery is one attempt series of an event to a subscription. A
+// manual redelivery creates a new row with the same EventID.
+type WebhookDelivery struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID int64      `gorm:"column:subscription_id;not null;index"`
+	EventID        string     `gorm:"column:event_id;size:36;not null;index"`
+	Event          string     `gorm:"size:50;not null"`
+	Payload        string     `gorm:"type:longtext;not null"`
+	Status         string     `gorm:"size:20;default:'pending';index"` // pending, success, failed
+	Attempts       int        `gorm:"default:0"`
+	ResponseCode   int        `gorm:"column:response_code;default:0"`
+	ResponseBody   string     `gorm:"column:response_body;type:text"` // truncated
+	LastError      string     `gorm:"column:last_error;type:text"`
+	NextAttemptAt  *time.Time `gorm:"column:next_attempt_at;index"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "core_webhookdelivery"
+}
+
+// ModerationRule is an admin-managed content filter applied to posts and comments
+type ModerationRule struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement"`
+	Name         string     `gorm:"size:100;not null"`
+	Kind         string     `gorm:"size:20;not null"`             // keyword, regex, phone, url, bank
+	Pattern      string     `gorm:"type:text"`                    // keyword list (one per line) or regex; unused for built-in detectors
+	Scope        string     `gorm:"size:20;default:'all'"`        // all, items, comments
+	Action       string     `gorm:"size:20;not null"`             // block, hold, flag
+	ReportReason string     `gorm:"column:report_reason;size:50"` // reason used when Action is flag
+	IsActive     bool       `gorm:"column:is_active;default:true"`
+	HitCount     int        `gorm:"column:hit_count;default:0"`
+	LastHitAt    *time.Time `gorm:"column:last_hit_at"`
+	CreatedByID  *int64     `gorm:"column:created_by_id"`
+	CreatedAt    time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	CreatedBy *User `gorm:"foreignKey:CreatedByID"`
+}
+
+func (ModerationRule) TableName() string {
+	return "core_moderationrule"
 }
 
 type Notification struct {
 	ID              int64     `gorm:"primaryKey;autoIncrement"`
 	UserID          int64     `gorm:"column:user_id;not null"`
-	Type            string    `gorm:"size:30;not null"` // report, warning, system_update
+	Type            string    `gorm:"size:30;not null"` // report, warning, system_update, appeal, saved_search, found_item, handover
 	Title           string    `gorm:"size:200;not null"`
 	Message         string    `gorm:"type:text;not null"`
 	IsRead          bool      `gorm:"column:is_read;default:false"`
@@ -223,3 +779,47 @@ type SiteVisit struct {
 func (SiteVisit) TableName() string {
 	return "core_sitevisit"
 }
+
+// ModerationAction is the audit trail of every sanction applied to a user
+type ModerationAction struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement"`
+	UserID    int64      `gorm:"column:user_id;not null;index"`
+	AdminID   *int64     `gorm:"column:admin_id"`  // nil when applied automatically (strike escalation)
+	Action    string     `gorm:"size:20;not null"` // strike, suspend, ban, unban, lift
+	Reason    string     `gorm:"size:255"`
+	ReportID  *int64     `gorm:"column:report_id"`
+	ExpiresAt *time.Time `gorm:"column:expires_at"` // suspension end, nil for permanent actions
+	AppealID  *int64     `gorm:"column:appeal_id"`  // set on the unban or lift that resolved an approved appeal
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	User   User        `gorm:"foreignKey:UserID"`
+	Admin  *User       `gorm:"foreignKey:AdminID"`
+	Report *ItemReport `gorm:"foreignKey:ReportID"`
+}
+
+func (ModerationAction) TableName() string {
+	return "core_moderationaction"
+}
+
+// BanAppeal is a banned or suspended user's request to lift a sanction.
+// Each sanction (ModerationAction) can be appealed once.
+type BanAppeal struct {
+	ID                 int64      `gorm:"primaryKey;autoIncrement"`
+	UserID             int64      `gorm:"column:user_id;not null;index"`
+	ModerationActionID int64      `gorm:"column:moderation_action_id;not null;uniqueIndex"`
+	Statement          string     `gorm:"type:text;not null"`
+	Status             string     `gorm:"size:20;default:'pending'"` // pending, approved, denied
+	AdminID            *int64     `gorm:"column:admin_id"`
+	AdminNote          string     `gorm:"column:admin_note;type:text"`
+	DecidedAt          *time.Time `gorm:"column:decided_at"`
+	CreatedAt          time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt          time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	User             User             `gorm:"foreignKey:UserID"`
+	Admin            *User            `gorm:"foreignKey:AdminID"`
+	ModerationAction ModerationAction `gorm:"foreignKey:ModerationActionID"`
+}
+
+func (BanAppeal) TableName() string {
+	return "core_banappeal"
+}
@@ -15,6 +15,7 @@ func RegisterRoutes(r *gin.Engine) {
 		public.GET("/", handlers.LandingPage)
 
 		public.GET("/images/:pk", handlers.GetItemImage) // New Image Route
+		public.GET("/images/:pk/:n", handlers.GetItemImageAt)
 		public.GET("/login", handlers.LoginPage)
 		public.POST("/login", handlers.Login)
 		public.GET("/register", handlers.RegisterPage)
@@ -32,6 +33,19 @@ func RegisterRoutes(r *gin.Engine) {
 		public.GET("/category/:pk", handlers.CategoryPage)
 		public.GET("/subcategory/:pk", handlers.SubCategoryPage)
 
+		// Map view; pins are clustered and approximate
+		public.GET("/map", handlers.MapPage)
+		public.GET("/items/pins", handlers.ItemPins)
+		public.GET("/places/suggest", handlers.SuggestPlaces)
+
+		// Belonging tags: the page a QR sticker opens, usable without an account
+		public.GET("/t/:code", handlers.ScanTag)
+		public.POST("/t/:code/message", handlers.SendTagMessage)
+
+		// Ban appeals (session holds the restricted user, not a login)
+		public.GET("/appeal", handlers.AppealPage)
+		public.POST("/appeal", handlers.SubmitAppeal)
+
 		// Midtrans payment notification callback (public, no auth)
 		public.POST("/topup/notification", handlers.MidtransNotification)
 	}
@@ -48,8 +62,30 @@ func RegisterRoutes(r *gin.Engine) {
 
 		authorized.GET("/profile", handlers.Profile)
 		authorized.POST("/profile/update", handlers.UpdateProfile)
+		authorized.POST("/profile/notifications", handlers.UpdateNotificationPreferences)
+		authorized.POST("/searches", handlers.CreateSavedSearch)
+		authorized.POST("/searches/:id/toggle", handlers.ToggleSavedSearch)
+		authorized.POST("/searches/:id/delete", handlers.DeleteSavedSearch)
 		authorized.GET("/profile/picture/:user_id", handlers.GetProfilePicture)
 
+		// Identifier registry and found-item reports
+		authorized.GET("/registry", handlers.RegistryPage)
+		authorized.POST("/registry", handlers.RegisterIdentifier)
+		authorized.POST("/registry/:id/delete", handlers.DeleteIdentifier)
+		authorized.POST("/registry/pending/:id/delete", handlers.WithdrawIdentifierConflict)
+		authorized.GET("/found/new", handlers.FoundReportPage)
+		authorized.POST("/found", handlers.SubmitFoundReport)
+		authorized.GET("/found/:id", handlers.FoundReportDetail)
+		authorized.POST("/found/:id/link", handlers.LinkFoundReport)
+		authorized.POST("/found/:id/close", handlers.CloseFoundReport)
+		authorized.GET("/tags", handlers.TagListPage)
+		authorized.POST("/tags", handlers.CreateTag)
+		authorized.GET("/tags/sheet.pdf", handlers.TagSheet)
+		authorized.POST("/tags/:id", handlers.UpdateTag)
+		authorized.GET("/tags/:id/qr", handlers.TagQRCode)
+		authorized.POST("/tags/:id/toggle", handlers.ToggleTag)
+		authorized.POST("/tags/:id/delete", handlers.DeleteTag)
+
 		// TopUp routes
 		authorized.POST("/topup/initiate", handlers.InitiateTopUp)
 		authorized.POST("/topup/confirm", handlers.ConfirmTopUp)
@@ -60,6 +96,15 @@ func RegisterRoutes(r *gin.Engine) {
 		authorized.POST("/item/:pk/found", handlers.MarkAsFound)
 		authorized.POST("/item/:pk/select-finder", handlers.SelectFinder) // NEW
 		authorized.POST("/item/:pk/return", handlers.ConfirmReturn)
+		authorized.GET("/item/:pk/handover/qr", handlers.HandoverQRCode)
+		authorized.POST("/item/:pk/meetups", handlers.ProposeMeetup)
+		authorized.POST("/item/:pk/close", handlers.CloseItem)
+		authorized.POST("/item/:pk/reopen", handlers.ReopenItem)
+		authorized.POST("/meetups/:id/accept", handlers.AcceptMeetup)
+		authorized.POST("/meetups/:id/decline", handlers.DeclineMeetup)
+		authorized.POST("/meetups/:id/cancel", handlers.CancelMeetup)
+		authorized.POST("/meetups/:id/no-show", handlers.ReportNoShow)
+		authorized.GET("/meetups/:id/calendar.ics", handlers.MeetupCalendar)
 
 		// User post management
 		authorized.GET("/item/:pk/edit", handlers.EditItemPage)
@@ -69,10 +114,17 @@ func RegisterRoutes(r *gin.Engine) {
 		// Report routes
 		authorized.POST("/item/:pk/report", handlers.SubmitReport)
 
+		// Private messages between owner and claimants
+		authorized.POST("/item/:pk/messages", handlers.StartConversation)
+		authorized.GET("/messages", handlers.MessageInbox)
+		authorized.GET("/messages/:id", handlers.ConversationPage)
+		authorized.POST("/messages/:id", handlers.SendMessage)
+
 		// Notification routes
 		authorized.GET("/notifications", handlers.NotificationListPage)
 		authorized.GET("/api/notifications", handlers.GetNotifications)
 		authorized.GET("/api/notifications/count", handlers.GetUnreadCount)
+		authorized.GET("/api/notifications/stream", handlers.StreamNotifications)
 		authorized.POST("/api/notifications/:id/read", handlers.MarkAsRead)
 		authorized.POST("/api/notifications/read-all", handlers.MarkAllAsRead)
 
@@ -90,11 +142,57 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.POST("/item/:pk/delete", handlers.AdminDeleteItem)
 		admin.POST("/user/:id/ban", handlers.BanUser)
 		admin.POST("/user/:id/unban", handlers.UnbanUser)
+		admin.POST("/user/:id/suspend", handlers.SuspendUser)
 
 		// Report management
 		admin.GET("/reports", handlers.AdminReportList)
 		admin.POST("/report/:id/resolve", handlers.ResolveReport)
 		admin.POST("/report/:id/warn", handlers.WarnUser)
+		admin.POST("/reports/bulk", handlers.BulkReportAction)
+		admin.GET("/report/evidence/:id", handlers.GetReportEvidence)
+		admin.GET("/messages/:id", handlers.AdminConversation)
+
+		// Content moderation rules
+		admin.GET("/moderation/rules", handlers.AdminRuleList)
+		admin.POST("/moderation/rules", handlers.CreateRule)
+		admin.POST("/moderation/rules/:id", handlers.UpdateRule)
+		admin.POST("/moderation/rules/:id/toggle", handlers.ToggleRule)
+		admin.POST("/moderation/rules/:id/delete", handlers.DeleteRule)
+		admin.GET("/moderation/held", handlers.AdminHeldContent)
+		admin.POST("/moderation/held/item/:pk/approve", handlers.ApproveHeldItem)
+		admin.POST("/moderation/held/item/:pk/reject", handlers.RejectHeldItem)
+		admin.POST("/moderation/held/comment/:id/approve", handlers.ApproveHeldComment)
+		admin.POST("/moderation/held/comment/:id/reject", handlers.RejectHeldComment)
+		admin.GET("/duplicates", handlers.AdminDuplicateList)
+		admin.POST("/duplicates/dismiss", handlers.DismissDuplicates)
+		admin.GET("/registry/conflicts", handlers.AdminIdentifierConflicts)
+		admin.POST("/registry/conflicts/:id", handlers.ResolveIdentifierConflict)
+
+		// Outbound webhooks
+		admin.GET("/webhooks", handlers.AdminWebhookList)
+		admin.POST("/webhooks", handlers.CreateWebhook)
+		admin.POST("/webhooks/:id", handlers.UpdateWebhook)
+		admin.POST("/webhooks/:id/toggle", handlers.ToggleWebhook)
+		admin.POST("/webhooks/:id/rotate", handlers.RotateWebhookSecret)
+		admin.POST("/webhooks/:id/delete", handlers.DeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", handlers.AdminWebhookDeliveries)
+		admin.POST("/webhooks/deliveries/:id/redeliver", handlers.RedeliverWebhook)
+
+		// Ban appeals
+		admin.GET("/appeals", handlers.AdminAppealList)
+		admin.POST("/appeals/:id/approve", handlers.ApproveAppeal)
+		admin.POST("/appeals/:id/deny", handlers.DenyAppeal)
+
+		// Places directory
+		admin.GET("/places", handlers.AdminPlaceList)
+		admin.POST("/places", handlers.CreatePlace)
+		admin.POST("/places/:id", handlers.UpdatePlace)
+		admin.POST("/places/:id/toggle", handlers.TogglePlace)
+		admin.POST("/places/:id/delete", handlers.DeletePlace)
+		admin.GET("/attributes", handlers.AdminAttributeList)
+		admin.POST("/attributes", handlers.CreateAttributeField)
+		admin.POST("/attributes/:id", handlers.UpdateAttributeField)
+		admin.POST("/attributes/:id/delete", handlers.DeleteAttributeField)
 
 		// Withdrawal management
 		admin.GET("/withdrawals", handlers.AdminWithdrawalsPage)
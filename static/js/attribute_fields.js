@@ -0,0 +1,76 @@
+// Attribute fields of the picked subcategory on the report and edit forms.
+// Schemas (keyed by subcategory ID) come from #attribute-schemas-data and
+// the current values (keyed by field ID) from #attribute-values-data.
+(function () {
+    function readJSON(id) {
+        const el = document.getElementById(id);
+        try {
+            return el ? JSON.parse(el.textContent) : {};
+        } catch (e) {
+            return {};
+        }
+    }
+
+    const inputTypes = { text: 'text', number: 'number', date: 'date' };
+
+    // Runs after the inline scripts that fill the subcategory select
+    document.addEventListener('DOMContentLoaded', function () {
+        const container = document.getElementById('attributeFields');
+        const subSelect = document.getElementById('id_subcategory');
+        const catSelect = document.getElementById('id_category');
+        if (!container || !subSelect) return;
+
+        const schemas = readJSON('attribute-schemas-data');
+        const values = readJSON('attribute-values-data');
+
+        // Keep what was typed when switching back and forth
+        function remember() {
+            container.querySelectorAll('[name^="attr_"]').forEach(function (input) {
+                values[input.name.slice(5)] = input.value;
+            });
+        }
+
+        function render() {
+            remember();
+            container.innerHTML = '';
+            const fields = schemas[subSelect.value] || [];
+            container.style.display = fields.length ? '' : 'none';
+
+            fields.forEach(function (field) {
+                const group = document.createElement('div');
+                const label = document.createElement('label');
+                label.textContent = field.name + (field.is_required ? ' *' : '');
+                group.appendChild(label);
+
+                let input;
+                if (field.kind === 'enum') {
+                    input = document.createElement('select');
+                    input.appendChild(new Option('Pilih...', ''));
+                    field.options.forEach(function (option) {
+                        input.appendChild(new Option(option, option));
+                    });
+                } else {
+                    input = document.createElement('input');
+                    input.type = inputTypes[field.kind] || 'text';
+                    if (field.kind === 'number') input.step = 'any';
+                    if (field.kind === 'text') input.maxLength = 255;
+                }
+                input.name = 'attr_' + field.id;
+                input.value = values[field.id] || '';
+                input.required = field.is_required;
+                group.appendChild(input);
+
+                if (field.is_private) {
+                    const hint = document.createElement('small');
+                    hint.textContent = '🔒 Privat: hanya terlihat oleh Anda, dipakai untuk mencocokkan klaim penemu.';
+                    group.appendChild(hint);
+                }
+                container.appendChild(group);
+            });
+        }
+
+        subSelect.addEventListener('change', render);
+        if (catSelect) catSelect.addEventListener('change', render);
+        render();
+    });
+})();
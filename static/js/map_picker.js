@@ -0,0 +1,50 @@
+// Map picker for the report/edit forms. Writes the chosen point into the
+// hidden latitude/longitude inputs.
+(function () {
+    const el = document.getElementById('mapPicker');
+    if (!el || typeof L === 'undefined') return;
+
+    const latInput = document.getElementById('latitudeInput');
+    const lngInput = document.getElementById('longitudeInput');
+    const defaultCenter = [-6.2, 106.816666]; // Jakarta
+
+    const hasPoint = latInput.value !== '' && lngInput.value !== '';
+    const map = L.map(el).setView(hasPoint ? [latInput.value, lngInput.value] : defaultCenter, hasPoint ? 16 : 11);
+    L.tileLayer('https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png', {
+        maxZoom: 19,
+        attribution: '&copy; OpenStreetMap'
+    }).addTo(map);
+
+    let marker = null;
+    function setPoint(latlng) {
+        latInput.value = latlng.lat.toFixed(6);
+        lngInput.value = latlng.lng.toFixed(6);
+        if (marker) {
+            marker.setLatLng(latlng);
+        } else {
+            marker = L.marker(latlng, { draggable: true }).addTo(map);
+            marker.on('dragend', function () { setPoint(marker.getLatLng()); });
+        }
+    }
+
+    if (hasPoint) setPoint(L.latLng(latInput.value, lngInput.value));
+    map.on('click', function (e) { setPoint(e.latlng); });
+
+    document.getElementById('mapClearBtn').addEventListener('click', function () {
+        latInput.value = '';
+        lngInput.value = '';
+        if (marker) {
+            map.removeLayer(marker);
+            marker = null;
+        }
+    });
+
+    document.getElementById('mapLocateBtn').addEventListener('click', function () {
+        if (!navigator.geolocation) return;
+        navigator.geolocation.getCurrentPosition(function (pos) {
+            const latlng = L.latLng(pos.coords.latitude, pos.coords.longitude);
+            map.setView(latlng, 17);
+            setPoint(latlng);
+        });
+    });
+})();
@@ -38,10 +38,32 @@ document.addEventListener('DOMContentLoaded', function() {
     // Load unread count on page load
     updateUnreadCount();
 
-    // Auto-refresh unread count every 30 seconds
-    setInterval(updateUnreadCount, 30000);
+    // Live updates; fall back to polling every 30 seconds
+    if (window.EventSource) {
+        connectNotificationStream();
+    } else {
+        setInterval(updateUnreadCount, 30000);
+    }
 });
 
+// Subscribe to server-sent notification events. Each tab holds its own stream;
+// the browser reconnects on its own and resumes from Last-Event-ID.
+function connectNotificationStream() {
+    const source = new EventSource('/api/notifications/stream');
+
+    source.addEventListener('unread', function(e) {
+        const data = JSON.parse(e.data);
+        setBadge('notificationBadge', data.count);
+        setBadge('messageBadge', data.messages);
+    });
+
+    source.addEventListener('notification', function() {
+        if (notificationDropdownOpen) {
+            loadNotifications();
+        }
+    });
+}
+
 // Load notifications
 function loadNotifications() {
     fetch('/api/notifications?limit=10')
@@ -75,13 +97,19 @@ function displayNotifications(notifications) {
         const iconMap = {
             'report': 'report',
             'warning': 'warning',
-            'system_update': 'info'
+            'system_update': 'info',
+            'appeal': 'gavel',
+            'found_item': 'verified',
+            'handover': 'handshake'
         };
 
         const colorMap = {
             'report': '#faa61a',
             'warning': '#dc3545',
-            'system_update': '#007bff'
+            'system_update': '#007bff',
+            'appeal': '#7289da',
+            'found_item': '#3ba55c',
+            'handover': '#1abc9c'
         };
 
         const icon = iconMap[notification.Type] || 'notifications';
@@ -166,21 +194,27 @@ function markAllNotificationsAsRead() {
     });
 }
 
-// Update unread count badge
+// Show a count on a badge element, hiding it when zero
+function setBadge(id, count) {
+    const badge = document.getElementById(id);
+    if (badge) {
+        if (count > 0) {
+            badge.textContent = count > 99 ? '99+' : count;
+            badge.style.display = 'flex';
+        } else {
+            badge.style.display = 'none';
+        }
+    }
+}
+
+// Update unread count badges
 function updateUnreadCount() {
     fetch('/api/notifications/count')
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                const badge = document.getElementById('notificationBadge');
-                if (badge) {
-                    if (data.count > 0) {
-                        badge.textContent = data.count > 99 ? '99+' : data.count;
-                        badge.style.display = 'flex';
-                    } else {
-                        badge.style.display = 'none';
-                    }
-                }
+                setBadge('notificationBadge', data.count);
+                setBadge('messageBadge', data.messages);
             }
         })
         .catch(error => {
@@ -0,0 +1,65 @@
+// Location autocomplete against the places directory. Picking a suggestion
+// fills the text field and the hidden place_id; typing something else
+// clears place_id again.
+(function () {
+    const input = document.getElementById('locationInput');
+    const placeInput = document.getElementById('placeIdInput');
+    const list = document.getElementById('placeSuggestions');
+    if (!input || !placeInput || !list) return;
+
+    let timer = null;
+    let controller = null;
+
+    function hide() {
+        list.style.display = 'none';
+        list.innerHTML = '';
+    }
+
+    function pick(place) {
+        input.value = place.full_name;
+        placeInput.value = place.id;
+        placeInput.dataset.name = place.full_name;
+        hide();
+    }
+
+    function render(places) {
+        list.innerHTML = '';
+        if (!places.length) {
+            hide();
+            return;
+        }
+        places.forEach(function (place) {
+            const option = document.createElement('div');
+            option.className = 'place-suggestion';
+            option.textContent = place.full_name;
+            option.addEventListener('mousedown', function (e) {
+                e.preventDefault();
+                pick(place);
+            });
+            list.appendChild(option);
+        });
+        list.style.display = 'block';
+    }
+
+    input.addEventListener('input', function () {
+        if (input.value !== placeInput.dataset.name) {
+            placeInput.value = '';
+        }
+        clearTimeout(timer);
+        const q = input.value.trim();
+        if (q.length < 2) {
+            hide();
+            return;
+        }
+        timer = setTimeout(function () {
+            if (controller) controller.abort();
+            controller = new AbortController();
+            fetch('/places/suggest?q=' + encodeURIComponent(q), { signal: controller.signal })
+                .then(function (res) { return res.json(); })
+                .then(function (data) { render(data.places || []); })
+                .catch(function () {});
+        }, 200);
+    });
+
+    input.addEventListener('blur', hide);
+})();
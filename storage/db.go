@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"temuin/models"
+
+	"gorm.io/gorm"
+)
+
+// DBStore keeps blobs in the core_blob table
+type DBStore struct {
+	db *gorm.DB
+}
+
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+func (s *DBStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	blob := models.Blob{Key: key, Data: data, ContentType: contentType, Size: len(data)}
+	return s.db.WithContext(ctx).Save(&blob).Error
+}
+
+func (s *DBStore) Get(ctx context.Context, key string) (*Object, error) {
+	var blob models.Blob
+	if err := s.db.WithContext(ctx).Where("blob_key = ?", key).Take(&blob).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &Object{Data: blob.Data, ContentType: blob.ContentType}, nil
+}
+
+func (s *DBStore) Delete(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("blob_key = ?", key).Delete(&models.Blob{}).Error
+}
@@ -0,0 +1,85 @@
+// Package storage keeps uploaded blobs (post photos, avatars) behind one
+// interface, so they can live in MySQL, on disk or in an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"temuin/config"
+)
+
+// ErrNotFound is returned by Get for a key that holds nothing
+var ErrNotFound = errors.New("storage: object not found")
+
+// Object is a stored blob
+type Object struct {
+	Data        []byte
+	ContentType string
+}
+
+// Store keeps blobs by key. Keys are slash-separated paths like
+// "items/12/3f9a0c1e/thumb.jpg".
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Default is the backend chosen by STORAGE_BACKEND
+var Default Store
+
+// Init opens the configured backend. Call it after config.ConnectDB and
+// config.InitStorage.
+func Init() {
+	store, err := New(config.StorageBackend)
+	if err != nil {
+		log.Fatal("❌ Failed to open blob storage:", err)
+	}
+	Default = store
+	log.Printf("✅ Blob storage: %s", config.StorageBackend)
+}
+
+// New opens a backend by name: "db", "fs" or "s3"
+func New(backend string) (Store, error) {
+	switch backend {
+	case "db":
+		return NewDBStore(config.DB), nil
+	case "fs":
+		return NewFSStore(config.StorageDir)
+	case "s3":
+		return NewS3Store(S3Options{
+			Endpoint:  config.S3Endpoint,
+			Region:    config.S3Region,
+			Bucket:    config.S3Bucket,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			UseSSL:    config.S3UseSSL,
+		})
+	}
+	return nil, fmt.Errorf("storage: unknown backend %q", backend)
+}
+
+// NewKey returns a fresh key under prefix, e.g. "items/12/3f9a0c1e5b7d2a64".
+// Keys are never reused, so a replaced photo gets a new URL-safe name.
+func NewKey(prefix string) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return strings.TrimRight(prefix, "/") + "/" + hex.EncodeToString(b)
+}
+
+// DeleteAll removes keys, logging failures. Used after the rows pointing at
+// the blobs are gone, when there is nothing left to roll back.
+func DeleteAll(ctx context.Context, store Store, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("[storage] delete %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// testStore runs the behavior every backend shares against store
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	key := NewKey("test/blobs")
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Put(ctx, key, pngHeader, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	obj, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(obj.Data, pngHeader) {
+		t.Errorf("Get data = %q, want %q", obj.Data, pngHeader)
+	}
+	if obj.ContentType != "image/png" {
+		t.Errorf("Get content type = %q, want image/png", obj.ContentType)
+	}
+
+	replaced := append(append([]byte{}, pngHeader...), 0x01)
+	if err := store.Put(ctx, key, replaced, "image/png"); err != nil {
+		t.Fatalf("Put (replace): %v", err)
+	}
+	if obj, err := store.Get(ctx, key); err != nil || !bytes.Equal(obj.Data, replaced) {
+		t.Errorf("Get after replace = %v, %v; want the new data", obj, err)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete(missing) = %v, want nil", err)
+	}
+}
+
+func TestFSStore(t *testing.T) {
+	store, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+func TestFSStoreRejectsEscapingKeys(t *testing.T) {
+	store, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "/", "../outside", "items/../../outside"} {
+		if err := store.Put(context.Background(), key, pngHeader, "image/png"); err == nil {
+			t.Errorf("Put(%q) succeeded, want an invalid key error", key)
+		}
+	}
+}
+
+// TestS3Store runs against a MinIO (or other S3-compatible) server when
+// STORAGE_TEST_S3_ENDPOINT is set, e.g.
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	mc mb local/temuin-test
+//	STORAGE_TEST_S3_ENDPOINT=localhost:9000 STORAGE_TEST_S3_BUCKET=temuin-test \
+//	STORAGE_TEST_S3_ACCESS_KEY=minioadmin STORAGE_TEST_S3_SECRET_KEY=minioadmin go test ./storage
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_S3_ENDPOINT not set")
+	}
+	store, err := NewS3Store(S3Options{
+		Endpoint:  endpoint,
+		Region:    os.Getenv("STORAGE_TEST_S3_REGION"),
+		Bucket:    os.Getenv("STORAGE_TEST_S3_BUCKET"),
+		AccessKey: os.Getenv("STORAGE_TEST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("STORAGE_TEST_S3_SECRET_KEY"),
+		UseSSL:    os.Getenv("STORAGE_TEST_S3_SSL") == "true",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	testStore(t, store)
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"temuin/models"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// AttributeKinds lists the field types an attribute schema may use
+var AttributeKinds = []string{"enum", "text", "number", "date"}
+
+// AttributeKindLabels are the Indonesian names of the field types
+var AttributeKindLabels = map[string]string{
+	"enum":   "Pilihan",
+	"text":   "Teks",
+	"number": "Angka",
+	"date":   "Tanggal",
+}
+
+const (
+	attributeDateLayout = "2006-01-02"
+	maxAttributeLength  = 255
+)
+
+// AttributeOptions splits an enum field's choices, one per line
+func AttributeOptions(field *models.AttributeField) []string {
+	var options []string
+	for _, line := range strings.Split(field.Options, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			options = append(options, line)
+		}
+	}
+	return options
+}
+
+// SubCategoryFields returns the attribute schema of a subcategory in form order
+func SubCategoryFields(db *gorm.DB, subCategoryID int64) []models.AttributeField {
+	var fields []models.AttributeField
+	db.Where("subcategory_id = ?", subCategoryID).Order("position, id").Find(&fields)
+	return fields
+}
+
+// NormalizeAttribute checks a submitted value against its field and returns
+// it in stored form: enum choices as defined, numbers without trailing zeros
+// and dates as YYYY-MM-DD. Returns a user-facing message when invalid; an
+// empty value is valid unless the field is required.
+func NormalizeAttribute(field *models.AttributeField, raw string) (string, string) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		if field.IsRequired {
+			return "", field.Name + " wajib diisi"
+		}
+		return "", ""
+	}
+
+	switch field.Kind {
+	case "enum":
+		for _, option := range AttributeOptions(field) {
+			if strings.EqualFold(option, value) {
+				return option, ""
+			}
+		}
+		return "", "Pilihan " + field.Name + " tidak dikenal"
+	case "number":
+		n, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", field.Name + " harus berupa angka"
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), ""
+	case "date":
+		date, err := time.Parse(attributeDateLayout, value)
+		if err != nil {
+			return "", field.Name + " harus berupa tanggal"
+		}
+		return date.Format(attributeDateLayout), ""
+	default:
+		if len(value) > maxAttributeLength {
+			return "", fmt.Sprintf("%s maksimal %d karakter", field.Name, maxAttributeLength)
+		}
+		return value, ""
+	}
+}
+
+// AttributesMatch compares two stored values of a field. Text ignores case,
+// spaces and punctuation, so "35-209 900 176" matches an IMEI typed as
+// "35209900176"; dates may be a day apart.
+func AttributesMatch(field *models.AttributeField, a, b string) bool {
+	switch field.Kind {
+	case "text":
+		return compactText(a) == compactText(b)
+	case "date":
+		dateA, errA := time.Parse(attributeDateLayout, a)
+		dateB, errB := time.Parse(attributeDateLayout, b)
+		if errA != nil || errB != nil {
+			return false
+		}
+		diff := dateA.Sub(dateB)
+		return diff <= 24*time.Hour && diff >= -24*time.Hour
+	default:
+		return a == b
+	}
+}
+
+// compactText keeps only the lowercased letters and digits of a value
+func compactText(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// AttributeValue is a field of a post with its value, for display
+type AttributeValue struct {
+	Field models.AttributeField
+	Value string
+}
+
+// ItemAttributeValues lists the filled-in attributes of a post in form order.
+// Private fields are left out unless includePrivate.
+func ItemAttributeValues(db *gorm.DB, itemID int64, includePrivate bool) []AttributeValue {
+	var rows []models.ItemAttribute
+	query := db.Preload("Field").
+		Joins("JOIN core_attributefield ON core_attributefield.id = core_itemattribute.field_id").
+		Where("core_itemattribute.item_id = ?", itemID).
+		Order("core_attributefield.position, core_attributefield.id")
+	if !includePrivate {
+		query = query.Where("core_attributefield.is_private = ?", false)
+	}
+	query.Find(&rows)
+
+	values := make([]AttributeValue, len(rows))
+	for i, row := range rows {
+		values[i] = AttributeValue{Field: row.Field, Value: row.Value}
+	}
+	return values
+}
+
+// AttributeSignal is how one field of a claim compares with the post
+type AttributeSignal struct {
+	Field   models.AttributeField
+	Claimed string
+	Matched bool
+}
+
+// ClaimSignals compares what a claimant described with the post's values.
+// Fields either side left empty are skipped. Returns the signals and how
+// many matched.
+func ClaimSignals(db *gorm.DB, itemID, claimID int64) ([]AttributeSignal, int) {
+	stored := make(map[int64]string)
+	var itemRows []models.ItemAttribute
+	db.Where("item_id = ?", itemID).Find(&itemRows)
+	for _, row := range itemRows {
+		stored[row.FieldID] = row.Value
+	}
+
+	var claimRows []models.ClaimAttribute
+	db.Preload("Field").
+		Joins("JOIN core_attributefield ON core_attributefield.id = core_claimattribute.field_id").
+		Where("core_claimattribute.claim_id = ?", claimID).
+		Order("core_attributefield.position, core_attributefield.id").
+		Find(&claimRows)
+
+	var signals []AttributeSignal
+	matched := 0
+	for _, row := range claimRows {
+		value, ok := stored[row.FieldID]
+		if !ok {
+			continue
+		}
+		signal := AttributeSignal{Field: row.Field, Claimed: row.Value, Matched: AttributesMatch(&row.Field, value, row.Value)}
+		if signal.Matched {
+			matched++
+		}
+		signals = append(signals, signal)
+	}
+	return signals, matched
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// CalendarEvent is a single event exported as an iCalendar (.ics) file
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	AlarmBefore time.Duration // 0 for no alarm
+}
+
+// ICS renders the event as an RFC 5545 calendar that Google Calendar,
+// Outlook and Apple Calendar can import
+func ICS(event CalendarEvent) []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		buf.WriteString(icsFold(name + ":" + value))
+		buf.WriteString("\r\n")
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//TemuIN//Serah Terima//ID")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("BEGIN", "VEVENT")
+	line("UID", event.UID)
+	line("DTSTAMP", time.Now().UTC().Format(icsTimeLayout))
+	line("DTSTART", event.Start.UTC().Format(icsTimeLayout))
+	line("DTEND", event.End.UTC().Format(icsTimeLayout))
+	line("SUMMARY", icsEscape(event.Summary))
+	if event.Location != "" {
+		line("LOCATION", icsEscape(event.Location))
+	}
+	if event.Description != "" {
+		line("DESCRIPTION", icsEscape(event.Description))
+	}
+	if event.URL != "" {
+		line("URL", event.URL)
+	}
+	if event.AlarmBefore > 0 {
+		line("BEGIN", "VALARM")
+		line("ACTION", "DISPLAY")
+		line("DESCRIPTION", icsEscape(event.Summary))
+		line("TRIGGER", fmt.Sprintf("-PT%dM", int(event.AlarmBefore.Minutes())))
+		line("END", "VALARM")
+	}
+	line("END", "VEVENT")
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icsEscape escapes a TEXT value
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// icsFold splits a content line into 75-octet chunks without cutting a
+// UTF-8 character; continuation lines start with a space
+func icsFold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"temuin/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Built-in detectors for the phone, url and bank rule kinds
+var (
+	phonePattern = regexp.MustCompile(`(?:\+62|\b62|\b0)\s*8[\d\s\-.]{7,14}\d`)
+	urlPattern   = regexp.MustCompile(`(?i)\bhttps?://\S+|\bwww\.\S+|\b[a-z0-9-]+\.(?:com|id|net|org|co\.id|ly|me|link|shop|store)\b`)
+	bankPattern  = regexp.MustCompile(`(?i)\b(?:no\.?\s*rek(?:ening)?|norek|rekening|rek|bca|bri|bni|mandiri|bsi|cimb|dana|ovo|gopay)\b[\s:.\-a-z]{0,20}\d[\d\s\-]{6,20}\d`)
+)
+
+// actionRank orders rule actions from weakest to strongest
+var actionRank = map[string]int{
+	"flag":  1,
+	"hold":  2,
+	"block": 3,
+}
+
+// ModerationResult is the outcome of running the active rules over a piece of text
+type ModerationResult struct {
+	Action string                  // strongest action among the hits, empty when nothing matched
+	Hits   []models.ModerationRule // every rule that matched
+}
+
+// Blocked reports whether the content must be rejected
+func (r ModerationResult) Blocked() bool { return r.Action == "block" }
+
+// Held reports whether the content must be hidden until reviewed
+func (r ModerationResult) Held() bool { return r.Action == "hold" }
+
+// FlagRules returns the matching rules whose action is to open a report
+func (r ModerationResult) FlagRules() []models.ModerationRule {
+	var rules []models.ModerationRule
+	for _, rule := range r.Hits {
+		if rule.Action == "flag" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// CompileRulePattern validates a rule's pattern before it is saved
+func CompileRulePattern(kind, pattern string) error {
+	switch kind {
+	case "keyword":
+		if len(ruleKeywords(pattern)) == 0 {
+			return fmt.Errorf("keyword list is empty")
+		}
+	case "regex":
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("regex is empty")
+		}
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			return err
+		}
+	case "phone", "url", "bank":
+	default:
+		return fmt.Errorf("unknown rule kind %q", kind)
+	}
+	return nil
+}
+
+// ruleKeywords splits a keyword pattern into lowercased terms, one per line or comma
+func ruleKeywords(pattern string) []string {
+	var keywords []string
+	for _, k := range strings.FieldsFunc(pattern, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
+
+// ruleMatches reports whether the text triggers the rule
+func ruleMatches(rule models.ModerationRule, text string) bool {
+	switch rule.Kind {
+	case "keyword":
+		lower := strings.ToLower(text)
+		for _, k := range ruleKeywords(rule.Pattern) {
+			if strings.Contains(lower, k) {
+				return true
+			}
+		}
+	case "regex":
+		re, err := regexp.Compile("(?i)" + rule.Pattern)
+		return err == nil && re.MatchString(text)
+	case "phone":
+		return phonePattern.MatchString(text)
+	case "url":
+		return urlPattern.MatchString(text)
+	case "bank":
+		return bankPattern.MatchString(text)
+	}
+	return false
+}
+
+// CheckContent runs the active rules for the scope ("items" or "comments") over
+// the given texts and records a hit on every rule that matched.
+func CheckContent(db *gorm.DB, scope string, texts ...string) ModerationResult {
+	var rules []models.ModerationRule
+	db.Where("is_active = ? AND scope IN ?", true, []string{"all", scope}).Find(&rules)
+
+	text := strings.Join(texts, "\n")
+	var result ModerationResult
+	for _, rule := range rules {
+		if !ruleMatches(rule, text) {
+			continue
+		}
+		result.Hits = append(result.Hits, rule)
+		if actionRank[rule.Action] > actionRank[result.Action] {
+			result.Action = rule.Action
+		}
+	}
+
+	if len(result.Hits) > 0 {
+		ids := make([]int64, len(result.Hits))
+		for i, rule := range result.Hits {
+			ids[i] = rule.ID
+		}
+		db.Model(&models.ModerationRule{}).Where("id IN ?", ids).UpdateColumns(map[string]interface{}{
+			"hit_count":   gorm.Expr("hit_count + 1"),
+			"last_hit_at": time.Now(),
+		})
+	}
+
+	return result
+}
+
+// VisibleContent excludes posts and comments held for review from public queries
+func VisibleContent(db *gorm.DB) *gorm.DB {
+	return db.Where("is_held = ?", false)
+}
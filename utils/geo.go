@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"temuin/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	earthRadiusKm = 6371.0
+
+	// Public views only ever see coordinates rounded to this many decimals
+	// (about 1 km), so a post's exact spot stays private. Public filters and
+	// sorts use the rounded values too, so moving the search center cannot
+	// triangulate the exact pin.
+	approximateDecimals = 2
+	// approximateSlack is half a rounding step: an exact coordinate is never
+	// further than this from its rounded value
+	approximateSlack = 0.005
+
+	MinRadiusKm     = 1.0 // smaller radii would let a visitor triangulate a post
+	MaxRadiusKm     = 50.0
+	DefaultRadiusKm = 5.0
+)
+
+// approxLatSQL and approxLngSQL are the post's public, rounded coordinates
+// (see ApproximateCoordinate)
+const (
+	approxLatSQL = "ROUND(core_lostitem.latitude, 2)"
+	approxLngSQL = "ROUND(core_lostitem.longitude, 2)"
+)
+
+// haversineSQL is the great-circle distance in km from (?, ?) to the post's
+// approximate location. Its arguments are lat, lat, lng.
+const haversineSQL = "2 * 6371 * ASIN(SQRT(POWER(SIN(RADIANS(" + approxLatSQL + " - ?) / 2), 2) + " +
+	"COS(RADIANS(?)) * COS(RADIANS(" + approxLatSQL + ")) * POWER(SIN(RADIANS(" + approxLngSQL + " - ?) / 2), 2)))"
+
+// GeoPoint is a latitude/longitude pair in degrees
+type GeoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+// ParseCoordinates reads an optional map pin from form values. Both empty
+// means no pin.
+func ParseCoordinates(latValue, lngValue string) (*float64, *float64, error) {
+	latValue, lngValue = strings.TrimSpace(latValue), strings.TrimSpace(lngValue)
+	if latValue == "" && lngValue == "" {
+		return nil, nil, nil
+	}
+	lat, err1 := strconv.ParseFloat(latValue, 64)
+	lng, err2 := strconv.ParseFloat(lngValue, 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, nil, errors.New("invalid coordinates")
+	}
+	return &lat, &lng, nil
+}
+
+// HaversineKm is the great-circle distance between two points in km
+func HaversineKm(a, b GeoPoint) float64 {
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(a.Lat*math.Pi/180)*math.Cos(b.Lat*math.Pi/180)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// boundingBox returns the lat/lng ranges containing every point within
+// radiusKm of center. It is the cheap, index-friendly prefilter before the
+// exact haversine check.
+func boundingBox(center GeoPoint, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	minLat, maxLat = center.Lat-dLat, center.Lat+dLat
+
+	cos := math.Cos(center.Lat * math.Pi / 180)
+	if cos < 0.01 {
+		return minLat, maxLat, -180, 180
+	}
+	dLng := dLat / cos
+	return minLat, maxLat, center.Lng - dLng, center.Lng + dLng
+}
+
+// withinRadius keeps posts whose approximate location is within radiusKm of
+// center. The bounding box on the exact columns is widened by
+// approximateSlack, so it only uses the index and never decides the result.
+func withinRadius(db *gorm.DB, center GeoPoint, radiusKm float64) *gorm.DB {
+	minLat, maxLat, minLng, maxLng := boundingBox(center, radiusKm)
+	return db.Where("core_lostitem.latitude BETWEEN ? AND ?", minLat-approximateSlack, maxLat+approximateSlack).
+		Where("core_lostitem.longitude BETWEEN ? AND ?", minLng-approximateSlack, maxLng+approximateSlack).
+		Where(haversineSQL+" <= ?", center.Lat, center.Lat, center.Lng, radiusKm)
+}
+
+// ApproximateCoordinate rounds a coordinate for public display
+func ApproximateCoordinate(v float64) float64 {
+	scale := math.Pow(10, approximateDecimals)
+	return math.Round(v*scale) / scale
+}
+
+// CanSeeExactLocation reports whether viewer may see a post's exact pin: the
+// owner always, and the selected finder once there is one
+func CanSeeExactLocation(item *models.LostItem, viewer *models.User) bool {
+	if viewer == nil {
+		return false
+	}
+	return viewer.ID == item.UserID || (item.FinderID != nil && *item.FinderID == viewer.ID)
+}
+
+// MapPin is a cluster of posts, or a single post when Count is 1. Coordinates
+// are always approximate.
+type MapPin struct {
+	Lat    float64 `json:"lat"`
+	Lng    float64 `json:"lng"`
+	Count  int64   `json:"count"`
+	ItemID int64   `json:"item_id,omitempty"`
+	Title  string  `json:"title,omitempty"`
+	Status string  `json:"status,omitempty"`
+}
+
+// MapBounds is the visible area of the map, in degrees
+type MapBounds struct {
+	South, West, North, East float64
+}
+
+// ParseMapBounds reads "south,west,north,east"
+func ParseMapBounds(value string) (MapBounds, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return MapBounds{}, false
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return MapBounds{}, false
+		}
+		v[i] = f
+	}
+	b := MapBounds{South: v[0], West: v[1], North: v[2], East: v[3]}
+	if b.South > b.North || b.South < -90 || b.North > 90 || b.West < -180 || b.East > 180 {
+		return MapBounds{}, false
+	}
+	return b, true
+}
+
+// ClusterPins groups the posts matching filter inside bounds on a grid sized
+// for the zoom level (about 60px cells on 256px tiles)
+func ClusterPins(db *gorm.DB, filter ListingFilter, bounds MapBounds, zoom int) ([]MapPin, error) {
+	if zoom < 0 {
+		zoom = 0
+	}
+	if zoom > 18 {
+		zoom = 18
+	}
+	cell := 360 / math.Pow(2, float64(zoom)) * 60 / 256
+	filter.resolvePlace(db)
+
+	// Bounds and cells use the approximate location, like withinRadius
+	query := filter.apply(db, "").
+		Where("core_lostitem.latitude IS NOT NULL AND core_lostitem.longitude IS NOT NULL").
+		Where("core_lostitem.latitude BETWEEN ? AND ?", bounds.South-approximateSlack, bounds.North+approximateSlack).
+		Where(approxLatSQL+" BETWEEN ? AND ?", bounds.South, bounds.North)
+	if bounds.West <= bounds.East {
+		query = query.Where(approxLngSQL+" BETWEEN ? AND ?", bounds.West, bounds.East)
+	} else {
+		// The view crosses the antimeridian
+		query = query.Where("("+approxLngSQL+" >= ? OR "+approxLngSQL+" <= ?)", bounds.West, bounds.East)
+	}
+
+	var rows []struct {
+		Lat    float64
+		Lng    float64
+		Count  int64
+		ItemID int64
+	}
+	if err := query.
+		Select("AVG(" + approxLatSQL + ") AS lat, AVG(" + approxLngSQL + ") AS lng, COUNT(*) AS count, MIN(core_lostitem.id) AS item_id").
+		Group(fmt.Sprintf("FLOOR(%[2]s / %[1].8f), FLOOR(%[3]s / %[1].8f)", cell, approxLatSQL, approxLngSQL)).
+		Limit(500).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Single posts carry their title so the map can link to them
+	var singleIDs []int64
+	for _, row := range rows {
+		if row.Count == 1 {
+			singleIDs = append(singleIDs, row.ItemID)
+		}
+	}
+	singles := make(map[int64]models.LostItem)
+	if len(singleIDs) > 0 {
+		var items []models.LostItem
+		if err := db.Select("id", "title", "status").Where("id IN ?", singleIDs).Find(&items).Error; err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			singles[item.ID] = item
+		}
+	}
+
+	pins := make([]MapPin, 0, len(rows))
+	for _, row := range rows {
+		pin := MapPin{Lat: ApproximateCoordinate(row.Lat), Lng: ApproximateCoordinate(row.Lng), Count: row.Count}
+		if item, ok := singles[row.ItemID]; ok && row.Count == 1 {
+			pin.ItemID = item.ID
+			pin.Title = item.Title
+			pin.Status = item.Status
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import "sync"
+
+// Hub is an in-process pub/sub that wakes every open stream of a user.
+// Events carry no payload: subscribers re-read the database, so a wake-up that
+// arrives before the publishing transaction commits is picked up on the next one.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+}
+
+// NotificationHub delivers new notifications and unread-count changes to SSE streams
+var NotificationHub = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[int64]map[chan struct{}]struct{})}
+}
+
+// Subscribe registers a stream (one per browser tab) for the user. The returned
+// function must be called when the stream closes.
+func (h *Hub) Subscribe(userID int64) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan struct{}]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Publish wakes all streams of the given users. It never blocks: a stream that
+// already has a pending wake-up will read everything new in one pass.
+func (h *Hub) Publish(userIDs ...int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, userID := range userIDs {
+		for ch := range h.subscribers[userID] {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"temuin/config"
+	"unicode"
+)
+
+// IdentifierKinds lists the identifiers owners may register
+var IdentifierKinds = []string{"imei", "serial", "document"}
+
+// IdentifierKindLabels are the Indonesian names of the identifier kinds
+var IdentifierKindLabels = map[string]string{
+	"imei":     "IMEI",
+	"serial":   "Nomor seri",
+	"document": "Nomor dokumen",
+}
+
+const (
+	minIdentifierLength = 4
+	maxIdentifierLength = 40
+	identifierHintChars = 4
+)
+
+// NormalizeIdentifier reduces an identifier to its uppercase letters and
+// digits, so "35-209900-176148-1" and "352099001761481" are the same IMEI.
+// IMEIs must be 15 digits with a valid check digit. Returns a user-facing
+// message when invalid.
+func NormalizeIdentifier(kind, raw string) (string, string) {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(raw) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	value := b.String()
+
+	if _, ok := IdentifierKindLabels[kind]; !ok {
+		return "", "Jenis nomor tidak dikenal"
+	}
+	if kind == "imei" {
+		if len(value) != 15 || strings.Trim(value, "0123456789") != "" {
+			return "", "IMEI harus 15 digit (cek dengan *#06#)"
+		}
+		if !luhnValid(value) {
+			return "", "IMEI tidak valid, periksa kembali digitnya"
+		}
+		return value, ""
+	}
+	if len(value) < minIdentifierLength || len(value) > maxIdentifierLength {
+		return "", "Nomor harus 4-40 huruf atau angka"
+	}
+	return value, ""
+}
+
+// luhnValid checks the Luhn check digit used by IMEIs
+func luhnValid(digits string) bool {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// HashIdentifier keys a normalized identifier with IDENTIFIER_PEPPER. The kind
+// is left out so a serial typed as a document number still matches, and the
+// key keeps a leaked table from being reversed by hashing every IMEI.
+func HashIdentifier(normalized string) string {
+	mac := hmac.New(sha256.New, []byte(config.IdentifierPepper))
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IdentifierHint masks a normalized identifier down to its last characters
+func IdentifierHint(normalized string) string {
+	if len(normalized) <= identifierHintChars {
+		return "••"
+	}
+	return "••" + normalized[len(normalized)-identifierHintChars:]
+}
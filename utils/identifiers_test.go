@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"imei with separators", "imei", "35-209900-176148-1", "352099001761481", false},
+		{"imei with spaces", "imei", " 4901 5420 3237 518 ", "490154203237518", false},
+		{"imei bad check digit", "imei", "352099001761482", "", true},
+		{"imei too short", "imei", "35209900176148", "", true},
+		{"imei with letters", "imei", "35209900176148A", "", true},
+		{"serial uppercased", "serial", "c02-xk1 9jhd", "C02XK19JHD", false},
+		{"document keeps letters and digits", "document", "B 1234 XYZ", "B1234XYZ", false},
+		{"serial too short", "serial", "a-1", "", true},
+		{"serial too long", "serial", "0123456789012345678901234567890123456789X", "", true},
+		{"unknown kind", "passport", "A1234567", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, msg := NormalizeIdentifier(tt.kind, tt.raw)
+			if (msg != "") != tt.wantErr {
+				t.Fatalf("NormalizeIdentifier(%q, %q) message = %q, want error %v", tt.kind, tt.raw, msg, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeIdentifier(%q, %q) = %q, want %q", tt.kind, tt.raw, got, tt.want)
+			}
+		})
+	}
+}
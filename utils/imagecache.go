@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// CachedImage is an image response ready to send
+type CachedImage struct {
+	Data        []byte
+	ContentType string
+	ETag        string // quoted, as sent in the header
+}
+
+// ImageCacheLRU keeps recently served images in memory up to a byte budget,
+// evicting the least recently used first. Keys must name immutable content
+// (a storage key, or a URL carrying the content hash).
+type ImageCacheLRU struct {
+	mu     sync.Mutex
+	budget int64
+	used   int64
+	order  *list.List // front is most recent
+	items  map[string]*list.Element
+}
+
+type imageCacheEntry struct {
+	key   string
+	image *CachedImage
+}
+
+// ImageCache is the shared cache for served photos and avatars, sized by
+// IMAGE_CACHE_MB. A nil cache stores nothing.
+var ImageCache *ImageCacheLRU
+
+// InitImageCache sizes the shared image cache
+func InitImageCache(budgetMB int) {
+	if budgetMB > 0 {
+		ImageCache = NewImageCache(int64(budgetMB) << 20)
+	}
+}
+
+func NewImageCache(budget int64) *ImageCacheLRU {
+	return &ImageCacheLRU{budget: budget, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns a cached image and marks it recently used
+func (c *ImageCacheLRU) Get(key string) (*CachedImage, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*imageCacheEntry).image, true
+}
+
+// Add caches an image. Images larger than an eighth of the budget are not
+// cached, so one upload cannot flush everything else.
+func (c *ImageCacheLRU) Add(key string, image *CachedImage) {
+	if c == nil {
+		return
+	}
+	size := int64(len(image.Data))
+	if size > c.budget/8 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.used -= int64(len(el.Value.(*imageCacheEntry).image.Data))
+		el.Value.(*imageCacheEntry).image = image
+		c.used += size
+		c.order.MoveToFront(el)
+	} else {
+		c.items[key] = c.order.PushFront(&imageCacheEntry{key: key, image: image})
+		c.used += size
+	}
+	for c.used > c.budget {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*imageCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.items, entry.key)
+		c.used -= int64(len(entry.image.Data))
+	}
+}
+
+// ContentHash is a short hex digest of data, used for ETags and to version
+// image URLs
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,260 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	// Photos are stored at three sizes: the full photo (long edge capped by
+	// config), the detail view and a fixed-size card thumbnail
+	DetailMaxDimension = 800
+	ThumbWidth         = 400
+	ThumbHeight        = 300
+
+	// maxImagePixels rejects decompression bombs before decoding
+	maxImagePixels = 40_000_000
+
+	jpegQuality = 85
+)
+
+var (
+	ErrImageFormat   = errors.New("unsupported image format")
+	ErrImageTooLarge = errors.New("image dimensions too large")
+)
+
+// imageDecoders are the formats accepted, keyed by sniffed content type
+var imageDecoders = map[string]func([]byte) (image.Image, error){
+	"image/jpeg": func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) },
+	"image/png":  func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) },
+	"image/gif":  func(data []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(data)) },
+	"image/webp": func(data []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(data)) },
+}
+
+// imageConfigDecoders read only the header, to check dimensions first
+var imageConfigDecoders = map[string]func([]byte) (image.Config, error){
+	"image/jpeg": func(data []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(data)) },
+	"image/png":  func(data []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(data)) },
+	"image/gif":  func(data []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(data)) },
+	"image/webp": func(data []byte) (image.Config, error) { return webp.DecodeConfig(bytes.NewReader(data)) },
+}
+
+// ProcessedImage is an upload re-encoded as JPEG at every stored size. Only
+// pixels survive re-encoding, so EXIF (GPS, camera serials) and other
+// metadata are dropped.
+type ProcessedImage struct {
+	Data        []byte
+	Detail      []byte
+	Thumb       []byte
+	ContentType string
+	Hash        string // ContentHash of Data, versions the photo's URLs
+	PHash       uint64 // PerceptualHash, finds the same picture across posts
+	Width       int
+	Height      int
+}
+
+// ProcessImage validates an upload by its magic bytes, decodes it, applies
+// the EXIF orientation and re-encodes it with the long edge capped at
+// maxDimension. Animated GIFs keep their first frame.
+func ProcessImage(data []byte, maxDimension int) (*ProcessedImage, error) {
+	contentType := http.DetectContentType(data)
+	decode, ok := imageDecoders[contentType]
+	if !ok {
+		return nil, ErrImageFormat
+	}
+	cfg, err := imageConfigDecoders[contentType](data)
+	if err != nil {
+		return nil, ErrImageFormat
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	src, err := decode(data)
+	if err != nil {
+		return nil, ErrImageFormat
+	}
+
+	// Scale first so turning the photo upright touches fewer pixels
+	full := fitWithin(src, maxDimension)
+	if contentType == "image/jpeg" {
+		full = applyOrientation(full, exifOrientation(data))
+	}
+	processed := &ProcessedImage{
+		ContentType: "image/jpeg",
+		Width:       full.Bounds().Dx(),
+		Height:      full.Bounds().Dy(),
+	}
+	if processed.Data, err = encodeJPEG(full); err != nil {
+		return nil, err
+	}
+	processed.Hash = ContentHash(processed.Data)
+	processed.PHash = PerceptualHash(full)
+	if processed.Detail, err = encodeJPEG(fitWithin(full, DetailMaxDimension)); err != nil {
+		return nil, err
+	}
+	if processed.Thumb, err = encodeJPEG(cover(full, ThumbWidth, ThumbHeight)); err != nil {
+		return nil, err
+	}
+	return processed, nil
+}
+
+// encodeJPEG flattens transparency onto white and encodes the image
+func encodeJPEG(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fitWithin scales the image down so its long edge is at most maxDimension
+func fitWithin(img image.Image, maxDimension int) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxDimension && h <= maxDimension {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*maxDimension/w)
+		w = maxDimension
+	} else {
+		w = max(1, w*maxDimension/h)
+		h = maxDimension
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// cover scales and center-crops the image to exactly width x height
+func cover(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// The largest centered region with the target aspect ratio
+	crop := bounds
+	if w*height > h*width {
+		cw := h * width / height
+		crop.Min.X += (w - cw) / 2
+		crop.Max.X = crop.Min.X + cw
+	} else {
+		ch := w * height / width
+		crop.Min.Y += (h - ch) / 2
+		crop.Max.Y = crop.Min.Y + ch
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Over, nil)
+	return dst
+}
+
+// exifOrientation reads the orientation tag (1-8) from a JPEG's EXIF block.
+// Returns 1 (upright) when there is none.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || size < 2 || i+2+size > len(data) {
+			// Start of scan: no more metadata
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation turns the pixels upright, since the tag itself is
+// stripped on re-encode
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	// Orientations 5-8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Lifecycle states of a post
+const (
+	ItemOpen             = "open"
+	ItemFinderSelected   = "finder_selected"
+	ItemHandoverPending  = "handover_pending"
+	ItemReturned         = "returned"
+	ItemClosedUnresolved = "closed_unresolved"
+	ItemExpired          = "expired"
+	ItemRemoved          = "removed"
+)
+
+// ItemStateLabels names each state on the item timeline
+var ItemStateLabels = map[string]string{
+	ItemOpen:             "Dibuka",
+	ItemFinderSelected:   "Penemu dipilih",
+	ItemHandoverPending:  "Jadwal serah terima disepakati",
+	ItemReturned:         "Dikembalikan ke pemilik",
+	ItemClosedUnresolved: "Ditutup tanpa ditemukan",
+	ItemExpired:          "Kedaluwarsa",
+	ItemRemoved:          "Dihapus",
+}
+
+// itemTransitions lists the states each state may move to. Choosing another
+// finder is a transition to finder_selected from either handover state.
+var itemTransitions = map[string][]string{
+	ItemOpen:             {ItemFinderSelected, ItemClosedUnresolved, ItemExpired, ItemRemoved},
+	ItemFinderSelected:   {ItemFinderSelected, ItemHandoverPending, ItemReturned, ItemClosedUnresolved, ItemRemoved},
+	ItemHandoverPending:  {ItemFinderSelected, ItemReturned, ItemClosedUnresolved, ItemRemoved},
+	ItemReturned:         {ItemRemoved},
+	ItemClosedUnresolved: {ItemOpen, ItemRemoved},
+	ItemExpired:          {ItemOpen, ItemRemoved},
+}
+
+// ErrInvalidTransition is returned for a move the lifecycle does not allow
+var ErrInvalidTransition = errors.New("invalid item state transition")
+
+// CanTransition reports whether a post in state from may move to state to
+func CanTransition(from, to string) bool {
+	for _, next := range itemTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveItemStates are the states in which a post is still looking for its
+// item and holds its bounty
+var ActiveItemStates = []string{ItemOpen, ItemFinderSelected, ItemHandoverPending}
+
+// ItemActive reports whether the post is still looking for its item, from
+// being posted until it is returned, closed or expired
+func ItemActive(state string) bool {
+	return state == ItemOpen || state == ItemFinderSelected || state == ItemHandoverPending
+}
+
+// ItemStatus is the public Status shown and filtered on for a state
+func ItemStatus(state string) string {
+	switch state {
+	case ItemReturned:
+		return "RETURNED"
+	case ItemClosedUnresolved, ItemExpired:
+		return "CLOSED"
+	}
+	return "LOST"
+}
+
+// TransitionItem moves a post to another state, keeping Status in step, and
+// records the change in the item history. Must run inside the transaction of
+// the action causing it.
+func TransitionItem(tx *gorm.DB, item *models.LostItem, to string, actorID *int64, note string) error {
+	from := item.State
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+
+	status := item.Status
+	if to != ItemRemoved {
+		status = ItemStatus(to)
+	}
+	// Guarded on the old state so two concurrent actions cannot both move it
+	result := tx.Model(&models.LostItem{}).Where("id = ? AND state = ?", item.ID, from).
+		Updates(map[string]interface{}{"state": to, "status": status})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("%w: item %d is no longer %s", ErrInvalidTransition, item.ID, from)
+	}
+	item.State = to
+	item.Status = status
+
+	if runes := []rune(note); len(runes) > 255 {
+		note = string(runes[:255])
+	}
+	return tx.Create(&models.ItemHistory{
+		ItemID:    item.ID,
+		FromState: from,
+		ToState:   to,
+		ActorID:   actorID,
+		Note:      note,
+	}).Error
+}
+
+// RefundBounty returns a post's bounty to its owner when the post ends
+// without a return. Must run inside a transaction.
+func RefundBounty(tx *gorm.DB, item *models.LostItem, transactionType string) error {
+	if item.BountyCoins <= 0 {
+		return nil
+	}
+	var owner models.User
+	if err := tx.First(&owner, item.UserID).Error; err != nil {
+		return err
+	}
+	owner.CoinBalance += item.BountyCoins
+	if err := tx.Save(&owner).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&models.CoinTransaction{
+		UserID:          owner.ID,
+		Amount:          item.BountyCoins,
+		TransactionType: transactionType,
+	}).Error; err != nil {
+		return err
+	}
+	item.BountyCoins = 0
+	return tx.Model(item).Update("bounty_coins", 0).Error
+}
+
+// StartItemExpiry expires open posts config.ItemExpiryDays after they were
+// created or last reopened, refunding their bounty. Posts with a selected
+// finder are left to finish their handover.
+func StartItemExpiry(db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			expireItems(db, time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func expireItems(db *gorm.DB, now time.Time) {
+	cutoff := now.Add(-config.ItemExpiry())
+	reopened := db.Model(&models.ItemHistory{}).Select("item_id").Where("to_state = ? AND created_at >= ?", ItemOpen, cutoff)
+
+	// Status and finder are checked too so rows not yet backfilled by
+	// cmd/migrate/item_lifecycle (state defaults to open) are never expired
+	var items []models.LostItem
+	db.Where("state = ? AND status = ? AND finder_id IS NULL AND created_at < ? AND id NOT IN (?)", ItemOpen, ItemStatus(ItemOpen), cutoff, reopened).
+		Limit(500).Find(&items)
+
+	for _, item := range items {
+		message := fmt.Sprintf("Postingan '%s' ditutup otomatis setelah %d hari. Buka kembali postingan jika barang masih dicari.", item.Title, config.ItemExpiryDays)
+		if item.BountyCoins > 0 {
+			message = fmt.Sprintf("Postingan '%s' ditutup otomatis setelah %d hari dan bounty %d coins dikembalikan ke saldo Anda. Buka kembali postingan jika barang masih dicari.", item.Title, config.ItemExpiryDays, item.BountyCoins)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := TransitionItem(tx, &item, ItemExpired, nil, fmt.Sprintf("Tidak ada penemu dalam %d hari", config.ItemExpiryDays)); err != nil {
+				return err
+			}
+			if err := RefundBounty(tx, &item, "expired_refund"); err != nil {
+				return err
+			}
+			return Notify(tx, &models.Notification{
+				UserID:        item.UserID,
+				Type:          "system_update",
+				Title:         "Postingan kedaluwarsa",
+				Message:       message,
+				ReferenceURL:  fmt.Sprintf("/item/%d", item.ID),
+				RelatedItemID: &item.ID,
+			})
+		})
+		if err != nil {
+			if !errors.Is(err, ErrInvalidTransition) {
+				log.Printf("[lifecycle] expire item %d: %v", item.ID, err)
+			}
+			continue
+		}
+		NotificationHub.Publish(item.UserID)
+	}
+}
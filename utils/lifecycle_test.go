@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ItemOpen, ItemFinderSelected, true},
+		{ItemOpen, ItemExpired, true},
+		{ItemOpen, ItemReturned, false},
+		{ItemOpen, ItemHandoverPending, false},
+		{ItemFinderSelected, ItemFinderSelected, true},
+		{ItemFinderSelected, ItemReturned, true},
+		{ItemHandoverPending, ItemFinderSelected, true},
+		{ItemHandoverPending, ItemExpired, false},
+		{ItemReturned, ItemOpen, false},
+		{ItemReturned, ItemRemoved, true},
+		{ItemClosedUnresolved, ItemOpen, true},
+		{ItemExpired, ItemOpen, true},
+		{ItemExpired, ItemReturned, false},
+		{ItemRemoved, ItemOpen, false},
+		{"unknown", ItemOpen, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
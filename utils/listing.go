@@ -0,0 +1,441 @@
+package utils
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	listingDateLayout   = "2006-01-02"
+	listingLocationTops = 8 // most common locations offered as facets
+)
+
+// listingSorts maps the sort parameter to its ORDER BY clause. Posts expire
+// config.ItemExpiryDays after creation, so the oldest open post expires first;
+// returned and closed posts no longer expire and go last.
+var listingSorts = map[string]string{
+	"relevance": "", // ranked by SearchItems, only with a keyword search
+	"newest":    "created_at DESC, id DESC",
+	"bounty":    "bounty_coins DESC, created_at DESC, id DESC",
+	"expiry":    "status <> 'LOST', created_at ASC, id ASC",
+	"nearest":   "", // ordered by distance, only with a radius search
+}
+
+// ListingFilter holds the browse filters shared by Home, LandingPage and the
+// category pages
+type ListingFilter struct {
+	Query         string // matched on its stems through the search index
+	Status        string
+	Location      string
+	CategoryID    *int64
+	SubCategoryID *int64
+	HasBounty     bool
+	From          *time.Time // inclusive, by creation day
+	To            *time.Time // inclusive, by creation day
+	Near          *GeoPoint  // radius search center
+	PlaceID       *int64     // also matches places under it
+	RadiusKm      float64
+	Sort          string
+	Page          int
+	PerPage       int
+
+	Attributes map[int64]string // public choice field values, see PinAttributeFields
+
+	ExcludeHighlighted bool // category pages show highlighted posts separately
+	CountFacets        bool
+
+	path            string
+	params          url.Values
+	placeSubtree    []int64 // resolved once per listing, see resolvePlace
+	attributeFields []models.AttributeField
+}
+
+// ParseListingFilter reads the filters from the query string. Callers pin
+// the category or subcategory of the page afterwards.
+func ParseListingFilter(c *gin.Context) ListingFilter {
+	params := c.Request.URL.Query()
+	filter := ListingFilter{
+		Query:    strings.TrimSpace(params.Get("q")),
+		Status:   params.Get("status"),
+		Location: strings.TrimSpace(params.Get("location")),
+		Sort:     params.Get("sort"),
+		PerPage:  config.ListingPerPage,
+		path:     c.Request.URL.Path,
+		params:   params,
+	}
+	if _, ok := listingSorts[filter.Sort]; !ok {
+		filter.Sort = "relevance"
+	}
+	if filter.Sort == "relevance" && len(SearchTerms(filter.Query)) == 0 {
+		filter.Sort = "newest"
+	}
+	filter.HasBounty = params.Get("bounty") == "1"
+	if id, err := strconv.ParseInt(params.Get("subcategory"), 10, 64); err == nil && id > 0 {
+		filter.SubCategoryID = &id
+	}
+	if from, err := time.ParseInLocation(listingDateLayout, params.Get("from"), time.Local); err == nil {
+		filter.From = &from
+	}
+	if to, err := time.ParseInLocation(listingDateLayout, params.Get("to"), time.Local); err == nil {
+		filter.To = &to
+	}
+	if id, err := strconv.ParseInt(params.Get("place"), 10, 64); err == nil && id > 0 {
+		filter.PlaceID = &id
+	}
+	if lat, lng, err := ParseCoordinates(params.Get("lat"), params.Get("lng")); err == nil && lat != nil {
+		filter.Near = &GeoPoint{Lat: *lat, Lng: *lng}
+		filter.RadiusKm, _ = strconv.ParseFloat(params.Get("radius"), 64)
+		if filter.RadiusKm == 0 {
+			filter.RadiusKm = DefaultRadiusKm
+		}
+		filter.RadiusKm = math.Max(MinRadiusKm, math.Min(MaxRadiusKm, filter.RadiusKm))
+	}
+	if filter.Sort == "nearest" && filter.Near == nil {
+		filter.Sort = "newest"
+	}
+	filter.Page, _ = strconv.Atoi(params.Get("page"))
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	return filter
+}
+
+// PinAttributeFields turns on the attribute facets of a subcategory page. Only
+// public choice fields are offered: filtering on a private value would let
+// anyone probe it.
+func (f *ListingFilter) PinAttributeFields(fields []models.AttributeField) {
+	f.Attributes = make(map[int64]string)
+	for _, field := range fields {
+		if field.IsPrivate || field.Kind != "enum" {
+			continue
+		}
+		f.attributeFields = append(f.attributeFields, field)
+		if value := strings.TrimSpace(f.params.Get(attributeFacetKey(field.ID))); value != "" {
+			f.Attributes[field.ID] = value
+		}
+	}
+}
+
+// attributeFacetKey is the query parameter of an attribute facet
+func attributeFacetKey(fieldID int64) string {
+	return "attr_" + strconv.FormatInt(fieldID, 10)
+}
+
+// URLWith returns the current page URL with key set to value (or removed
+// when value is empty). The page number is reset.
+func (f ListingFilter) URLWith(key, value string) string {
+	params := url.Values{}
+	for k, v := range f.params {
+		params[k] = v
+	}
+	params.Del("page")
+	params.Del("cursor")
+	if value == "" {
+		params.Del(key)
+	} else {
+		params.Set(key, value)
+	}
+	return f.pageURL(params)
+}
+
+// NearClearURL returns the current page URL without the radius search
+func (f ListingFilter) NearClearURL() string {
+	params := url.Values{}
+	for k, v := range f.params {
+		params[k] = v
+	}
+	for _, key := range []string{"page", "cursor", "lat", "lng", "radius"} {
+		params.Del(key)
+	}
+	if params.Get("sort") == "nearest" {
+		params.Del("sort")
+	}
+	return f.pageURL(params)
+}
+
+func (f ListingFilter) pageURL(params url.Values) string {
+	if len(params) == 0 {
+		return f.path
+	}
+	return f.path + "?" + params.Encode()
+}
+
+// apply adds every filter except the named facet, so each facet counts what
+// selecting one of its values would return
+func (f ListingFilter) apply(db *gorm.DB, except string) *gorm.DB {
+	db = db.Model(&models.LostItem{}).Scopes(VisibleContent)
+	if f.ExcludeHighlighted {
+		db = db.Where("core_lostitem.is_highlighted = ?", false)
+	}
+	if terms := SearchTerms(f.Query); len(terms) > 0 {
+		db = db.Where("core_lostitem.id IN (SELECT item_id FROM core_searchterm WHERE term IN ?)", terms)
+	}
+	if f.CategoryID != nil {
+		db = db.Where("core_lostitem.category_id = ?", *f.CategoryID)
+	}
+	if f.SubCategoryID != nil && except != "subcategory" {
+		db = db.Where("core_lostitem.subcategory_id = ?", *f.SubCategoryID)
+	}
+	if f.Status != "" && except != "status" {
+		db = db.Where("core_lostitem.status = ?", f.Status)
+	}
+	if f.Location != "" && except != "location" {
+		db = db.Where("core_lostitem.location LIKE ?", "%"+f.Location+"%")
+	}
+	if f.HasBounty && except != "bounty" {
+		db = db.Where("core_lostitem.bounty_coins > 0")
+	}
+	if f.From != nil {
+		db = db.Where("core_lostitem.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("core_lostitem.created_at < ?", f.To.AddDate(0, 0, 1))
+	}
+	if f.PlaceID != nil {
+		ids := f.placeSubtree
+		if ids == nil {
+			ids = PlaceSubtreeIDs(db, *f.PlaceID)
+		}
+		db = db.Where("core_lostitem.place_id IN ?", ids)
+	}
+	if f.Near != nil {
+		db = withinRadius(db, *f.Near, f.RadiusKm)
+	}
+	for fieldID, value := range f.Attributes {
+		if except == attributeFacetKey(fieldID) {
+			continue
+		}
+		db = db.Where("EXISTS (SELECT 1 FROM core_itemattribute WHERE core_itemattribute.item_id = core_lostitem.id AND core_itemattribute.field_id = ? AND core_itemattribute.value = ?)", fieldID, value)
+	}
+	return db
+}
+
+// resolvePlace looks up the places under PlaceID once, before the count,
+// page and facet queries
+func (f *ListingFilter) resolvePlace(db *gorm.DB) {
+	if f.PlaceID != nil && f.placeSubtree == nil {
+		f.placeSubtree = PlaceSubtreeIDs(db, *f.PlaceID)
+	}
+}
+
+// FacetOption is one selectable facet value with the number of matching posts
+type FacetOption struct {
+	Value  string
+	Label  string
+	Count  int64
+	Active bool
+	URL    string // toggles this value
+}
+
+// AttributeFacet is the values of one attribute field with their counts
+type AttributeFacet struct {
+	Key     string // query parameter
+	Name    string
+	Options []FacetOption
+}
+
+// ListingFacets are the per-facet counts shown above the results
+type ListingFacets struct {
+	Status        []FacetOption
+	SubCategories []FacetOption
+	Locations     []FacetOption
+	Attributes    []AttributeFacet
+	Bounty        FacetOption
+}
+
+// ListingPage is one page of browse results
+type ListingPage struct {
+	Items      []models.LostItem
+	Total      int64
+	Page       int
+	TotalPages int
+	PrevURL    string
+	NextURL    string
+	Sort       string
+	Facets     ListingFacets
+	Filter     ListingFilter
+}
+
+// ListItems returns one page of visible posts matching the filter, plus the
+// facet counts when filter.CountFacets is set
+func ListItems(db *gorm.DB, filter ListingFilter) (ListingPage, error) {
+	if filter.PerPage <= 0 {
+		filter.PerPage = config.ListingPerPage
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if _, ok := listingSorts[filter.Sort]; !ok || (filter.Sort == "nearest" && filter.Near == nil) || filter.Sort == "relevance" {
+		filter.Sort = "newest"
+	}
+	var order interface{} = listingSorts[filter.Sort]
+	if filter.Sort == "nearest" {
+		order = clause.OrderBy{Expression: clause.Expr{
+			SQL:                haversineSQL + " ASC, core_lostitem.id DESC",
+			Vars:               []interface{}{filter.Near.Lat, filter.Near.Lat, filter.Near.Lng},
+			WithoutParentheses: true,
+		}}
+	}
+
+	filter.resolvePlace(db)
+	page := ListingPage{Page: filter.Page, Sort: filter.Sort, Filter: filter}
+	if err := filter.apply(db, "").Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+	page.TotalPages = int(math.Ceil(float64(page.Total) / float64(filter.PerPage)))
+
+	if err := filter.apply(db, "").Preload("User").
+		Order(order).
+		Offset((filter.Page - 1) * filter.PerPage).
+		Limit(filter.PerPage).
+		Find(&page.Items).Error; err != nil {
+		return page, err
+	}
+
+	if filter.Page > 1 {
+		page.PrevURL = filter.URLWith("page", strconv.Itoa(filter.Page-1))
+		if filter.Page == 2 {
+			page.PrevURL = filter.URLWith("page", "")
+		}
+	}
+	if filter.Page < page.TotalPages {
+		page.NextURL = filter.URLWith("page", strconv.Itoa(filter.Page+1))
+	}
+
+	if filter.CountFacets {
+		facets, err := countFacets(db, filter)
+		if err != nil {
+			return page, err
+		}
+		page.Facets = facets
+	}
+	return page, nil
+}
+
+// facetRow is a GROUP BY result
+type facetRow struct {
+	Value string
+	Count int64
+}
+
+func countFacets(db *gorm.DB, filter ListingFilter) (ListingFacets, error) {
+	var facets ListingFacets
+
+	// Status
+	var statuses []facetRow
+	if err := filter.apply(db, "status").
+		Select("status AS value, COUNT(*) AS count").
+		Group("status").
+		Scan(&statuses).Error; err != nil {
+		return facets, err
+	}
+	statusLabels := map[string]string{"LOST": "Hilang", "RETURNED": "Dikembalikan", "CLOSED": "Ditutup"}
+	for _, row := range statuses {
+		label := statusLabels[row.Value]
+		if label == "" {
+			label = row.Value
+		}
+		facets.Status = append(facets.Status, filter.option("status", row.Value, label, row.Count, filter.Status == row.Value))
+	}
+
+	// Subcategories, only inside a category page
+	if filter.CategoryID != nil {
+		var subs []struct {
+			ID    int64
+			Name  string
+			Count int64
+		}
+		if err := filter.apply(db, "subcategory").
+			Select("core_subcategory.id AS id, core_subcategory.name AS name, COUNT(*) AS count").
+			Joins("JOIN core_subcategory ON core_subcategory.id = core_lostitem.subcategory_id").
+			Group("core_subcategory.id, core_subcategory.name").
+			Order("core_subcategory.name").
+			Scan(&subs).Error; err != nil {
+			return facets, err
+		}
+		for _, sub := range subs {
+			active := filter.SubCategoryID != nil && *filter.SubCategoryID == sub.ID
+			facets.SubCategories = append(facets.SubCategories,
+				filter.option("subcategory", strconv.FormatInt(sub.ID, 10), sub.Name, sub.Count, active))
+		}
+	}
+
+	// Public choice attributes, only on a subcategory page
+	for _, field := range filter.attributeFields {
+		key := attributeFacetKey(field.ID)
+		var values []facetRow
+		if err := filter.apply(db, key).
+			Select("core_itemattribute.value AS value, COUNT(*) AS count").
+			Joins("JOIN core_itemattribute ON core_itemattribute.item_id = core_lostitem.id AND core_itemattribute.field_id = ?", field.ID).
+			Group("core_itemattribute.value").
+			Order("count DESC, value").
+			Scan(&values).Error; err != nil {
+			return facets, err
+		}
+		facet := AttributeFacet{Key: key, Name: field.Name}
+		for _, row := range values {
+			facet.Options = append(facet.Options, filter.option(key, row.Value, row.Value, row.Count, filter.Attributes[field.ID] == row.Value))
+		}
+		if len(facet.Options) > 0 {
+			facets.Attributes = append(facets.Attributes, facet)
+		}
+	}
+
+	// Most common locations
+	var locations []facetRow
+	if err := filter.apply(db, "location").
+		Select("location AS value, COUNT(*) AS count").
+		Where("location IS NOT NULL AND location <> ''").
+		Group("location").
+		Order("count DESC, location").
+		Limit(listingLocationTops).
+		Scan(&locations).Error; err != nil {
+		return facets, err
+	}
+	for _, row := range locations {
+		active := strings.EqualFold(filter.Location, row.Value)
+		facets.Locations = append(facets.Locations, filter.option("location", row.Value, row.Value, row.Count, active))
+	}
+
+	// Has bounty
+	var withBounty int64
+	if err := filter.apply(db, "bounty").Where("bounty_coins > 0").Count(&withBounty).Error; err != nil {
+		return facets, err
+	}
+	facets.Bounty = filter.option("bounty", "1", "Ada bounty", withBounty, filter.HasBounty)
+	return facets, nil
+}
+
+// option builds a facet value whose URL selects it, or clears it when active
+func (f ListingFilter) option(key, value, label string, count int64, active bool) FacetOption {
+	target := value
+	if active {
+		target = ""
+	}
+	return FacetOption{Value: value, Label: label, Count: count, Active: active, URL: f.URLWith(key, target)}
+}
+
+// FromValue formats the From date for <input type="date">
+func (f ListingFilter) FromValue() string {
+	if f.From == nil {
+		return ""
+	}
+	return f.From.Format(listingDateLayout)
+}
+
+// ToValue formats the To date for <input type="date">
+func (f ListingFilter) ToValue() string {
+	if f.To == nil {
+		return ""
+	}
+	return f.To.Format(listingDateLayout)
+}
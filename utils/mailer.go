@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"temuin/config"
+	"time"
+)
+
+// EmailMessage is a rendered email ready to send
+type EmailMessage struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Mailer sends email. Implementations are picked by config.MailDriver.
+type Mailer interface {
+	Send(msg EmailMessage) error
+}
+
+// SMTPMailer sends through an SMTP relay with PLAIN auth
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTPMailer) Send(msg EmailMessage) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	data, err := buildMIME(m.From, msg)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{msg.To}, data)
+}
+
+// LogMailer is the development sink: each email is written as an .eml file
+// into Dir and summarised in the server log instead of being sent
+type LogMailer struct {
+	Dir  string
+	From string
+}
+
+func (m LogMailer) Send(msg EmailMessage) error {
+	data, err := buildMIME(m.From, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), sanitizeFilename(msg.To))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	log.Printf("[mail] to=%s subject=%q saved=%s", msg.To, msg.Subject, path)
+	return nil
+}
+
+// NewMailer builds the mailer selected in config
+func NewMailer() Mailer {
+	if config.MailDriver == "smtp" {
+		return SMTPMailer{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.MailFrom,
+		}
+	}
+	return LogMailer{Dir: config.MailSinkDir, From: config.MailFrom}
+}
+
+// buildMIME renders a multipart/alternative message with text and HTML parts
+func buildMIME(from string, msg EmailMessage) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", from)
+	fmt.Fprintf(&out, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&out, "Subject: %s\r\n", encodeHeader(msg.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// encodeHeader B-encodes header values containing non-ASCII characters
+func encodeHeader(s string) string {
+	return mime.BEncoding.Encode("UTF-8", s)
+}
+
+func sanitizeFilename(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
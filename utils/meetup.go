@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"temuin/config"
+	"temuin/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartMeetupReminders reminds both sides of an agreed meetup
+// config.MeetupReminderMinutes before it starts
+func StartMeetupReminders(db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(config.MeetupPollSeconds) * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sendMeetupReminders(db, time.Now())
+		}
+	}()
+}
+
+func sendMeetupReminders(db *gorm.DB, now time.Time) {
+	var meetups []models.Meetup
+	db.Preload("Slot").Preload("Item").
+		Joins("JOIN core_meetupslot ON core_meetupslot.id = core_meetup.slot_id").
+		Where("core_meetup.status = ? AND core_meetup.reminded_at IS NULL", "accepted").
+		Where("core_meetupslot.starts_at > ? AND core_meetupslot.starts_at <= ?",
+			now, now.Add(time.Duration(config.MeetupReminderMinutes)*time.Minute)).
+		Find(&meetups)
+
+	for _, meetup := range meetups {
+		if meetup.Slot == nil {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Claim the reminder so a slow tick cannot send it twice
+			result := tx.Model(&models.Meetup{}).Where("id = ? AND reminded_at IS NULL", meetup.ID).Update("reminded_at", now)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			message := fmt.Sprintf("Serah terima '%s' pukul %s di %s.",
+				meetup.Item.Title, meetup.Slot.StartsAt.Format("15:04"), meetup.Slot.Place)
+			for _, userID := range []int64{meetup.ProposerID, meetup.RecipientID} {
+				if err := Notify(tx, &models.Notification{
+					UserID:        userID,
+					Type:          "handover",
+					Title:         "Pengingat jadwal serah terima",
+					Message:       message,
+					ReferenceURL:  fmt.Sprintf("/item/%d#meetup", meetup.ItemID),
+					RelatedItemID: &meetup.ItemID,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("[meetup] reminder for meetup %d: %v", meetup.ID, err)
+			continue
+		}
+		NotificationHub.Publish(meetup.ProposerID)
+		NotificationHub.Publish(meetup.RecipientID)
+	}
+}
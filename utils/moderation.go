@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"temuin/config"
+	"temuin/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IsSuspended reports whether the user is inside an active suspension window
+func IsSuspended(user *models.User) bool {
+	return user.SuspendedUntil != nil && user.SuspendedUntil.After(time.Now())
+}
+
+// IsRestricted reports whether the user is banned or currently suspended
+func IsRestricted(user *models.User) bool {
+	return user.IsBanned || IsSuspended(user)
+}
+
+// RestrictionMessage builds the message shown to a banned or suspended user at login
+func RestrictionMessage(user *models.User) string {
+	reason := user.BanReason
+	if reason == "" {
+		reason = "pelanggaran ketentuan platform (penipuan, jual beli, atau pelanggaran lainnya)"
+	}
+
+	if user.IsBanned {
+		return "Akun Anda telah diblokir secara permanen. Alasan: " + reason + "."
+	}
+	if IsSuspended(user) {
+		return fmt.Sprintf("Akun Anda ditangguhkan hingga %s. Alasan: %s.",
+			user.SuspendedUntil.Format("02 Jan 2006 15:04"), reason)
+	}
+	return ""
+}
+
+// LiftExpiredSuspension clears a suspension whose end time has passed.
+// Returns true if the user row was updated.
+func LiftExpiredSuspension(db *gorm.DB, user *models.User) bool {
+	if user.SuspendedUntil == nil || user.SuspendedUntil.After(time.Now()) {
+		return false
+	}
+
+	user.SuspendedUntil = nil
+	if !user.IsBanned {
+		user.BanReason = ""
+	}
+	if err := db.Model(user).Updates(map[string]interface{}{
+		"suspended_until": nil,
+		"ban_reason":      user.BanReason,
+	}).Error; err != nil {
+		return false
+	}
+
+	db.Create(&models.ModerationAction{
+		UserID: user.ID,
+		Action: "lift",
+		Reason: "Masa penangguhan berakhir",
+	})
+	return true
+}
+
+// SuspendUser suspends the user until the given time and records the action
+func SuspendUser(db *gorm.DB, user *models.User, until time.Time, reason string, adminID, reportID *int64) (*models.ModerationAction, error) {
+	user.SuspendedUntil = &until
+	user.BanReason = reason
+	if err := db.Save(user).Error; err != nil {
+		return nil, err
+	}
+
+	action := models.ModerationAction{
+		UserID:    user.ID,
+		AdminID:   adminID,
+		Action:    "suspend",
+		Reason:    reason,
+		ReportID:  reportID,
+		ExpiresAt: &until,
+	}
+	if err := db.Create(&action).Error; err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// BanUser permanently bans the user and records the action
+func BanUser(db *gorm.DB, user *models.User, reason string, adminID, reportID *int64) (*models.ModerationAction, error) {
+	user.IsBanned = true
+	user.SuspendedUntil = nil
+	user.BanReason = reason
+	if err := db.Save(user).Error; err != nil {
+		return nil, err
+	}
+
+	action := models.ModerationAction{
+		UserID:   user.ID,
+		AdminID:  adminID,
+		Action:   "ban",
+		Reason:   reason,
+		ReportID: reportID,
+	}
+	if err := db.Create(&action).Error; err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// ActiveSanction returns the ban or suspension currently restricting the user.
+// Users restricted before sanctions were recorded have no such action, so one
+// is recorded from their ban reason the first time it is needed.
+func ActiveSanction(db *gorm.DB, user *models.User) (*models.ModerationAction, error) {
+	kind := "suspend"
+	if user.IsBanned {
+		kind = "ban"
+	}
+
+	var action models.ModerationAction
+	err := db.Preload("Report").Preload("Report.Item").
+		Where("user_id = ? AND action = ?", user.ID, kind).
+		Order("created_at DESC, id DESC").
+		First(&action).Error
+	if err == nil {
+		return &action, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) || !IsRestricted(user) {
+		return nil, err
+	}
+
+	action = models.ModerationAction{
+		UserID:    user.ID,
+		Action:    kind,
+		Reason:    user.BanReason,
+		ExpiresAt: user.SuspendedUntil,
+	}
+	if kind == "ban" {
+		action.ExpiresAt = nil
+	}
+	if action.Reason == "" {
+		action.Reason = "Sanksi sebelum riwayat moderasi dicatat"
+	}
+	if err := db.Create(&action).Error; err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// UnbanUser lifts both a ban and any running suspension and records the action
+func UnbanUser(db *gorm.DB, user *models.User, reason string, adminID *int64) (*models.ModerationAction, error) {
+	user.IsBanned = false
+	user.SuspendedUntil = nil
+	user.BanReason = ""
+	if err := db.Save(user).Error; err != nil {
+		return nil, err
+	}
+
+	action := models.ModerationAction{
+		UserID:  user.ID,
+		AdminID: adminID,
+		Action:  "unban",
+		Reason:  reason,
+	}
+	if err := db.Create(&action).Error; err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// LiftSanction lifts one ban or suspension and records the action ("unban"
+// or "lift"). A suspension is only cleared while it is still the user's
+// latest one; other restrictions stay in place.
+func LiftSanction(db *gorm.DB, user *models.User, sanction *models.ModerationAction, reason string, adminID *int64) (*models.ModerationAction, error) {
+	action := models.ModerationAction{
+		UserID:  user.ID,
+		AdminID: adminID,
+		Action:  "lift",
+		Reason:  reason,
+	}
+
+	switch sanction.Action {
+	case "ban":
+		user.IsBanned = false
+		action.Action = "unban"
+	case "suspend":
+		var latest models.ModerationAction
+		if err := db.Where("user_id = ? AND action = ?", user.ID, "suspend").
+			Order("created_at DESC, id DESC").First(&latest).Error; err != nil {
+			return nil, err
+		}
+		if latest.ID == sanction.ID {
+			user.SuspendedUntil = nil
+		}
+	default:
+		return nil, fmt.Errorf("action %q is not a sanction", sanction.Action)
+	}
+	if !IsRestricted(user) {
+		user.BanReason = ""
+	}
+	if err := db.Model(user).Updates(map[string]interface{}{
+		"is_banned":       user.IsBanned,
+		"suspended_until": user.SuspendedUntil,
+		"ban_reason":      user.BanReason,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Create(&action).Error; err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// ApplyStrike adds a strike to the user and escalates to suspension or ban once
+// the configured thresholds are reached. Returns the strongest action taken
+// ("strike", "suspend" or "ban").
+func ApplyStrike(db *gorm.DB, user *models.User, reason string, adminID, reportID *int64) (string, error) {
+	user.StrikeCount++
+	if err := db.Model(user).Update("strike_count", user.StrikeCount).Error; err != nil {
+		return "", err
+	}
+
+	if err := db.Create(&models.ModerationAction{
+		UserID:   user.ID,
+		AdminID:  adminID,
+		Action:   "strike",
+		Reason:   reason,
+		ReportID: reportID,
+	}).Error; err != nil {
+		return "", err
+	}
+
+	// Escalations are automatic, so they are recorded without an admin
+	switch {
+	case user.StrikeCount >= config.StrikeBanThreshold && !user.IsBanned:
+		banReason := fmt.Sprintf("Mencapai %d teguran (terakhir: %s)", user.StrikeCount, reason)
+		if _, err := BanUser(db, user, banReason, nil, reportID); err != nil {
+			return "", err
+		}
+		return "ban", nil
+	case user.StrikeCount >= config.StrikeSuspendThreshold && !user.IsBanned && !IsSuspended(user):
+		until := time.Now().AddDate(0, 0, config.SuspensionDays)
+		suspendReason := fmt.Sprintf("Mencapai %d teguran (terakhir: %s)", user.StrikeCount, reason)
+		if _, err := SuspendUser(db, user, until, suspendReason, nil, reportID); err != nil {
+			return "", err
+		}
+		return "suspend", nil
+	}
+
+	return "strike", nil
+}
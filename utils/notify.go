@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+	"gorm.io/gorm"
+)
+
+// NotificationType describes a notification type users can configure in Profile
+type NotificationType struct {
+	Key   string
+	Label string
+}
+
+var NotificationTypes = []NotificationType{
+	{Key: "report", Label: "Laporan postingan"},
+	{Key: "warning", Label: "Peringatan & tindakan moderasi"},
+	{Key: "appeal", Label: "Banding akun"},
+	{Key: "system_update", Label: "Pembaruan sistem"},
+	{Key: "saved_search", Label: "Postingan baru di pencarian tersimpan"},
+	{Key: "found_item", Label: "Barang terdaftar Anda ditemukan"},
+	{Key: "handover", Label: "Serah terima barang"},
+}
+
+var emailModes = map[string]bool{"off": true, "instant": true, "digest": true}
+
+// ValidEmailMode reports whether mode is a supported email setting
+func ValidEmailMode(mode string) bool {
+	return emailModes[mode]
+}
+
+// DefaultPreference is used when the user has not saved a preference for the type.
+// Account actions and search alerts go out immediately; report traffic
+// (mostly admins) is batched.
+func DefaultPreference(notifType string) models.NotificationPreference {
+	pref := models.NotificationPreference{Type: notifType, InApp: true, Email: "off"}
+	switch notifType {
+	case "warning", "appeal", "saved_search", "found_item", "handover":
+		pref.Email = "instant"
+	case "report":
+		pref.Email = "digest"
+	}
+	return pref
+}
+
+// LoadPreferences returns the effective preference of every configurable type
+func LoadPreferences(db *gorm.DB, userID int64) map[string]models.NotificationPreference {
+	prefs := make(map[string]models.NotificationPreference, len(NotificationTypes))
+	for _, t := range NotificationTypes {
+		prefs[t.Key] = DefaultPreference(t.Key)
+	}
+
+	var saved []models.NotificationPreference
+	db.Where("user_id = ?", userID).Find(&saved)
+	for _, pref := range saved {
+		prefs[pref.Type] = pref
+	}
+	return prefs
+}
+
+func loadPreference(db *gorm.DB, userID int64, notifType string) models.NotificationPreference {
+	var pref models.NotificationPreference
+	if err := db.Where("user_id = ? AND type = ?", userID, notifType).First(&pref).Error; err != nil {
+		return DefaultPreference(notifType)
+	}
+	return pref
+}
+
+// Notify fans a notification out to the channels the recipient enabled for its
+// type: the in-app row and/or a queued email (instant or daily digest). It runs
+// inside the caller's transaction; callers still publish to NotificationHub
+// after commit.
+func Notify(tx *gorm.DB, notification *models.Notification) error {
+	pref := loadPreference(tx, notification.UserID, notification.Type)
+
+	if pref.InApp {
+		if err := tx.Create(notification).Error; err != nil {
+			return err
+		}
+	}
+	if pref.Email == "off" {
+		return nil
+	}
+
+	var user models.User
+	if err := tx.Select("id", "username", "first_name", "email").First(&user, notification.UserID).Error; err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+
+	delivery := models.EmailDelivery{
+		UserID:    user.ID,
+		Type:      notification.Type,
+		ToAddress: user.Email,
+		Subject:   notification.Title,
+		TextBody:  notificationText(notification),
+	}
+	if notification.ID != 0 {
+		delivery.NotificationID = &notification.ID
+	}
+
+	if pref.Email == "digest" {
+		delivery.Status = "digest"
+	} else {
+		html, err := renderEmail("templates/email/notification.html", pongo2.Context{
+			"user":         user,
+			"notification": notification,
+			"link":         absoluteURL(notification.ReferenceURL),
+		})
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		delivery.Subject = "[TemuIN] " + notification.Title
+		delivery.HTMLBody = html
+		delivery.Status = "pending"
+		delivery.NextAttemptAt = &now
+	}
+	return tx.Create(&delivery).Error
+}
+
+// notificationText is the plain-text part of a notification email and the
+// entry stored for the digest
+func notificationText(notification *models.Notification) string {
+	text := notification.Message
+	if notification.ReferenceURL != "" {
+		text += "\n\n" + absoluteURL(notification.ReferenceURL)
+	}
+	return text
+}
+
+func absoluteURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return config.AppBaseURL + path
+}
+
+func renderEmail(path string, ctx pongo2.Context) (string, error) {
+	tpl, err := pongo2.FromFile(path)
+	if err != nil {
+		return "", err
+	}
+	ctx["base_url"] = config.AppBaseURL
+	ctx["preferences_url"] = config.AppBaseURL + "/profile#notifications"
+	return tpl.Execute(ctx)
+}
+
+const (
+	mailBatchSize    = 50
+	mailRetryBase    = time.Minute
+	mailRetryMaxWait = 6 * time.Hour
+)
+
+// StartMailWorker sends queued emails in the background, retrying failures
+// with exponential backoff, and bundles digest entries once a day at
+// config.DigestHour.
+func StartMailWorker(db *gorm.DB) {
+	mailer := NewMailer()
+	log.Printf("Mail worker started (driver=%s)", config.MailDriver)
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(config.MailPollSeconds) * time.Second)
+		defer ticker.Stop()
+
+		var lastDigest string
+		for range ticker.C {
+			now := time.Now()
+			if day := now.Format("2006-01-02"); now.Hour() == config.DigestHour && lastDigest != day {
+				if err := queueDigests(db, now); err != nil {
+					log.Println("[mail] digest failed:", err)
+				} else {
+					lastDigest = day
+				}
+			}
+			sendPendingEmails(db, mailer, now)
+		}
+	}()
+}
+
+// sendPendingEmails sends due deliveries; a failure schedules the next attempt
+// or marks the delivery failed once config.MailMaxAttempts is reached
+func sendPendingEmails(db *gorm.DB, mailer Mailer, now time.Time) {
+	var deliveries []models.EmailDelivery
+	db.Where("status = ? AND next_attempt_at <= ?", "pending", now).
+		Order("next_attempt_at ASC").
+		Limit(mailBatchSize).
+		Find(&deliveries)
+
+	for _, delivery := range deliveries {
+		err := mailer.Send(EmailMessage{
+			To:       delivery.ToAddress,
+			Subject:  delivery.Subject,
+			TextBody: delivery.TextBody,
+			HTMLBody: delivery.HTMLBody,
+		})
+
+		updates := map[string]interface{}{"attempts": delivery.Attempts + 1}
+		if err == nil {
+			updates["status"] = "sent"
+			updates["sent_at"] = time.Now()
+			updates["last_error"] = ""
+		} else {
+			updates["last_error"] = err.Error()
+			if delivery.Attempts+1 >= config.MailMaxAttempts {
+				updates["status"] = "failed"
+				log.Printf("[mail] delivery %d to %s failed permanently: %v", delivery.ID, delivery.ToAddress, err)
+			} else {
+				updates["next_attempt_at"] = time.Now().Add(backoffDelay(mailRetryBase, mailRetryMaxWait, delivery.Attempts+1))
+			}
+		}
+		db.Model(&models.EmailDelivery{}).Where("id = ?", delivery.ID).Updates(updates)
+	}
+}
+
+// backoffDelay doubles base for every attempt after the first, capped at max
+func backoffDelay(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// queueDigests turns each user's waiting digest entries into one pending email
+func queueDigests(db *gorm.DB, now time.Time) error {
+	var userIDs []int64
+	if err := db.Model(&models.EmailDelivery{}).
+		Where("status = ? AND created_at < ?", "digest", now).
+		Distinct().
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var entries []models.EmailDelivery
+			if err := tx.Where("user_id = ? AND status = ? AND created_at < ?", userID, "digest", now).
+				Order("created_at ASC").
+				Find(&entries).Error; err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return nil
+			}
+
+			var user models.User
+			if err := tx.Select("id", "username", "first_name", "email").First(&user, userID).Error; err != nil {
+				return err
+			}
+
+			html, err := renderEmail("templates/email/digest.html", pongo2.Context{
+				"user":    user,
+				"entries": entries,
+			})
+			if err != nil {
+				return err
+			}
+
+			var text strings.Builder
+			for _, entry := range entries {
+				fmt.Fprintf(&text, "%s (%s)\n%s\n\n", entry.Subject, entry.CreatedAt.Format("02 Jan 15:04"), entry.TextBody)
+			}
+
+			ids := make([]int64, len(entries))
+			for i, entry := range entries {
+				ids[i] = entry.ID
+			}
+
+			digest := models.EmailDelivery{
+				UserID:        userID,
+				Type:          "digest",
+				ToAddress:     user.Email,
+				Subject:       fmt.Sprintf("[TemuIN] Ringkasan harian: %d notifikasi", len(entries)),
+				TextBody:      text.String(),
+				HTMLBody:      html,
+				Status:        "pending",
+				NextAttemptAt: &now,
+			}
+			if err := tx.Create(&digest).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.EmailDelivery{}).Where("id IN ?", ids).Update("status", "digested").Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/bits"
+	"net/http"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// The photo is shrunk to phashSize² gray pixels and the lowest
+	// phashBits² frequencies of its DCT make up the hash
+	phashSize = 32
+	phashBits = 8
+
+	// minPHashEnergy is the mean strength of those frequencies below which a
+	// photo is too flat (blank walls, solid colors) to compare meaningfully
+	minPHashEnergy = 1.0
+)
+
+// phashCos[u][x] is the DCT-II basis cos((2x+1)uπ / 2N)
+var phashCos = func() [phashBits][phashSize]float64 {
+	var table [phashBits][phashSize]float64
+	for u := 0; u < phashBits; u++ {
+		for x := 0; x < phashSize; x++ {
+			table[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSize))
+		}
+	}
+	return table
+}()
+
+// PerceptualHash fingerprints what a photo looks like rather than its bytes:
+// re-encoding, resizing, small crops and color tweaks change only a few of
+// its 64 bits. Returns 0 for photos too featureless to fingerprint.
+func PerceptualHash(img image.Image) uint64 {
+	gray := image.NewGray(image.Rect(0, 0, phashSize, phashSize))
+	draw.Draw(gray, gray.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Over, nil)
+
+	// Separable DCT, keeping only the low frequencies: rows, then columns
+	var rows [phashSize][phashBits]float64
+	for y := 0; y < phashSize; y++ {
+		for u := 0; u < phashBits; u++ {
+			var sum float64
+			for x := 0; x < phashSize; x++ {
+				sum += float64(gray.Pix[y*gray.Stride+x]) * phashCos[u][x]
+			}
+			rows[y][u] = sum
+		}
+	}
+	var coeffs [phashBits * phashBits]float64
+	for v := 0; v < phashBits; v++ {
+		for u := 0; u < phashBits; u++ {
+			var sum float64
+			for y := 0; y < phashSize; y++ {
+				sum += rows[y][u] * phashCos[v][y]
+			}
+			coeffs[v*phashBits+u] = sum / phashSize
+		}
+	}
+
+	// The first coefficient is the average brightness, not the structure
+	ac := coeffs[1:]
+	var energy float64
+	for _, c := range ac {
+		energy += math.Abs(c)
+	}
+	if energy/float64(len(ac)) < minPHashEnergy {
+		return 0
+	}
+	median := medianOf(ac)
+
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// PerceptualHashData decodes a stored photo and fingerprints it
+func PerceptualHashData(data []byte) (uint64, error) {
+	decode, ok := imageDecoders[http.DetectContentType(data)]
+	if !ok {
+		return 0, ErrImageFormat
+	}
+	img, err := decode(data)
+	if err != nil {
+		return 0, ErrImageFormat
+	}
+	return PerceptualHash(img), nil
+}
+
+// HammingDistance counts the bits two perceptual hashes differ in. Photos of
+// the same picture are usually within a handful.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0},
+		{0, 0xFFFFFFFFFFFFFFFF, 64},
+		{0b1011, 0b0010, 2},
+		{0x8000000000000000, 1, 2},
+		{0xF0F0F0F0F0F0F0F0, 0x0F0F0F0F0F0F0F0F, 64},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%#x, %#x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := HammingDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("HammingDistance(%#x, %#x) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
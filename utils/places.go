@@ -0,0 +1,372 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"temuin/models"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// PlaceKinds lists the place levels from the top down
+var PlaceKinds = []string{"site", "building", "floor", "room"}
+
+// PlaceKindLabels are the Indonesian names of the place levels
+var PlaceKindLabels = map[string]string{
+	"site":     "Kampus / Lokasi",
+	"building": "Gedung",
+	"floor":    "Lantai",
+	"room":     "Ruang",
+}
+
+// placeParents lists which kinds may contain each kind ("" is the top level)
+var placeParents = map[string][]string{
+	"site":     {""},
+	"building": {"site"},
+	"floor":    {"building"},
+	"room":     {"building", "floor"},
+}
+
+// ValidPlaceParent reports whether a place of kind may sit under parentKind
+// (empty for a top-level place)
+func ValidPlaceParent(kind, parentKind string) bool {
+	for _, allowed := range placeParents[kind] {
+		if allowed == parentKind {
+			return true
+		}
+	}
+	return false
+}
+
+// placeAbbreviations expands the short forms users type in locations
+var placeAbbreviations = map[string]string{
+	"gd": "gedung", "gdg": "gedung", "gdung": "gedung",
+	"lt": "lantai", "lnt": "lantai", "lntai": "lantai",
+	"r": "ruang", "rg": "ruang", "ruangan": "ruang", "rm": "ruang",
+	"kmps": "kampus", "kps": "kampus",
+	"fak": "fakultas", "perpus": "perpustakaan", "lab": "laboratorium",
+	"jl": "jalan", "jln": "jalan",
+}
+
+// NormalizePlace lowercases a place name, drops punctuation and expands
+// common abbreviations, so "Gd. B Lt.2" and "gedung b lantai 2" compare equal
+func NormalizePlace(text string) string {
+	return strings.Join(placeTokens(text), " ")
+}
+
+func placeTokens(text string) []string {
+	words := wordPattern.FindAllString(strings.ToLower(text), -1)
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		// "lt2" and "b12" are split into letters and digits
+		for _, part := range splitDigits(word) {
+			if full, ok := placeAbbreviations[part]; ok {
+				part = full
+			}
+			tokens = append(tokens, part)
+		}
+	}
+	return tokens
+}
+
+// splitDigits splits a word where letters meet digits
+func splitDigits(word string) []string {
+	var parts []string
+	start := 0
+	for i := 1; i < len(word); i++ {
+		if isDigit(word[i]) != isDigit(word[i-1]) {
+			parts = append(parts, word[start:i])
+			start = i
+		}
+	}
+	return append(parts, word[start:])
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// PlaceOption is a place with its depth, for indented select lists
+type PlaceOption struct {
+	models.Place
+	Depth  int
+	Indent string
+}
+
+// loadPlaces returns every place with its aliases, keyed by parent ID (0 for
+// top-level places). The directory is small, so it is walked in memory.
+func loadPlaces(db *gorm.DB, activeOnly bool) (map[int64][]models.Place, error) {
+	var places []models.Place
+	query := db.Preload("Aliases").Order("name")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Find(&places).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[int64][]models.Place)
+	for _, place := range places {
+		var parent int64
+		if place.ParentID != nil {
+			parent = *place.ParentID
+		}
+		children[parent] = append(children[parent], place)
+	}
+	return children, nil
+}
+
+// PlaceOptions lists places depth-first, parents before their children.
+// Inactive places (and everything under them) are skipped when activeOnly.
+func PlaceOptions(db *gorm.DB, activeOnly bool) []PlaceOption {
+	children, err := loadPlaces(db, activeOnly)
+	if err != nil {
+		return nil
+	}
+
+	var options []PlaceOption
+	var walk func(parent int64, depth int)
+	walk = func(parent int64, depth int) {
+		for _, place := range children[parent] {
+			options = append(options, PlaceOption{Place: place, Depth: depth, Indent: strings.Repeat("— ", depth)})
+			walk(place.ID, depth+1)
+		}
+	}
+	walk(0, 0)
+	return options
+}
+
+// PlaceSubtreeIDs returns the place and all places under it, so filtering by
+// a building also finds posts pinned to its rooms
+func PlaceSubtreeIDs(db *gorm.DB, placeID int64) []int64 {
+	children, err := loadPlaces(db, false)
+	if err != nil {
+		return []int64{placeID}
+	}
+
+	ids := []int64{placeID}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			ids = append(ids, child.ID)
+		}
+	}
+	return ids
+}
+
+// RefreshPlaceNames recomputes FullName for a place and everything under it.
+// Call it after a place is created, renamed or moved.
+func RefreshPlaceNames(db *gorm.DB, place *models.Place) error {
+	fullName := place.Name
+	if place.ParentID != nil {
+		var parent models.Place
+		if err := db.First(&parent, *place.ParentID).Error; err != nil {
+			return err
+		}
+		fullName = parent.FullName + " › " + place.Name
+	}
+	if err := db.Model(place).Update("full_name", fullName).Error; err != nil {
+		return err
+	}
+	place.FullName = fullName
+
+	var children []models.Place
+	if err := db.Where("parent_id = ?", place.ID).Find(&children).Error; err != nil {
+		return err
+	}
+	for i := range children {
+		if err := RefreshPlaceNames(db, &children[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlaceSuggestion is one autocomplete entry
+type PlaceSuggestion struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Kind     string `json:"kind"`
+}
+
+// placeCandidate is one way of writing a place: its path, its path without
+// the site, or an alias
+type placeCandidate struct {
+	place  models.Place
+	tokens []string
+}
+
+func placeCandidates(db *gorm.DB) ([]placeCandidate, error) {
+	children, err := loadPlaces(db, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []placeCandidate
+	var walk func(parent int64, path []string, siteLen int)
+	walk = func(parent int64, path []string, siteLen int) {
+		for _, place := range children[parent] {
+			full := append(append([]string{}, path...), placeTokens(place.Name)...)
+			candidates = append(candidates, placeCandidate{place, full})
+			if parent == 0 {
+				siteLen = len(full)
+			} else if siteLen < len(full) {
+				// Users rarely name the campus they are on
+				candidates = append(candidates, placeCandidate{place, full[siteLen:]})
+			}
+			for _, alias := range place.Aliases {
+				candidates = append(candidates, placeCandidate{place, placeTokens(alias.Alias)})
+			}
+			walk(place.ID, full, siteLen)
+		}
+	}
+	walk(0, nil, 0)
+	return candidates, nil
+}
+
+// SuggestPlaces returns up to limit active places whose name, path or alias
+// contains every word of the query; the last word may be unfinished
+func SuggestPlaces(db *gorm.DB, query string, limit int) ([]PlaceSuggestion, error) {
+	words := placeTokens(query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	candidates, err := placeCandidates(db)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool)
+	var matches []models.Place
+	for _, candidate := range candidates {
+		if seen[candidate.place.ID] {
+			continue
+		}
+		matched := true
+		for i, word := range words {
+			prefix := i == len(words)-1
+			if !containsToken(candidate.tokens, word, prefix) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			seen[candidate.place.ID] = true
+			matches = append(matches, candidate.place)
+		}
+	}
+
+	// Shorter paths first: "Gedung B" before "Gedung B › Lantai 2 › R. 201"
+	sort.SliceStable(matches, func(i, j int) bool {
+		return len(matches[i].FullName) < len(matches[j].FullName)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	suggestions := make([]PlaceSuggestion, len(matches))
+	for i, place := range matches {
+		suggestions[i] = PlaceSuggestion{ID: place.ID, Name: place.Name, FullName: place.FullName, Kind: place.Kind}
+	}
+	return suggestions, nil
+}
+
+func containsToken(tokens []string, word string, prefix bool) bool {
+	for _, token := range tokens {
+		if prefix && strings.HasPrefix(token, word) {
+			return true
+		}
+		if tokenSimilarity(token, word) >= 0.8 {
+			return true
+		}
+	}
+	return false
+}
+
+// PlaceMatcher matches free-text locations against the active places. Build
+// it once for a batch of locations.
+type PlaceMatcher struct {
+	candidates []placeCandidate
+}
+
+// NewPlaceMatcher loads the places directory
+func NewPlaceMatcher(db *gorm.DB) (*PlaceMatcher, error) {
+	candidates, err := placeCandidates(db)
+	if err != nil {
+		return nil, err
+	}
+	return &PlaceMatcher{candidates: candidates}, nil
+}
+
+// Match finds the place a free-text location most likely refers to. The
+// score is the share of the place's words found in the text (fuzzily,
+// numbers exactly); ties go to the more specific place. Returns nil when no
+// place reaches minScore.
+func (m *PlaceMatcher) Match(text string, minScore float64) (*models.Place, float64) {
+	words := placeTokens(text)
+	if len(words) == 0 {
+		return nil, 0
+	}
+
+	var best *models.Place
+	var bestScore float64
+	var bestMatched int
+	for i := range m.candidates {
+		candidate := &m.candidates[i]
+		if len(candidate.tokens) == 0 {
+			continue
+		}
+		matched := 0
+		for _, token := range candidate.tokens {
+			if containsToken(words, token, false) {
+				matched++
+			}
+		}
+		score := float64(matched) / float64(len(candidate.tokens))
+		if score > bestScore || (score == bestScore && matched > bestMatched) {
+			best, bestScore, bestMatched = &candidate.place, score, matched
+		}
+	}
+	if best == nil || bestScore < minScore {
+		return nil, bestScore
+	}
+	return best, bestScore
+}
+
+// tokenSimilarity is 1 for equal tokens and falls with edit distance. Numbers
+// and short tokens must match exactly, so "lantai 2" never matches "lantai 3".
+func tokenSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if isDigit(a[0]) || isDigit(b[0]) || utf8.RuneCountInString(a) < 4 || utf8.RuneCountInString(b) < 4 {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
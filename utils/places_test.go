@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"temuin/models"
+	"testing"
+)
+
+// testPlaceMatcher builds a matcher over a small campus without a database,
+// the way placeCandidates flattens the directory
+func testPlaceMatcher() *PlaceMatcher {
+	places := []struct {
+		place models.Place
+		path  string
+	}{
+		{models.Place{ID: 1, Name: "Kampus Depok"}, "Kampus Depok"},
+		{models.Place{ID: 2, Name: "Gedung B"}, "Gedung B"},
+		{models.Place{ID: 3, Name: "Lantai 2"}, "Gedung B Lantai 2"},
+		{models.Place{ID: 4, Name: "Lantai 3"}, "Gedung B Lantai 3"},
+		{models.Place{ID: 5, Name: "Perpustakaan Pusat"}, "Perpustakaan Pusat"},
+		{models.Place{ID: 6, Name: "Kantin Teknik"}, "Kantin Teknik"},
+	}
+	m := &PlaceMatcher{}
+	for _, p := range places {
+		m.candidates = append(m.candidates, placeCandidate{place: p.place, tokens: placeTokens(p.path)})
+	}
+	return m
+}
+
+func TestPlaceMatcherMatch(t *testing.T) {
+	m := testPlaceMatcher()
+	tests := []struct {
+		text     string
+		minScore float64
+		wantID   int64 // 0 when nothing should match
+	}{
+		{"Gedung B lantai 2", 0.8, 3},
+		{"gd. B lt.2", 0.8, 3},
+		{"lantai 3", 0.8, 0}, // half of "Gedung B Lantai 3"
+		{"gedung b lantai 3 dekat lift", 0.8, 4},
+		{"gedung b", 0.8, 2},
+		{"perpus pusat", 0.8, 5},
+		{"perpustakan pusat", 0.8, 5},
+		{"kantin teknik", 0.8, 6},
+		{"parkiran motor", 0.5, 0},
+		{"", 0.5, 0},
+	}
+	for _, tt := range tests {
+		place, score := m.Match(tt.text, tt.minScore)
+		var got int64
+		if place != nil {
+			got = place.ID
+		}
+		if got != tt.wantID {
+			t.Errorf("Match(%q, %v) = place %d (score %.2f), want %d", tt.text, tt.minScore, got, score, tt.wantID)
+		}
+	}
+}
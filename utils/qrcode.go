@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// TagURL is the address a belonging tag's QR code opens
+func TagURL(code string) string {
+	return absoluteURL("/t/" + code)
+}
+
+// HandoverURL opens a post with its handover code filled in for the finder
+func HandoverURL(itemID int64, code string) string {
+	return absoluteURL(fmt.Sprintf("/item/%d?code=%s#handover", itemID, code))
+}
+
+// QRCodePNG renders content as a size x size PNG with the standard quiet zone
+func QRCodePNG(content string, size int) ([]byte, error) {
+	return qrcode.Encode(content, qrcode.Medium, size)
+}
+
+// QRCodeSVG renders content as a scalable SVG, one unit per module. Dark
+// modules of a row are merged into runs to keep the path short.
+func QRCodeSVG(content string) ([]byte, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+	size := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		size, size, size, size, path.String())
+	return []byte(svg), nil
+}
+
+// qrModules returns the modules of content without the quiet zone, for
+// drawing the code as vector shapes
+func qrModules(content string) ([][]bool, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	q.DisableBorder = true
+	return q.Bitmap(), nil
+}
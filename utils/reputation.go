@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"temuin/config"
+	"temuin/models"
+
+	"gorm.io/gorm"
+)
+
+// Reputation summarizes how a user has behaved in past handovers
+type Reputation struct {
+	Returns int64 // completed returns as the owner or the finder
+	NoShows int64 // agreed meetups the other side reported them missing
+}
+
+// Flagged reports whether the user has missed enough meetups to warn the
+// people arranging a handover with them
+func (r Reputation) Flagged() bool {
+	return r.NoShows >= int64(config.NoShowWarning)
+}
+
+// UserReputation is the Reputation of one user
+func UserReputation(db *gorm.DB, userID int64) Reputation {
+	return Reputations(db, []int64{userID})[userID]
+}
+
+// Reputations looks up the Reputation of several users at once, such as the
+// claimants of a post
+func Reputations(db *gorm.DB, userIDs []int64) map[int64]Reputation {
+	result := make(map[int64]Reputation, len(userIDs))
+	if len(userIDs) == 0 {
+		return result
+	}
+
+	type count struct {
+		UserID int64
+		Total  int64
+	}
+	add := func(rows []count, apply func(*Reputation, int64)) {
+		for _, row := range rows {
+			rep := result[row.UserID]
+			apply(&rep, row.Total)
+			result[row.UserID] = rep
+		}
+	}
+
+	var asFinder, asOwner, noShows []count
+	db.Model(&models.LostItem{}).Select("finder_id AS user_id, COUNT(*) AS total").
+		Where("state = ? AND finder_id IN ?", ItemReturned, userIDs).Group("finder_id").Scan(&asFinder)
+	db.Model(&models.LostItem{}).Select("user_id, COUNT(*) AS total").
+		Where("state = ? AND finder_id IS NOT NULL AND user_id IN ?", ItemReturned, userIDs).Group("user_id").Scan(&asOwner)
+	db.Model(&models.MeetupNoShow{}).Select("reported_id AS user_id, COUNT(*) AS total").
+		Where("reported_id IN ?", userIDs).Group("reported_id").Scan(&noShows)
+
+	add(asFinder, func(r *Reputation, n int64) { r.Returns += n })
+	add(asOwner, func(r *Reputation, n int64) { r.Returns += n })
+	add(noShows, func(r *Reputation, n int64) { r.NoShows += n })
+	return result
+}
@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Field weights of the embedded search index
+const (
+	searchWeightTitle       = 3
+	searchWeightLocation    = 2
+	searchWeightAttribute   = 2
+	searchWeightDescription = 1
+
+	snippetContext = 80  // bytes of context kept before the first hit
+	snippetLength  = 220 // bytes of description shown in a snippet
+)
+
+// IndexItem rebuilds the search terms of a post. Call it after the post and
+// its attributes are created or edited, in the same transaction when there is
+// one. Private attributes are never indexed.
+func IndexItem(db *gorm.DB, item *models.LostItem) error {
+	weights := make(map[string]float64)
+	for _, term := range SearchTerms(item.Title) {
+		weights[term] += searchWeightTitle
+	}
+	for _, term := range SearchTerms(item.Location) {
+		weights[term] += searchWeightLocation
+	}
+	for _, term := range SearchTerms(item.Description) {
+		weights[term] += searchWeightDescription
+	}
+	for _, attr := range ItemAttributeValues(db, item.ID, false) {
+		for _, term := range SearchTerms(attr.Value) {
+			weights[term] += searchWeightAttribute
+		}
+	}
+
+	if err := RemoveItemIndex(db, item.ID); err != nil {
+		return err
+	}
+	if len(weights) == 0 {
+		return nil
+	}
+
+	rows := make([]models.SearchTerm, 0, len(weights))
+	for term, weight := range weights {
+		rows = append(rows, models.SearchTerm{ItemID: item.ID, Term: term, Weight: weight})
+	}
+	return db.CreateInBatches(rows, 200).Error
+}
+
+// RemoveItemIndex drops a post from the search index
+func RemoveItemIndex(db *gorm.DB, itemID int64) error {
+	return db.Where("item_id = ?", itemID).Delete(&models.SearchTerm{}).Error
+}
+
+// SearchParams are the listing filters of the page plus the pagination cursor
+type SearchParams struct {
+	Filter ListingFilter // Query is ranked, every other filter narrows the hits
+	Cursor string
+}
+
+// SearchHit is a post with its relevance score and highlighted text. Title and
+// Snippet are HTML-escaped with matches wrapped in <mark>.
+type SearchHit struct {
+	models.LostItem
+	Score        float64
+	TitleHTML    string
+	SnippetHTML  string
+	MatchedTerms int
+}
+
+// SearchPage is one page of ranked results; NextCursor is empty on the last
+// page. Listing carries the total, facets and filter of the search, its items
+// and page links stay empty.
+type SearchPage struct {
+	Hits       []SearchHit
+	NextCursor string
+	Listing    ListingPage
+}
+
+type searchRow struct {
+	ItemID  int64
+	Score   float64
+	Matched int
+}
+
+// SearchItems ranks the posts matching the listing filter against its query
+// with TF-IDF over the embedded index. Posts matching more of the query terms
+// rank higher. Pagination uses a keyset cursor on (score, id), so pages stay
+// stable while new posts arrive.
+func SearchItems(db *gorm.DB, params SearchParams) (SearchPage, error) {
+	filter := params.Filter
+	if filter.PerPage <= 0 {
+		filter.PerPage = config.ListingPerPage
+	}
+	filter.Sort = "relevance"
+	filter.resolvePlace(db)
+	page := SearchPage{Listing: ListingPage{Page: 1, Sort: filter.Sort, Filter: filter}}
+
+	terms := uniqueTerms(SearchTerms(filter.Query))
+	if len(terms) == 0 {
+		return page, nil
+	}
+
+	if err := filter.apply(db, "").Count(&page.Listing.Total).Error; err != nil {
+		return page, err
+	}
+	if filter.CountFacets {
+		facets, err := countFacets(db, filter)
+		if err != nil {
+			return page, err
+		}
+		page.Listing.Facets = facets
+	}
+
+	// Inverse document frequency per term
+	var totalDocs int64
+	if err := db.Model(&models.LostItem{}).Count(&totalDocs).Error; err != nil {
+		return page, err
+	}
+	var frequencies []struct {
+		Term  string
+		Count int64
+	}
+	if err := db.Model(&models.SearchTerm{}).
+		Select("term, COUNT(*) AS count").
+		Where("term IN ?", terms).
+		Group("term").
+		Scan(&frequencies).Error; err != nil {
+		return page, err
+	}
+	if len(frequencies) == 0 {
+		return page, nil
+	}
+
+	caseSQL := "CASE st.term"
+	var args []interface{}
+	for _, f := range frequencies {
+		caseSQL += " WHEN ? THEN ?"
+		args = append(args, f.Term, math.Log(1+float64(totalDocs)/float64(f.Count)))
+	}
+	caseSQL += " ELSE 0 END"
+
+	// Score is rounded so the cursor can compare it exactly
+	scoreSQL := fmt.Sprintf("ROUND(SUM(st.weight * %s) * COUNT(DISTINCT st.term) / ?, 6)", caseSQL)
+	args = append(args, len(terms))
+
+	query := db.Table("core_searchterm AS st").
+		Select("st.item_id AS item_id, "+scoreSQL+" AS score, COUNT(DISTINCT st.term) AS matched", args...).
+		Where("st.term IN ?", terms).
+		Where("st.item_id IN (?)", filter.apply(db, "").Select("core_lostitem.id")).
+		Group("st.item_id")
+
+	if score, id, ok := decodeSearchCursor(params.Cursor); ok {
+		query = query.Having("score < ? OR (score = ? AND st.item_id < ?)", score, score, id)
+	}
+
+	var rows []searchRow
+	if err := query.Order("score DESC, st.item_id DESC").Limit(filter.PerPage + 1).Scan(&rows).Error; err != nil {
+		return page, err
+	}
+	if len(rows) > filter.PerPage {
+		last := rows[filter.PerPage-1]
+		page.NextCursor = encodeSearchCursor(last.Score, last.ItemID)
+		rows = rows[:filter.PerPage]
+	}
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ItemID
+	}
+	var items []models.LostItem
+	if err := db.Preload("User").Where("id IN ?", ids).Find(&items).Error; err != nil {
+		return page, err
+	}
+	byID := make(map[int64]models.LostItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	stems := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		stems[term] = true
+	}
+	for _, row := range rows {
+		item, ok := byID[row.ItemID]
+		if !ok {
+			continue
+		}
+		page.Hits = append(page.Hits, SearchHit{
+			LostItem:     item,
+			Score:        row.Score,
+			TitleHTML:    HighlightTerms(item.Title, stems),
+			SnippetHTML:  Snippet(item.Description, stems),
+			MatchedTerms: row.Matched,
+		})
+	}
+	return page, nil
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+func encodeSearchCursor(score float64, id int64) string {
+	raw := strconv.FormatFloat(score, 'f', 6, 64) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor string) (float64, int64, bool) {
+	if cursor == "" {
+		return 0, 0, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, false
+	}
+	scorePart, idPart, found := strings.Cut(string(raw), ":")
+	if !found {
+		return 0, 0, false
+	}
+	score, err1 := strconv.ParseFloat(scorePart, 64)
+	id, err2 := strconv.ParseInt(idPart, 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return score, id, true
+}
+
+// HighlightTerms HTML-escapes text and wraps every word whose stem is in stems
+// with <mark>
+func HighlightTerms(text string, stems map[string]bool) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range wordPattern.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		if !stems[StemIndonesian(strings.ToLower(word))] {
+			continue
+		}
+		out.WriteString(html.EscapeString(text[last:loc[0]]))
+		out.WriteString("<mark>")
+		out.WriteString(html.EscapeString(word))
+		out.WriteString("</mark>")
+		last = loc[1]
+	}
+	out.WriteString(html.EscapeString(text[last:]))
+	return out.String()
+}
+
+// Snippet returns a highlighted excerpt of text around the first match, or
+// its beginning when nothing matches
+func Snippet(text string, stems map[string]bool) string {
+	start := 0
+	for _, loc := range wordPattern.FindAllStringIndex(text, -1) {
+		if stems[StemIndonesian(strings.ToLower(text[loc[0]:loc[1]]))] {
+			start = loc[0] - snippetContext
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end > len(text) {
+		end = len(text)
+	}
+
+	// Move both ends onto rune and word boundaries
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	if start > 0 {
+		if i := strings.IndexByte(text[start:end], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[start:end], ' '); i > 0 {
+			end = start + i
+		}
+	}
+
+	snippet := HighlightTerms(text[start:end], stems)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
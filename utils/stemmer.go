@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// searchStopwords are frequent Indonesian (and a few English) words that carry
+// no meaning for lost & found searches
+var searchStopwords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true, "untuk": true,
+	"dengan": true, "ini": true, "itu": true, "pada": true, "atau": true, "saya": true,
+	"aku": true, "kami": true, "ada": true, "tidak": true, "sudah": true, "akan": true,
+	"juga": true, "dalam": true, "oleh": true, "karena": true, "jika": true, "kalau": true,
+	"tolong": true, "mohon": true, "bantu": true, "sekitar": true, "tadi": true,
+	"the": true, "a": true, "an": true, "of": true, "and": true, "in": true, "at": true,
+}
+
+// isVowel is used by the prefix recoding rules (mem+vowel -> p, men+vowel -> t)
+func isVowel(r byte) bool {
+	return strings.IndexByte("aeiou", r) >= 0
+}
+
+// StemIndonesian reduces a lowercase word to an approximate root by stripping
+// inflectional suffixes (-lah, -nya, ...), common prefixes (di-, me-, ber-,
+// ter-, pe-, ...) and derivational suffixes (-kan, -an, -i), without a root
+// dictionary. Derivational suffixes go last and only from roots longer than
+// four letters, so every form of a short root stems alike ("cari", "dicari",
+// "pencarian" -> "cari"). ke- is only removed together with -an ("kehilangan"
+// -> "hilang"), since many roots start with it ("kemeja"). The same function
+// is used for indexing and querying, so over-stemming only merges related
+// forms ("ditemukan", "temuan" -> "temu").
+func StemIndonesian(word string) string {
+	if !isASCIIWord(word) || len(word) <= 4 {
+		return word
+	}
+
+	w := word
+	w = stripSuffix(w, "lah", "kah", "tah", "pun")
+	w = stripSuffix(w, "nya", "ku", "mu")
+
+	confixKe := strings.HasPrefix(w, "ke") && strings.HasSuffix(w, "an")
+	for i := 0; i < 2; i++ {
+		next := stripPrefix(w, confixKe && i == 0)
+		if next == w {
+			break
+		}
+		w = next
+	}
+
+	if len(w) > 4 {
+		w = stripSuffix(w, "kan", "an", "i")
+	}
+	return w
+}
+
+// isASCIIWord skips stemming for words with non-ASCII letters or digits,
+// e.g. model numbers like "a52s"
+func isASCIIWord(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func stripSuffix(w string, suffixes ...string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(w, suffix) && len(w)-len(suffix) >= 3 {
+			return w[:len(w)-len(suffix)]
+		}
+	}
+	return w
+}
+
+// stripPrefix removes one prefix; ke- only when allowKe is set
+func stripPrefix(w string, allowKe bool) string {
+	if len(w) <= 4 {
+		return w
+	}
+
+	// strip returns w without prefix (plus a recoded first letter) when
+	// at least three letters remain
+	strip := func(prefix, recode string) (string, bool) {
+		if !strings.HasPrefix(w, prefix) {
+			return w, false
+		}
+		rest := recode + w[len(prefix):]
+		if len(rest) < 3 {
+			return w, false
+		}
+		return rest, true
+	}
+
+	rules := []struct {
+		prefix, recode string
+		beforeVowel    bool // only apply when a vowel follows the prefix
+	}{
+		{"meng", "", false},
+		{"meny", "s", false},
+		{"mem", "p", true},
+		{"men", "t", true},
+		{"mem", "", false},
+		{"men", "", false},
+		{"me", "", false},
+		{"peng", "", false},
+		{"peny", "s", false},
+		{"pem", "p", true},
+		{"pen", "t", true},
+		{"pem", "", false},
+		{"pen", "", false},
+		{"per", "", false},
+		{"pe", "", false},
+		{"ber", "", false},
+		{"be", "", false},
+		{"ter", "", false},
+		{"di", "", false},
+		{"se", "", false},
+	}
+	if allowKe {
+		if rest, ok := strip("ke", ""); ok {
+			return rest
+		}
+	}
+	for _, rule := range rules {
+		if rule.beforeVowel && (len(w) <= len(rule.prefix) || !isVowel(w[len(rule.prefix)])) {
+			continue
+		}
+		if rest, ok := strip(rule.prefix, rule.recode); ok {
+			return rest
+		}
+	}
+	return w
+}
+
+// SearchTerms tokenizes text into stemmed index terms, dropping stopwords.
+// Duplicates are kept so callers can count frequencies.
+func SearchTerms(text string) []string {
+	words := wordPattern.FindAllString(strings.ToLower(text), -1)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < 2 || searchStopwords[word] {
+			continue
+		}
+		term := StemIndonesian(word)
+		if runes := []rune(term); len(runes) > 64 {
+			term = string(runes[:64])
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStemIndonesian(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"ditemukan", "temu"},
+		{"menemukan", "temu"},
+		{"temuan", "temu"},
+		{"cari", "cari"},
+		{"dicari", "cari"},
+		{"pencarian", "cari"},
+		{"beli", "beli"},
+		{"dibeli", "beli"},
+		{"kehilangan", "hilang"},
+		{"kemeja", "kemeja"},
+		{"dompetku", "dompet"},
+		{"tasnya", "tas"},
+		{"menyapu", "sapu"},
+		{"mengambil", "ambil"},
+		{"berwarna", "warna"},
+		{"terjatuh", "jatuh"},
+		{"perpustakaan", "pustaka"},
+		{"laptop", "laptop"},
+		{"kartu", "kartu"},
+		{"ponsel", "ponsel"},
+		{"café", "café"},
+	}
+	for _, tt := range tests {
+		if got := StemIndonesian(tt.word); got != tt.want {
+			t.Errorf("StemIndonesian(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+// Without a dictionary some roots lose a letter; what matters is that every
+// form of a word stems alike
+func TestStemIndonesianForms(t *testing.T) {
+	forms := [][]string{
+		{"kunci", "kuncinya", "kuncimu"},
+		{"pakai", "memakai", "dipakai"},
+		{"hilang", "kehilangan", "menghilang"},
+		{"jatuh", "terjatuh", "menjatuhkan"},
+	}
+	for _, words := range forms {
+		want := StemIndonesian(words[0])
+		for _, word := range words[1:] {
+			if got := StemIndonesian(word); got != want {
+				t.Errorf("StemIndonesian(%q) = %q, want %q like %q", word, got, want, words[0])
+			}
+		}
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"cari dicari pencarian", []string{"cari", "cari", "cari"}},
+		{"beli dibeli", []string{"beli", "beli"}},
+		{"Dompet HITAM", []string{"dompet", "hitam"}},
+		{"Tolong, dompet saya hilang di kantin!", []string{"dompet", "hilang", "kantin"}},
+		{"a b c", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := SearchTerms(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SearchTerms(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"temuin/models"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Sticker grid of the printable sheet, in millimetres on A4 portrait
+const (
+	sheetMargin  = 10.0
+	sheetColumns = 3
+	sheetRows    = 4
+	stickerQR    = 40.0
+)
+
+// TagSheetPDF lays out one sticker per tag, twelve to a page, with cut
+// lines. Codes are drawn as vector squares so they stay sharp at any size.
+func TagSheetPDF(tags []models.BelongingTag) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetTitle("TemuIN - Tag Barang", true)
+	// Core fonts are cp1252; labels are translated and anything else dropped
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	cellWidth := (pageWidth - 2*sheetMargin) / sheetColumns
+	cellHeight := (pageHeight - 2*sheetMargin) / sheetRows
+
+	for i, tag := range tags {
+		slot := i % (sheetColumns * sheetRows)
+		if slot == 0 {
+			pdf.AddPage()
+		}
+		x := sheetMargin + float64(slot%sheetColumns)*cellWidth
+		y := sheetMargin + float64(slot/sheetColumns)*cellHeight
+
+		// Cut lines
+		pdf.SetDrawColor(180, 180, 180)
+		pdf.SetLineWidth(0.2)
+		pdf.SetDashPattern([]float64{1, 1}, 0)
+		pdf.Rect(x, y, cellWidth, cellHeight, "D")
+		pdf.SetDashPattern([]float64{}, 0)
+
+		url := TagURL(tag.Code)
+		modules, err := qrModules(url)
+		if err != nil {
+			return nil, err
+		}
+		module := stickerQR / float64(len(modules))
+		qrX := x + (cellWidth-stickerQR)/2
+		qrY := y + 5
+		pdf.SetFillColor(0, 0, 0)
+		for row, line := range modules {
+			for col, dark := range line {
+				if dark {
+					pdf.Rect(qrX+float64(col)*module, qrY+float64(row)*module, module, module, "F")
+				}
+			}
+		}
+
+		textY := qrY + stickerQR + 3
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetXY(x+2, textY)
+		pdf.CellFormat(cellWidth-4, 4, fitText(pdf, tr(tag.Label), cellWidth-4), "", 2, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.SetX(x + 2)
+		pdf.CellFormat(cellWidth-4, 3.5, tr("Menemukan barang ini? Pindai kode"), "", 2, "C", false, 0, "")
+		pdf.SetX(x + 2)
+		pdf.CellFormat(cellWidth-4, 3.5, tr("untuk menghubungi pemiliknya."), "", 2, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 6)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.SetX(x + 2)
+		shortURL := strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+		pdf.CellFormat(cellWidth-4, 3, fitText(pdf, shortURL, cellWidth-4), "", 2, "C", false, 0, "")
+	}
+	if len(tags) == 0 {
+		pdf.AddPage()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fitText shortens text with an ellipsis until it fits the width in the
+// current font. The text is already single-byte cp1252.
+func fitText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"temuin/config"
+	"temuin/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvents are the events a subscription can listen to
+var WebhookEvents = []string{"item.created", "item.returned", "claim.created", "report.created"}
+
+// ValidWebhookEvent reports whether event is one of WebhookEvents
+func ValidWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the JSON body POSTed to subscribers
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+const (
+	webhookBatchSize     = 50
+	webhookRetryBase     = 30 * time.Second
+	webhookRetryMaxWait  = 12 * time.Hour
+	webhookResponseLimit = 2048 // bytes of the subscriber response kept in the log
+)
+
+// webhookWake lets QueueWebhook start a delivery without waiting for the next poll
+var webhookWake = make(chan struct{}, 1)
+
+// NewWebhookSecret returns a random signing secret for a subscription
+func NewWebhookSecret() string {
+	return "whsec_" + randomHex(24)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newEventID returns a random UUIDv4 string
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// SignWebhook computes the X-TemuIN-Signature value for a timestamp and body.
+// Receivers recompute HMAC-SHA256(secret, timestamp + "." + body) and compare.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// QueueWebhook creates a pending delivery of the event for every active
+// subscription listening to it. Call it inside the transaction that made the
+// change so no event is sent for a rolled-back write.
+func QueueWebhook(tx *gorm.DB, event string, data interface{}) error {
+	var subscriptions []models.WebhookSubscription
+	if err := tx.Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	var targets []models.WebhookSubscription
+	for _, sub := range subscriptions {
+		if subscribedTo(sub, event) {
+			targets = append(targets, sub)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	payload := WebhookPayload{
+		ID:        newEventID(),
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, sub := range targets {
+		delivery := models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        payload.ID,
+			Event:          event,
+			Payload:        string(body),
+			Status:         "pending",
+			NextAttemptAt:  &now,
+		}
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+	}
+
+	WakeWebhookWorker()
+	return nil
+}
+
+func subscribedTo(sub models.WebhookSubscription, event string) bool {
+	for _, e := range strings.Split(sub.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WakeWebhookWorker asks the worker to look for due deliveries now
+func WakeWebhookWorker() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartWebhookWorker delivers pending webhooks in the background, retrying
+// failures with exponential backoff until config.WebhookMaxAttempts
+func StartWebhookWorker(db *gorm.DB) {
+	client := &http.Client{Timeout: time.Duration(config.WebhookTimeoutSeconds) * time.Second}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(config.WebhookPollSeconds) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-webhookWake:
+				// The wake-up may beat the queuing transaction's commit; the
+				// next tick picks those deliveries up.
+			}
+			sendPendingWebhooks(db, client)
+		}
+	}()
+}
+
+func sendPendingWebhooks(db *gorm.DB, client *http.Client) {
+	var deliveries []models.WebhookDelivery
+	db.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+		Order("next_attempt_at ASC").
+		Limit(webhookBatchSize).
+		Find(&deliveries)
+
+	for _, delivery := range deliveries {
+		code, respBody, err := postWebhook(client, delivery)
+
+		attempts := delivery.Attempts + 1
+		updates := map[string]interface{}{
+			"attempts":      attempts,
+			"response_code": code,
+			"response_body": respBody,
+		}
+		if err == nil {
+			now := time.Now()
+			updates["status"] = "success"
+			updates["delivered_at"] = now
+			updates["last_error"] = ""
+		} else {
+			updates["last_error"] = err.Error()
+			if attempts >= config.WebhookMaxAttempts {
+				updates["status"] = "failed"
+				log.Printf("[webhook] delivery %d (%s) to %s failed permanently: %v", delivery.ID, delivery.Event, delivery.Subscription.URL, err)
+			} else {
+				updates["next_attempt_at"] = time.Now().Add(backoffDelay(webhookRetryBase, webhookRetryMaxWait, attempts))
+			}
+		}
+		db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates)
+	}
+}
+
+// postWebhook sends one delivery. Any non-2xx response counts as a failure.
+func postWebhook(client *http.Client, delivery models.WebhookDelivery) (int, string, error) {
+	sub := delivery.Subscription
+	if sub.ID == 0 {
+		return 0, "", fmt.Errorf("subscription no longer exists")
+	}
+	if !sub.IsActive {
+		return 0, "", fmt.Errorf("subscription is disabled")
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TemuIN-Webhooks/1.0")
+	req.Header.Set("X-TemuIN-Event", delivery.Event)
+	req.Header.Set("X-TemuIN-Delivery", delivery.EventID)
+	req.Header.Set("X-TemuIN-Timestamp", timestamp)
+	req.Header.Set("X-TemuIN-Signature", SignWebhook(sub.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, strings.ToValidUTF8(string(respBody), ""), fmt.Errorf("subscriber responded %s", resp.Status)
+	}
+	return resp.StatusCode, strings.ToValidUTF8(string(respBody), ""), nil
+}